@@ -3,22 +3,70 @@ package app
 import (
 	"yatter-backend-go/app/config"
 	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/lifecycle"
+	"yatter-backend-go/app/logger"
+	"yatter-backend-go/app/mail"
+	"yatter-backend-go/app/policy"
+	"yatter-backend-go/app/ratelimit"
 )
 
 // Dependency manager for whole application
 type App struct {
 	Dao dao.Dao
+
+	// Config is nil in tests, whose Get returns the default configuration
+	Config *config.Store
+
+	// Lifecycle is nil in tests, which is always ready
+	Lifecycle *lifecycle.Manager
+
+	Logger *logger.Logger
+
+	// RateLimit is nil in tests, which throttles nothing
+	RateLimit *ratelimit.Limiter
+
+	// Lockout of failed logins, nil in tests which never locks
+	Lockout *ratelimit.Lockout
+
+	// Mailer is nil in tests unless a mail.Memory is set, nil sends nothing
+	Mailer mail.Mailer
+
+	// Breach checks new passwords against breached ones, nil checks only common passwords
+	Breach policy.BreachChecker
 }
 
 // Create dependency manager
-func NewApp() (*App, error) {
-	// panic if lacking something
-	daoCfg := config.MySQLConfig()
+func NewApp(cfg *config.Store, log *logger.Logger) (*App, error) {
+	c := cfg.Get()
+	dao, err := dao.New(c.MySQL)
+	if err != nil {
+		return nil, err
+	}
 
-	dao, err := dao.New(daoCfg)
+	mailer, err := mail.New(c.Mail)
 	if err != nil {
 		return nil, err
 	}
 
-	return &App{Dao: dao}, nil
+	var breach policy.BreachChecker
+	if c.Accounts.BreachedPasswordsFile != "" {
+		list, err := policy.LoadBreachList(c.Accounts.BreachedPasswordsFile)
+		if err != nil {
+			return nil, err
+		}
+		breach = list
+	}
+
+	return &App{
+		Dao:       dao,
+		Config:    cfg,
+		Lifecycle: lifecycle.New(c.Server.DrainDelay, c.Server.ShutdownTimeout),
+		Logger:    log,
+		RateLimit: ratelimit.New(ratelimit.NewMemoryStore(), func() bool {
+			return cfg.Get().Features.RateLimit
+		}),
+		Lockout: ratelimit.NewLockout(),
+		Mailer:  mailer,
+		Breach:  breach,
+	}, nil
 }
@@ -2,40 +2,432 @@ package config
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net/url"
 	"os"
+	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
+	"yatter-backend-go/app/logger"
+
+	"gopkg.in/yaml.v2"
 )
 
+// Config whole configuration of application
+//
+// Each field can be overridden by the environment variable named in `env` tag.
+// Fields tagged `secret` are redacted when printed, and fields tagged `reload`
+// are replaced when the configuration is reloaded.
+type Config struct {
+	Server       ServerConfig       `yaml:"server"`
+	MySQL        MySQLConfig        `yaml:"mysql"`
+	Media        MediaConfig        `yaml:"media"`
+	Worker       WorkerConfig       `yaml:"worker"`
+	Tracing      TracingConfig      `yaml:"tracing"`
+	Log          LogConfig          `yaml:"log"`
+	Mail         MailConfig         `yaml:"mail"`
+	Registration RegistrationConfig `yaml:"registration"`
+	Accounts     AccountsConfig     `yaml:"accounts"`
+	Features     Features           `yaml:"features" reload:"true"`
+}
+
+// ServerConfig configuration of HTTP server
+type ServerConfig struct {
+	Port int `yaml:"port" env:"PORT"`
+
+	// URL the server is reached at, profiles are under it as `/@username` and media files as `/v1/media/files/`
+	PublicURL string `yaml:"public_url" env:"PUBLIC_URL"`
+
+	ReadTimeout    time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	WriteTimeout   time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout    time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	RequestTimeout time.Duration `yaml:"request_timeout" env:"SERVER_REQUEST_TIMEOUT"`
+
+	// Duration to fail readiness before draining connections on shutdown
+	DrainDelay      time.Duration `yaml:"drain_delay" env:"SERVER_DRAIN_DELAY"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+}
+
+// MediaConfig configuration of media storage and upload limits
+type MediaConfig struct {
+	FilesDir   string `yaml:"files_dir" env:"MEDIA_FILES_DIR"`
+	SigningKey string `yaml:"signing_key" env:"MEDIA_SIGNING_KEY" secret:"true"`
+	FFmpegPath string `yaml:"ffmpeg_path" env:"FFMPEG_PATH"`
+
+	// Directory of account export archives, not served publicly unlike FilesDir
+	ExportsDir string `yaml:"exports_dir" env:"MEDIA_EXPORTS_DIR"`
+
+	ImageSizeLimit int64 `yaml:"image_size_limit" env:"MEDIA_IMAGE_SIZE_LIMIT" reload:"true"`
+	GifvSizeLimit  int64 `yaml:"gifv_size_limit" env:"MEDIA_GIFV_SIZE_LIMIT" reload:"true"`
+	VideoSizeLimit int64 `yaml:"video_size_limit" env:"MEDIA_VIDEO_SIZE_LIMIT" reload:"true"`
+	AudioSizeLimit int64 `yaml:"audio_size_limit" env:"MEDIA_AUDIO_SIZE_LIMIT" reload:"true"`
+	ImageMaxWidth  int   `yaml:"image_max_width" env:"MEDIA_IMAGE_MAX_WIDTH" reload:"true"`
+	ImageMaxHeight int   `yaml:"image_max_height" env:"MEDIA_IMAGE_MAX_HEIGHT" reload:"true"`
+}
+
+// WorkerConfig configuration of background job worker
+type WorkerConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" env:"WORKER_POLL_INTERVAL"`
+	Lease        time.Duration `yaml:"lease" env:"WORKER_LEASE"`
+}
+
+// TracingConfig configuration of OpenTelemetry tracing
+type TracingConfig struct {
+	// One of "none", "stdout" and "otlp"
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER"`
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT"`
+	Insecure    bool    `yaml:"insecure" env:"TRACING_INSECURE"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+	ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME"`
+}
+
+// LogConfig configuration of logging
+type LogConfig struct {
+	// One of "debug", "info", "warn" and "error"
+	Level string `yaml:"level" env:"LOG_LEVEL" reload:"true"`
+}
+
+// MailConfig configuration of outgoing mail
+type MailConfig struct {
+	// One of "smtp", "file" and "memory"
+	Driver string `yaml:"driver" env:"MAIL_DRIVER"`
+	From   string `yaml:"from" env:"MAIL_FROM"`
+
+	SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     int    `yaml:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername string `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+
+	// Directory to write messages of file driver
+	Dir string `yaml:"dir" env:"MAIL_DIR"`
+}
+
+// Registration modes
 const (
-	portKey     = "PORT"
-	defaultPort = 8080
+	// Anyone can register
+	RegistrationOpen = "open"
+
+	// Accounts wait for approval of an admin unless registered with an invite
+	RegistrationApproval = "approval"
+
+	// An invite is required
+	RegistrationInvite = "invite"
+
+	// Registration is refused
+	RegistrationClosed = "closed"
 )
 
-// Read port to listen on
-func Port() int {
-	num, err := getInt(portKey)
-	if err != nil {
-		return defaultPort
+// RegistrationConfig configuration of account registration
+type RegistrationConfig struct {
+	// One of "open", "approval", "invite" and "closed"
+	Mode string `yaml:"mode" env:"REGISTRATION_MODE" reload:"true"`
+}
+
+// AccountsConfig policy of usernames and passwords
+type AccountsConfig struct {
+	// Regular expression which usernames must match, must not allow characters
+	// which have meanings in URL paths such as "/"
+	UsernamePattern   string `yaml:"username_pattern" env:"ACCOUNTS_USERNAME_PATTERN" reload:"true"`
+	UsernameMinLength int    `yaml:"username_min_length" env:"ACCOUNTS_USERNAME_MIN_LENGTH" reload:"true"`
+	UsernameMaxLength int    `yaml:"username_max_length" env:"ACCOUNTS_USERNAME_MAX_LENGTH" reload:"true"`
+
+	// Usernames refused in addition to the built-in ones, compared case-insensitively
+	ReservedUsernames []string `yaml:"reserved_usernames" env:"ACCOUNTS_RESERVED_USERNAMES" reload:"true"`
+
+	PasswordMinLength int `yaml:"password_min_length" env:"ACCOUNTS_PASSWORD_MIN_LENGTH" reload:"true"`
+
+	// File of SHA-1 hashes of breached passwords, one per line in the format of
+	// Have I Been Pwned. Empty checks only the built-in list of common passwords.
+	BreachedPasswordsFile string `yaml:"breached_passwords_file" env:"ACCOUNTS_BREACHED_PASSWORDS_FILE"`
+}
+
+// Features feature flags
+type Features struct {
+	// Accept `POST /v2/media`
+	AsyncMedia bool `yaml:"async_media" env:"FEATURE_ASYNC_MEDIA"`
+
+	// Transcode and extract frames with ffmpeg
+	Transcoding bool `yaml:"transcoding" env:"FEATURE_TRANSCODING"`
+
+	// Throttle requests exceeding the budget of routes
+	RateLimit bool `yaml:"rate_limit" env:"FEATURE_RATE_LIMIT"`
+
+	// Verify signed, expiring URLs of media files with media.signing_key
+	SignedMedia bool `yaml:"signed_media" env:"FEATURE_SIGNED_MEDIA"`
+}
+
+// Default configuration
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:            8080,
+			PublicURL:       "http://localhost:8080",
+			ReadTimeout:     30 * time.Second,
+			WriteTimeout:    5 * time.Minute,
+			IdleTimeout:     2 * time.Minute,
+			RequestTimeout:  60 * time.Second,
+			DrainDelay:      2 * time.Second,
+			ShutdownTimeout: 15 * time.Second,
+		},
+		MySQL: MySQLConfig{
+			TZ:              "Asia/Tokyo",
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 5 * time.Minute,
+		},
+		Media: MediaConfig{
+			FilesDir:       "files",
+			ExportsDir:     "exports",
+			FFmpegPath:     "ffmpeg",
+			ImageSizeLimit: 10 << 20,
+			GifvSizeLimit:  40 << 20,
+			VideoSizeLimit: 100 << 20,
+			AudioSizeLimit: 40 << 20,
+			ImageMaxWidth:  8192,
+			ImageMaxHeight: 8192,
+		},
+		Worker: WorkerConfig{
+			PollInterval: 2 * time.Second,
+			Lease:        5 * time.Minute,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			Endpoint:    "localhost:4318",
+			SampleRatio: 1,
+			ServiceName: "yatter",
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		Mail: MailConfig{
+			Driver:   "file",
+			From:     "yatter@localhost",
+			SMTPPort: 587,
+			Dir:      "mail",
+		},
+		Registration: RegistrationConfig{
+			Mode: RegistrationOpen,
+		},
+		Accounts: AccountsConfig{
+			UsernamePattern:   `^[A-Za-z0-9_]+$`,
+			UsernameMinLength: 1,
+			UsernameMaxLength: 30,
+			PasswordMinLength: 8,
+		},
+		Features: Features{
+			AsyncMedia:  true,
+			Transcoding: true,
+			RateLimit:   true,
+		},
+	}
+}
+
+// Load configuration from YAML file at path and environment variables
+// Empty path reads only environment variables. All problems are reported at once.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	var errs Errors
+
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.UnmarshalStrict(b, cfg); err != nil {
+			if te, ok := err.(*yaml.TypeError); ok {
+				for _, e := range te.Errors {
+					errs = append(errs, fmt.Errorf("%s: %s", path, e))
+				}
+			} else {
+				errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			}
+		}
+	}
+
+	errs = append(errs, applyEnv(reflect.ValueOf(cfg).Elem())...)
+	errs = append(errs, cfg.Validate()...)
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return cfg, nil
+}
+
+// Validate configuration and return all problems
+func (c *Config) Validate() Errors {
+	var errs Errors
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(0 < c.Server.Port && c.Server.Port < 65536, "server.port: %d is out of range", c.Server.Port)
+	u, err := url.Parse(c.Server.PublicURL)
+	check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "server.public_url: %q is not an http(s) URL", c.Server.PublicURL)
+	check(c.Server.ReadTimeout >= 0, "server.read_timeout: must not be negative")
+	check(c.Server.WriteTimeout >= 0, "server.write_timeout: must not be negative")
+	check(c.Server.IdleTimeout >= 0, "server.idle_timeout: must not be negative")
+	check(c.Server.RequestTimeout > 0, "server.request_timeout: must be positive")
+	check(c.Server.DrainDelay >= 0, "server.drain_delay: must not be negative")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout: must be positive")
+
+	errs = append(errs, c.MySQL.validate()...)
+
+	check(c.Media.FilesDir != "", "media.files_dir: is required")
+	check(c.Media.ExportsDir != "", "media.exports_dir: is required")
+	check(c.Media.ImageSizeLimit > 0, "media.image_size_limit: must be positive")
+	check(c.Media.GifvSizeLimit > 0, "media.gifv_size_limit: must be positive")
+	check(c.Media.VideoSizeLimit > 0, "media.video_size_limit: must be positive")
+	check(c.Media.AudioSizeLimit > 0, "media.audio_size_limit: must be positive")
+	check(c.Media.ImageMaxWidth > 0, "media.image_max_width: must be positive")
+	check(c.Media.ImageMaxHeight > 0, "media.image_max_height: must be positive")
+	check(!c.Features.SignedMedia || c.Media.SigningKey != "", "media.signing_key: is required for signed_media feature")
+
+	check(c.Worker.PollInterval > 0, "worker.poll_interval: must be positive")
+	check(c.Worker.Lease > 0, "worker.lease: must be positive")
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		check(c.Tracing.Endpoint != "", "tracing.endpoint: is required for otlp exporter")
+	default:
+		check(false, "tracing.exporter: %q is not one of none, stdout and otlp", c.Tracing.Exporter)
 	}
-	return num
+	_, err = logger.ParseLevel(c.Log.Level)
+	check(err == nil, "log.level: %q is not one of debug, info, warn and error", c.Log.Level)
+
+	check(0 <= c.Tracing.SampleRatio && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio: must be between 0 and 1")
+
+	check(c.Mail.From != "", "mail.from: is required")
+	switch c.Mail.Driver {
+	case "memory":
+	case "file":
+		check(c.Mail.Dir != "", "mail.dir: is required for file driver")
+	case "smtp":
+		check(c.Mail.SMTPHost != "", "mail.smtp_host: is required for smtp driver")
+		check(c.Mail.SMTPPort > 0, "mail.smtp_port: must be positive")
+	default:
+		check(false, "mail.driver: %q is not one of smtp, file and memory", c.Mail.Driver)
+	}
+
+	switch c.Registration.Mode {
+	case RegistrationOpen, RegistrationApproval, RegistrationInvite, RegistrationClosed:
+	default:
+		check(false, "registration.mode: %q is not one of open, approval, invite and closed", c.Registration.Mode)
+	}
+
+	_, err = regexp.Compile(c.Accounts.UsernamePattern)
+	check(err == nil, "accounts.username_pattern: %v", err)
+	check(c.Accounts.UsernameMinLength > 0, "accounts.username_min_length: must be positive")
+	check(c.Accounts.UsernameMaxLength >= c.Accounts.UsernameMinLength, "accounts.username_max_length: must not be less than username_min_length")
+	check(c.Accounts.UsernameMaxLength <= 255, "accounts.username_max_length: must not exceed 255")
+	// bcrypt refuses passwords longer than 72 bytes
+	check(0 < c.Accounts.PasswordMinLength && c.Accounts.PasswordMinLength <= 72, "accounts.password_min_length: must be between 1 and 72")
+
+	return errs
+}
+
+// Errors problems of configuration
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "invalid configuration:\n\t" + strings.Join(msgs, "\n\t")
 }
 
-func getInt(key string) (int, error) {
-	v := os.Getenv(key)
-	if v == "" {
-		return 0, fmt.Errorf("config:[%s] not found", key)
+// applyEnv overrides fields of v by environment variables in `env` tags
+func applyEnv(v reflect.Value) Errors {
+	var errs Errors
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f, fv := t.Field(i), v.Field(i)
+		if f.Type.Kind() == reflect.Struct {
+			errs = append(errs, applyEnv(fv)...)
+			continue
+		}
+
+		key := f.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+		s, ok := os.LookupEnv(key)
+		if !ok || s == "" {
+			continue
+		}
+		if err := setValue(fv, s); err != nil {
+			errs = append(errs, fmt.Errorf("config:[%s] %w", key, err))
+		}
 	}
-	num, err := strconv.Atoi(v)
+	return errs
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func setValue(v reflect.Value, s string) error {
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("should duration: %q", s)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(s)
+	case v.Kind() == reflect.Int || v.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("should number: %q", s)
+		}
+		v.SetInt(n)
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("should number: %q", s)
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("should boolean: %q", s)
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		parts := strings.Split(s, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		v.Set(reflect.ValueOf(parts))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// Redacted returns YAML of the configuration whose secrets are masked
+func (c *Config) Redacted() string {
+	cp := *c
+	redact(reflect.ValueOf(&cp).Elem())
+	b, err := yaml.Marshal(&cp)
 	if err != nil {
-		return 0, fmt.Errorf("config:[%s] should number", key)
+		return err.Error()
 	}
-	return num, nil
+	return string(b)
 }
 
-func getString(key string) (string, error) {
-	v := os.Getenv(key)
-	if v == "" {
-		return "", fmt.Errorf("config:[%s] not found", key)
+func redact(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f, fv := t.Field(i), v.Field(i)
+		if f.Type.Kind() == reflect.Struct && f.Type != durationType {
+			redact(fv)
+		} else if f.Tag.Get("secret") == "true" && fv.Kind() == reflect.String && fv.String() != "" {
+			fv.SetString("[REDACTED]")
+		}
 	}
-	return v, nil
 }
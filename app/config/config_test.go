@@ -0,0 +1,139 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, body string) string {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := ioutil.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func setEnv(t *testing.T, env map[string]string) {
+	for k, v := range env {
+		old, ok := os.LookupEnv(k)
+		os.Setenv(k, v)
+		k := k
+		t.Cleanup(func() {
+			if ok {
+				os.Setenv(k, old)
+			} else {
+				os.Unsetenv(k)
+			}
+		})
+	}
+}
+
+const validConfig = `
+mysql:
+  host: localhost:3306
+  user: yatter
+  password: secret
+  database: yatter
+media:
+  signing_key: key
+  image_size_limit: 100
+`
+
+func TestLoad(t *testing.T) {
+	setEnv(t, map[string]string{"PORT": "9000", "MYSQL_MAX_OPEN_CONNS": "10", "MYSQL_MAX_IDLE_CONNS": "5", "WORKER_LEASE": "1m", "MYSQL_HOST": ""})
+
+	cfg, err := Load(writeConfig(t, validConfig))
+	require.NoError(t, err)
+
+	assert.Equal(t, 9000, cfg.Server.Port)
+	assert.Equal(t, "localhost:3306", cfg.MySQL.Host)
+	assert.Equal(t, 10, cfg.MySQL.MaxOpenConns)
+	assert.Equal(t, int64(100), cfg.Media.ImageSizeLimit)
+	assert.Equal(t, time.Minute, cfg.Worker.Lease)
+	// defaults
+	assert.Equal(t, 60*time.Second, cfg.Server.RequestTimeout)
+	assert.Equal(t, "files", cfg.Media.FilesDir)
+	assert.Contains(t, cfg.MySQL.FormatDSN(), "loc=Asia%2FTokyo")
+}
+
+func TestLoadErrors(t *testing.T) {
+	setEnv(t, map[string]string{"PORT": "http", "MYSQL_HOST": "", "MYSQL_USER": "", "MYSQL_DATABASE": ""})
+
+	_, err := Load(writeConfig(t, `
+mysql:
+  tz: Mars/Base
+  max_open_conns: 1
+  max_idle_conns: 2
+media:
+  image_max_width: 0
+  unknown: 1
+`))
+	require.Error(t, err)
+
+	errs, ok := err.(Errors)
+	require.True(t, ok)
+	msg := errs.Error()
+	for _, want := range []string{
+		"field unknown not found",
+		"config:[PORT] should number",
+		"mysql.host: is required",
+		"mysql.tz: invalid timezone",
+		"mysql.max_idle_conns: 2 exceeds max_open_conns 1",
+		"media.image_max_width: must be positive",
+	} {
+		assert.Contains(t, msg, want)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg, err := Load(writeConfig(t, validConfig))
+	require.NoError(t, err)
+
+	s := cfg.Redacted()
+	assert.NotContains(t, s, "secret")
+	assert.Contains(t, s, "password: '[REDACTED]'")
+	assert.Contains(t, s, "signing_key: '[REDACTED]'")
+	assert.Equal(t, "secret", cfg.MySQL.Password, "original is kept")
+}
+
+func TestStoreReload(t *testing.T) {
+	setEnv(t, map[string]string{"MYSQL_HOST": ""})
+
+	path := writeConfig(t, validConfig)
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	s := NewStore(path, cfg)
+
+	next := strings.Replace(validConfig, "image_size_limit: 100", "image_size_limit: 200", 1)
+	next = strings.Replace(next, "localhost:3306", "db:3306", 1)
+	next += "features:\n  async_media: false\n"
+	require.NoError(t, ioutil.WriteFile(path, []byte(next), 0o600))
+
+	warnings, err := s.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mysql.host: change requires restart"}, warnings)
+
+	got := s.Get()
+	assert.Equal(t, int64(200), got.Media.ImageSizeLimit)
+	assert.False(t, got.Features.AsyncMedia)
+	assert.Equal(t, "localhost:3306", got.MySQL.Host)
+	assert.Equal(t, int64(100), cfg.Media.ImageSizeLimit, "loaded configuration is not modified")
+
+	// invalid configuration keeps the current one
+	require.NoError(t, ioutil.WriteFile(path, []byte("server:\n  port: -1\n"), 0o600))
+	_, err = s.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, got, s.Get())
+}
+
+func TestNilStore(t *testing.T) {
+	var s *Store
+	assert.Equal(t, Default(), s.Get())
+}
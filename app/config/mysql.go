@@ -2,79 +2,69 @@ package config
 
 import (
 	"fmt"
-	"log"
 	"time"
 
+	// embed timezone database, the runtime image may lack it
+	_ "time/tzdata"
+
 	"github.com/go-sql-driver/mysql"
 )
 
-// accessor namespace
-var MySQL _mysql
-
-type _mysql struct{}
+// MySQLConfig configuration of MySQL connection
+type MySQLConfig struct {
+	Host     string `yaml:"host" env:"MYSQL_HOST"`
+	User     string `yaml:"user" env:"MYSQL_USER"`
+	Password string `yaml:"password" env:"MYSQL_PASSWORD" secret:"true"`
+	Database string `yaml:"database" env:"MYSQL_DATABASE"`
+	TZ       string `yaml:"tz" env:"MYSQL_TZ"`
 
-// Read MySQL host
-func (_mysql) Host() string {
-	v, err := getString("MYSQL_HOST")
-	if err != nil {
-		log.Fatal(err)
-	}
-	return v
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"MYSQL_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"MYSQL_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"MYSQL_CONN_MAX_LIFETIME"`
 }
 
-// Read MySQL user
-func (_mysql) User() string {
-	v, err := getString("MYSQL_USER")
-	if err != nil {
-		log.Fatal(err)
+func (c MySQLConfig) validate() Errors {
+	var errs Errors
+	if c.Host == "" {
+		errs = append(errs, fmt.Errorf("mysql.host: is required"))
 	}
-	return v
-}
-
-// Read MySQL password
-func (_mysql) Password() string {
-	v, err := getString("MYSQL_PASSWORD")
-	if err != nil {
-		log.Fatal(err)
+	if c.User == "" {
+		errs = append(errs, fmt.Errorf("mysql.user: is required"))
 	}
-	return v
-}
-
-// Read MySQL database name
-func (_mysql) Database() string {
-	v, err := getString("MYSQL_DATABASE")
-	if err != nil {
-		log.Fatal(err)
+	if c.Database == "" {
+		errs = append(errs, fmt.Errorf("mysql.database: is required"))
 	}
-	return v
-}
-
-// Read Timezone for MySQL
-func (_mysql) Location() *time.Location {
-	tz, err := getString("MYSQL_TZ")
-	if err != nil {
-		return time.FixedZone("Asia/Tokyo", 9*60*60)
+	if _, err := time.LoadLocation(c.TZ); err != nil {
+		errs = append(errs, fmt.Errorf("mysql.tz: invalid timezone %q", c.TZ))
+	}
+	if c.MaxOpenConns < 0 || c.MaxIdleConns < 0 {
+		errs = append(errs, fmt.Errorf("mysql: pool sizes must not be negative"))
+	} else if c.MaxOpenConns > 0 && c.MaxIdleConns > c.MaxOpenConns {
+		errs = append(errs, fmt.Errorf("mysql.max_idle_conns: %d exceeds max_open_conns %d", c.MaxIdleConns, c.MaxOpenConns))
 	}
-	loc, err := time.LoadLocation(tz)
-	if err != nil {
-		log.Fatal(fmt.Errorf("Invalid timezone %+v", tz))
+	if c.ConnMaxLifetime < 0 {
+		errs = append(errs, fmt.Errorf("mysql.conn_max_lifetime: must not be negative"))
 	}
-	return loc
+	return errs
 }
 
-// Build mysql.Config
-func MySQLConfig() *mysql.Config {
+// Build DSN for mysql driver
+func (c MySQLConfig) FormatDSN() string {
 	cfg := mysql.NewConfig()
 
 	cfg.ParseTime = true
-	cfg.Loc = MySQL.Location()
-	if host := MySQL.Host(); host != "" {
-		cfg.Net = "tcp"
-		cfg.Addr = host
-	}
-	cfg.User = MySQL.User()
-	cfg.Passwd = MySQL.Password()
-	cfg.DBName = MySQL.Database()
+	// validated on load
+	cfg.Loc, _ = time.LoadLocation(c.TZ)
+	cfg.Net = "tcp"
+	cfg.Addr = c.Host
+	cfg.User = c.User
+	cfg.Passwd = c.Password
+	cfg.DBName = c.Database
+
+	return cfg.FormatDSN()
+}
 
-	return cfg
+// Connection pool settings
+func (c MySQLConfig) PoolSize() (maxOpen, maxIdle int, maxLifetime time.Duration) {
+	return c.MaxOpenConns, c.MaxIdleConns, c.ConnMaxLifetime
 }
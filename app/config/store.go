@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"yatter-backend-go/app/logger"
+)
+
+// Store holds current configuration which can be reloaded
+type Store struct {
+	path  string
+	mu    sync.Mutex
+	v     atomic.Value
+	hooks []func(*Config)
+}
+
+// Create Store from the configuration loaded from path
+func NewStore(path string, cfg *Config) *Store {
+	s := &Store{path: path}
+	s.v.Store(cfg)
+	return s
+}
+
+// Get current configuration, it must not be modified
+// nil Store returns the default configuration
+func (s *Store) Get() *Config {
+	if s == nil {
+		return Default()
+	}
+	return s.v.Load().(*Config)
+}
+
+// Reload configuration from the file and environment variables
+// Only fields tagged `reload` are applied, other changes are reported as warnings.
+func (s *Store) Reload() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next, err := Load(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	cur := *s.Get()
+	var warnings []string
+	merge(reflect.ValueOf(&cur).Elem(), reflect.ValueOf(next).Elem(), "", false, &warnings)
+	s.v.Store(&cur)
+	for _, h := range s.hooks {
+		h(&cur)
+	}
+
+	return warnings, nil
+}
+
+// OnReload registers h called with the configuration after each reload
+func (s *Store) OnReload(h func(*Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, h)
+}
+
+// merge copies reloadable fields of src into dst and records other changes
+func merge(dst, src reflect.Value, prefix string, reloadable bool, warnings *[]string) {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := prefix + f.Tag.Get("yaml")
+		ok := reloadable || f.Tag.Get("reload") == "true"
+
+		if f.Type.Kind() == reflect.Struct && f.Type != durationType {
+			merge(dst.Field(i), src.Field(i), name+".", ok, warnings)
+			continue
+		}
+		if reflect.DeepEqual(dst.Field(i).Interface(), src.Field(i).Interface()) {
+			continue
+		}
+		if ok {
+			dst.Field(i).Set(src.Field(i))
+		} else {
+			*warnings = append(*warnings, fmt.Sprintf("%s: change requires restart", name))
+		}
+	}
+}
+
+// WatchSignal reloads the configuration on SIGHUP until stop is closed
+func (s *Store) WatchSignal(stop <-chan struct{}) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	defer signal.Stop(ch)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ch:
+			warnings, err := s.Reload()
+			if err != nil {
+				logger.Default().Error("config reload failed, keep current configuration", "error", err)
+				continue
+			}
+			for _, w := range warnings {
+				logger.Default().Warn("config not reloaded", "reason", w)
+			}
+			logger.Default().Info("config reloaded")
+		}
+	}
+}
@@ -3,10 +3,15 @@ package dao
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 	"yatter-backend-go/app/domain/object"
 	"yatter-backend-go/app/domain/repository"
+	"yatter-backend-go/app/logger"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -25,8 +30,11 @@ func NewAccount(db *sqlx.DB) repository.Account {
 
 // FindByUsername : ユーザ名からユーザを取得
 func (r *account) FindByUsername(ctx context.Context, username string) (*object.Account, error) {
+	ctx, end := instrument(ctx, "account.FindByUsername")
+	defer end()
+
 	account := &object.Account{}
-	const findAccountByUsername = `SELECT * FROM account WHERE username = ?`
+	const findAccountByUsername = `SELECT * FROM account WHERE username = ? AND deleted_at IS NULL`
 	err := r.db.QueryRowxContext(ctx, findAccountByUsername, username).StructScan(account)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -40,8 +48,11 @@ func (r *account) FindByUsername(ctx context.Context, username string) (*object.
 
 // FindByUsername : IDからユーザを取得
 func (r *account) FindByID(ctx context.Context, id int64) (*object.Account, error) {
+	ctx, end := instrument(ctx, "account.FindByID")
+	defer end()
+
 	account := &object.Account{}
-	const findAccountByID = `SELECT * FROM account WHERE id = ?`
+	const findAccountByID = `SELECT * FROM account WHERE id = ? AND deleted_at IS NULL`
 	err := r.db.QueryRowxContext(ctx, findAccountByID, id).StructScan(account)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -53,27 +64,90 @@ func (r *account) FindByID(ctx context.Context, id int64) (*object.Account, erro
 	return account, nil
 }
 
-// CreateAccount : username, passwordから新しいアカウントを作成
-func (r *account) CreateAccount(ctx context.Context, username, password string) (int64, error) {
-	const createAccount = `INSERT INTO account (username, password_hash) VALUES (?, ?)`
-	res, err := r.db.ExecContext(ctx, createAccount, username, password)
+// FindByEmail : 確認済みのメールアドレスからユーザを取得
+func (r *account) FindByEmail(ctx context.Context, email string) (*object.Account, error) {
+	ctx, end := instrument(ctx, "account.FindByEmail")
+	defer end()
+
+	account := &object.Account{}
+	const findAccountByEmail = `SELECT * FROM account WHERE email = ? AND email_verified_at IS NOT NULL AND deleted_at IS NULL`
+	err := r.db.QueryRowxContext(ctx, findAccountByEmail, email).StructScan(account)
 	if err != nil {
-		return 0, err
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
 	}
 
-	id, err := res.LastInsertId()
+	return account, nil
+}
+
+// CreateAccount : 新しいアカウントを作成し、招待を使用
+func (r *account) CreateAccount(ctx context.Context, registration *object.Registration) (int64, error) {
+	ctx, end := instrument(ctx, "account.CreateAccount")
+	defer end()
+
+	var emailOrNull, reasonOrNull *string
+	if registration.Email != "" {
+		emailOrNull = &registration.Email
+	}
+	if registration.Reason != "" {
+		reasonOrNull = &registration.Reason
+	}
+
+	var id int64
+	err := Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		var inviteID *int64
+		if registration.InviteCode != "" {
+			invite, err := useInvite(ctx, tx, registration.InviteCode)
+			if err != nil {
+				return err
+			}
+			inviteID = &invite
+		}
+
+		const createAccount = `INSERT INTO account (username, password_hash, email, pending, registration_reason, invite_id)
+								VALUES (?, ?, ?, ?, ?, ?)`
+		res, err := tx.ExecContext(ctx, createAccount, registration.Username, registration.PasswordHash, emailOrNull,
+			registration.Pending, reasonOrNull, inviteID)
+		if key, ok := duplicateKey(err); ok && key == "email" {
+			return repository.NewConflict("account", "email", "email is already taken")
+		} else if ok {
+			return repository.NewConflict("account", "username", "username is already taken")
+		} else if err != nil {
+			return err
+		}
+
+		id, err = res.LastInsertId()
+		return err
+	})
 	if err != nil {
 		return 0, err
 	}
 
-	return id, err
+	return id, nil
 }
 
 // Follow : アカウントをフォロー
 func (r *account) Follow(ctx context.Context, followerID, followeeID int64) (int64, bool, error) {
-	err := Transaction(r.db, func(tx *sqlx.Tx) error {
+	ctx, end := instrument(ctx, "account.Follow")
+	defer end()
+
+	err := Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		var blocks int
+		const findBlocks = `SELECT COUNT(*) FROM account_block
+							WHERE (account_id = ? AND target_account_id = ?) OR (account_id = ? AND target_account_id = ?)`
+		if err := tx.QueryRowxContext(ctx, findBlocks, followerID, followeeID, followeeID, followerID).Scan(&blocks); err != nil {
+			return err
+		} else if blocks > 0 {
+			return repository.NewForbidden("following is blocked")
+		}
+
 		const follow = `INSERT INTO follow (follower_id, followee_id) VALUES (?, ?)`
 		if _, err := tx.ExecContext(ctx, follow, followerID, followeeID); err != nil {
+			if _, ok := duplicateKey(err); ok {
+				return repository.NewConflict("follow", "username", "account is already followed")
+			}
 			return err
 		}
 
@@ -100,10 +174,15 @@ func (r *account) Follow(ctx context.Context, followerID, followeeID int64) (int
 
 // Unfollow : アカウントのフォロー解除
 func (r *account) Unfollow(ctx context.Context, followerID, followeeID int64) (int64, bool, error) {
-	err := Transaction(r.db, func(tx *sqlx.Tx) error {
+	ctx, end := instrument(ctx, "account.Unfollow")
+	defer end()
+
+	err := Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
 		var empty struct{ I, J, K int64 }
 		const following = `SELECT * FROM follow WHERE follower_id = ? AND followee_id = ?`
-		if err := tx.QueryRowxContext(ctx, following, followerID, followeeID).Scan(&empty.I, &empty.J, &empty.K); err != nil {
+		if err := tx.QueryRowxContext(ctx, following, followerID, followeeID).Scan(&empty.I, &empty.J, &empty.K); errors.Is(err, sql.ErrNoRows) {
+			return repository.NewNotFound("follow")
+		} else if err != nil {
 			return err
 		}
 
@@ -153,6 +232,9 @@ func (r *account) manageNumberOfFollows(ctx context.Context, tx *sqlx.Tx, id int
 
 // FindRelationship : 指定したアカウントとのフォロー関係を取得する
 func (r *account) FindRelationship(ctx context.Context, userID, targetID int64) (bool, bool, error) {
+	ctx, end := instrument(ctx, "account.FindRelationship")
+	defer end()
+
 	following, err := r.findRelationship(ctx, userID, targetID)
 	if err != nil {
 		return false, false, err
@@ -166,6 +248,67 @@ func (r *account) FindRelationship(ctx context.Context, userID, targetID int64)
 	return following, followedBy, nil
 }
 
+// Block : アカウントをブロックし、双方向のフォローを解除
+func (r *account) Block(ctx context.Context, accountID, targetID int64) error {
+	ctx, end := instrument(ctx, "account.Block")
+	defer end()
+
+	return Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		const block = `INSERT IGNORE INTO account_block (account_id, target_account_id) VALUES (?, ?)`
+		if _, err := tx.ExecContext(ctx, block, accountID, targetID); err != nil {
+			return err
+		}
+
+		for _, pair := range [][2]int64{{accountID, targetID}, {targetID, accountID}} {
+			const unfollow = `DELETE FROM follow WHERE follower_id = ? AND followee_id = ?`
+			res, err := tx.ExecContext(ctx, unfollow, pair[0], pair[1])
+			if err != nil {
+				return err
+			}
+			if n, err := res.RowsAffected(); err != nil {
+				return err
+			} else if n == 0 {
+				continue
+			}
+			if err := r.manageNumberOfFollows(ctx, tx, pair[0], "following_count", -1); err != nil {
+				return err
+			}
+			if err := r.manageNumberOfFollows(ctx, tx, pair[1], "followers_count", -1); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Mute : アカウントをミュート
+func (r *account) Mute(ctx context.Context, accountID, targetID int64) error {
+	ctx, end := instrument(ctx, "account.Mute")
+	defer end()
+
+	const mute = `INSERT IGNORE INTO account_mute (account_id, target_account_id) VALUES (?, ?)`
+	_, err := r.db.ExecContext(ctx, mute, accountID, targetID)
+	return err
+}
+
+// FindBlockAndMute : ブロック、ミュートしているかを取得
+func (r *account) FindBlockAndMute(ctx context.Context, accountID, targetID int64) (bool, bool, error) {
+	ctx, end := instrument(ctx, "account.FindBlockAndMute")
+	defer end()
+
+	var relation struct {
+		Blocking bool
+		Muting   bool
+	}
+	const find = `SELECT
+					EXISTS(SELECT 1 FROM account_block WHERE account_id = ? AND target_account_id = ?) AS blocking,
+					EXISTS(SELECT 1 FROM account_mute WHERE account_id = ? AND target_account_id = ?) AS muting`
+	if err := r.db.QueryRowxContext(ctx, find, accountID, targetID, accountID, targetID).StructScan(&relation); err != nil {
+		return false, false, err
+	}
+	return relation.Blocking, relation.Muting, nil
+}
+
 func (r *account) findRelationship(ctx context.Context, followerID, followeeID int64) (bool, error) {
 	const query = `SELECT * FROM follow WHERE follower_id = ? AND followee_id = ?`
 	var empty struct{ I, J, K int64 }
@@ -182,6 +325,9 @@ func (r *account) findRelationship(ctx context.Context, followerID, followeeID i
 
 // FindFollowing : フォローしているアカウント情報を取得する
 func (r *account) FindFollowing(ctx context.Context, follower_id, limit int64) ([]object.Account, error) {
+	ctx, end := instrument(ctx, "account.FindFollowing")
+	defer end()
+
 	const findFollowing = `SELECT a.*
 							FROM follow as f
 							JOIN account as a
@@ -198,6 +344,9 @@ func (r *account) FindFollowing(ctx context.Context, follower_id, limit int64) (
 
 // FindFollowers : フォローされているアカウント情報を取得する
 func (r *account) FindFollowers(ctx context.Context, followeeID, maxID, sinceID, limit int64) ([]object.Account, error) {
+	ctx, end := instrument(ctx, "account.FindFollowers")
+	defer end()
+
 	connection := ""
 	idRange, ok := BuildRangeQuery("a.id", maxID, sinceID, 0)
 	if ok {
@@ -219,31 +368,339 @@ func (r *account) FindFollowers(ctx context.Context, followeeID, maxID, sinceID,
 	return accounts, nil
 }
 
-// UpdateCredentials : アカウントの経歴を更新する
-func (r *account) UpdateCredentials(ctx context.Context, id int64, displayName, note, avatar, header string) error {
-	var columns string
+// UpdateCredentials : アカウントの経歴と設定を更新し、新しいリンクの検証ジョブを登録
+// nil の項目は変更せず、空文字列は NULL にする
+// 値が変わらない項目は検証済みの状態を引き継ぐ
+// 置き換えた画像の添付は同じトランザクションで削除し、ファイルはコミット後に消す
+func (r *account) UpdateCredentials(ctx context.Context, id int64, update *object.CredentialsUpdate) error {
+	ctx, end := instrument(ctx, "account.UpdateCredentials")
+	defer end()
+
+	var blobs []string
+	err := Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		var current object.ProfileFields
+		var avatarID, headerID sql.NullInt64
+		const lockAccount = `SELECT fields, avatar_attachment_id, header_attachment_id FROM account WHERE id = ? AND deleted_at IS NULL FOR UPDATE`
+		if err := tx.QueryRowxContext(ctx, lockAccount, id).Scan(&current, &avatarID, &headerID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return repository.NewNotFound("account")
+			}
+			return err
+		}
+
+		var sets []string
+		var args []interface{}
+		set := func(column string, value interface{}) {
+			sets = append(sets, column+" = ?")
+			args = append(args, value)
+		}
+		setString := func(column string, value *string) {
+			if value == nil {
+				return
+			} else if *value == "" {
+				set(column, nil)
+			} else {
+				set(column, *value)
+			}
+		}
+		setBool := func(column string, value *bool) {
+			if value != nil {
+				set(column, *value)
+			}
+		}
+		replaced := []int64{}
+		setImage := func(column string, value *string, attachmentID int64, current sql.NullInt64) {
+			if value == nil {
+				return
+			}
+			setString(column, value)
+			if attachmentID == 0 {
+				set(column+"_attachment_id", nil)
+			} else {
+				set(column+"_attachment_id", attachmentID)
+			}
+			if current.Valid && current.Int64 != attachmentID {
+				replaced = append(replaced, current.Int64)
+			}
+		}
+
+		setString("display_name", update.DisplayName)
+		setString("note", update.Note)
+		setImage("avatar", update.Avatar, update.AvatarAttachmentID, avatarID)
+		setImage("header", update.Header, update.HeaderAttachmentID, headerID)
+		setBool("bot", update.Bot)
+		setBool("discoverable", update.Discoverable)
+		if update.Privacy != nil {
+			set("default_privacy", *update.Privacy)
+		}
+		setBool("default_sensitive", update.Sensitive)
+		setString("default_language", update.Language)
+
+		verify := false
+		if fields := update.Fields; fields != nil {
+			verifiedAt := map[string]*object.DateTime{}
+			for _, f := range current {
+				verifiedAt[f.Name+"\x00"+f.Value] = f.VerifiedAt
+			}
+			updated := make(object.ProfileFields, len(*fields))
+			for i, f := range *fields {
+				f.VerifiedAt = verifiedAt[f.Name+"\x00"+f.Value]
+				if f.VerifiedAt == nil && f.IsLink() {
+					verify = true
+				}
+				updated[i] = f
+			}
+			set("fields", updated)
+		}
+		if len(sets) == 0 {
+			return nil
+		}
+
+		updateCredentials := "UPDATE account SET " + strings.Join(sets, ", ") + " WHERE id = ?"
+		if _, err := tx.ExecContext(ctx, updateCredentials, append(args, id)...); err != nil {
+			return err
+		}
+
+		if len(replaced) > 0 {
+			// an image posted in a status as well is kept for the status
+			findReleased, params, err := sqlx.In(`SELECT id FROM attachment WHERE id IN (?) AND id NOT IN (SELECT attachment_id FROM status_attachment) FOR UPDATE`, replaced)
+			if err != nil {
+				return err
+			}
+			released := []int64{}
+			if err := tx.SelectContext(ctx, &released, findReleased, params...); err != nil {
+				return err
+			}
+			if blobs, err = deleteAttachments(ctx, tx, released); err != nil {
+				return err
+			}
+		}
 
-	credentials := map[string]string{
-		"display_name": displayName,
-		"note":         note,
-		"avatar":       avatar,
-		"header":       header,
+		if !verify {
+			return nil
+		}
+		payload, err := json.Marshal(AccountPayload{AccountID: id})
+		if err != nil {
+			return err
+		}
+		_, err = enqueueJob(ctx, tx, object.JobVerifyProfile, string(payload))
+		return err
+	})
+	if err != nil {
+		return err
 	}
 
-	for name, value := range credentials {
-		if value == "" {
-			continue
+	removeBlobs(blobs)
+	return nil
+}
+
+// VerifyFields : 値が values のいずれかである項目を検証済みにする
+// 検証の間に変更された項目は検証済みにならない
+func (r *account) VerifyFields(ctx context.Context, id int64, values []string) error {
+	ctx, end := instrument(ctx, "account.VerifyFields")
+	defer end()
+
+	verified := map[string]bool{}
+	for _, v := range values {
+		verified[v] = true
+	}
+
+	return Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		var fields object.ProfileFields
+		const lockAccount = `SELECT fields FROM account WHERE id = ? AND deleted_at IS NULL FOR UPDATE`
+		if err := tx.QueryRowxContext(ctx, lockAccount, id).Scan(&fields); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+
+		changed := false
+		now := object.DateTime{Time: time.Now()}
+		for i := range fields {
+			if fields[i].VerifiedAt == nil && verified[fields[i].Value] {
+				fields[i].VerifiedAt = &now
+				changed = true
+			}
+		}
+		if !changed {
+			return nil
+		}
+
+		const updateFields = `UPDATE account SET fields = ? WHERE id = ?`
+		_, err := tx.ExecContext(ctx, updateFields, fields, id)
+		return err
+	})
+}
+
+// UpdatePassword : パスワードを更新し、リセット用トークンと keepTokenID 以外のアクセストークンを削除
+func (r *account) UpdatePassword(ctx context.Context, id int64, passwordHash string, keepTokenID int64) error {
+	ctx, end := instrument(ctx, "account.UpdatePassword")
+	defer end()
+
+	return Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		const update = `UPDATE account SET password_hash = ? WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, update, passwordHash, id); err != nil {
+			return err
 		}
-		if columns != "" {
-			columns += ", "
+
+		const revokeResets = `DELETE FROM account_token WHERE account_id = ? AND purpose = ? AND used_at IS NULL`
+		if _, err := tx.ExecContext(ctx, revokeResets, id, object.TokenPasswordReset); err != nil {
+			return err
 		}
-		columns += fmt.Sprintf("%s = %q", name, value)
+		const revokeSessions = `DELETE FROM account_token WHERE account_id = ? AND purpose = ? AND used_at IS NULL AND id <> ?`
+		_, err := tx.ExecContext(ctx, revokeSessions, id, object.TokenAccess, keepTokenID)
+		return err
+	})
+}
+
+// VerifyEmail : メールアドレスを確認済みにする
+func (r *account) VerifyEmail(ctx context.Context, id int64) error {
+	ctx, end := instrument(ctx, "account.VerifyEmail")
+	defer end()
+
+	const verify = `UPDATE account SET email_verified_at = NOW() WHERE id = ? AND email IS NOT NULL`
+	_, err := r.db.ExecContext(ctx, verify, id)
+	return err
+}
+
+// AccountPayload is payload of jobs about an account, e.g. object.JobPurgeAccount
+type AccountPayload struct {
+	AccountID int64 `json:"account_id"`
+}
+
+// Number of statuses deleted at once while purging
+const purgeBatchSize = 100
+
+// Delete : アカウントを削除済みにし、データ消去ジョブを登録
+// ユーザ名を予約するため行は残し、認証情報とプロフィールのみ消去する
+func (r *account) Delete(ctx context.Context, id int64) error {
+	ctx, end := instrument(ctx, "account.Delete")
+	defer end()
+
+	return Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		return tombstoneAccount(ctx, tx, id)
+	})
+}
+
+// tombstoneAccount clears the account leaving its username and enqueues the purge
+func tombstoneAccount(ctx context.Context, tx *sqlx.Tx, id int64) error {
+	const tombstone = `UPDATE account
+					SET deleted_at = NOW(), password_hash = '', email = NULL, email_verified_at = NULL,
+						display_name = NULL, note = NULL, avatar = NULL, header = NULL,
+						avatar_attachment_id = NULL, header_attachment_id = NULL,
+						fields = NULL, bot = FALSE, discoverable = FALSE, default_language = NULL
+					WHERE id = ? AND deleted_at IS NULL`
+	res, err := tx.ExecContext(ctx, tombstone, id)
+	if err != nil {
+		return err
 	}
-	if columns == "" {
-		return nil
+	if count, err := res.RowsAffected(); err != nil {
+		return err
+	} else if count == 0 {
+		return repository.NewNotFound("account")
 	}
 
-	updateCredentials := fmt.Sprintf("UPDATE account SET %s WHERE id = %d", columns, id)
-	_, err := r.db.ExecContext(ctx, updateCredentials)
+	payload, err := json.Marshal(AccountPayload{AccountID: id})
+	if err != nil {
+		return err
+	}
+	_, err = enqueueJob(ctx, tx, object.JobPurgeAccount, string(payload))
 	return err
 }
+
+// Purge : 削除済みアカウントのステータス、添付ファイル、フォロー、トークンを消去
+// 途中で失敗しても再実行できる
+func (r *account) Purge(ctx context.Context, id int64) error {
+	ctx, end := instrument(ctx, "account.Purge")
+	defer end()
+
+	// statuses are deleted one by one to release their attachments
+	statuses := NewStatus(r.db)
+	for {
+		ids := []int64{}
+		const findStatuses = `SELECT id FROM status WHERE account_id = ? LIMIT ?`
+		if err := r.db.SelectContext(ctx, &ids, findStatuses, id, purgeBatchSize); err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		for _, statusID := range ids {
+			var notFound *repository.NotFoundError
+			if err := statuses.DeleteByID(ctx, statusID); err != nil && !errors.As(err, &notFound) {
+				return err
+			}
+		}
+	}
+
+	var exports, blobs []string
+	err := Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		followees := []int64{}
+		const findFollowees = `SELECT followee_id FROM follow WHERE follower_id = ? FOR UPDATE`
+		if err := tx.SelectContext(ctx, &followees, findFollowees, id); err != nil {
+			return err
+		}
+		for _, followeeID := range followees {
+			if err := r.manageNumberOfFollows(ctx, tx, followeeID, "followers_count", -1); err != nil {
+				return err
+			}
+		}
+
+		followers := []int64{}
+		const findFollowers = `SELECT follower_id FROM follow WHERE followee_id = ? FOR UPDATE`
+		if err := tx.SelectContext(ctx, &followers, findFollowers, id); err != nil {
+			return err
+		}
+		for _, followerID := range followers {
+			if err := r.manageNumberOfFollows(ctx, tx, followerID, "following_count", -1); err != nil {
+				return err
+			}
+		}
+
+		const unfollow = `DELETE FROM follow WHERE follower_id = ? OR followee_id = ?`
+		if _, err := tx.ExecContext(ctx, unfollow, id, id); err != nil {
+			return err
+		}
+		const resetCounts = `UPDATE account SET followers_count = 0, following_count = 0 WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, resetCounts, id); err != nil {
+			return err
+		}
+
+		const findExports = `SELECT file_path FROM account_export WHERE account_id = ? AND file_path IS NOT NULL`
+		if err := tx.SelectContext(ctx, &exports, findExports, id); err != nil {
+			return err
+		}
+
+		// the statuses are gone, so the rest are avatars, headers and uploads never posted
+		attachmentIDs := []int64{}
+		const findAttachments = `SELECT id FROM attachment WHERE account_id = ? FOR UPDATE`
+		if err := tx.SelectContext(ctx, &attachmentIDs, findAttachments, id); err != nil {
+			return err
+		}
+		var err error
+		if blobs, err = deleteAttachments(ctx, tx, attachmentIDs); err != nil {
+			return err
+		}
+
+		for _, table := range []string{"account_token", "recovery_code", "two_factor", "login_activity", "account_export", "account_import", "account_block", "account_mute"} {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE account_id = ?", id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// archives and blobs are removed after commit, the rows are gone so retrying cannot find them
+	for _, path := range exports {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logger.Default().Error("can't remove account export", "path", path, "error", err)
+		}
+	}
+	removeBlobs(blobs)
+
+	return nil
+}
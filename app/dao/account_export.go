@@ -0,0 +1,97 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type (
+	// Implementation for repository.AccountExport
+	accountExport struct {
+		db *sqlx.DB
+	}
+)
+
+// Create account export repository
+func NewAccountExport(db *sqlx.DB) repository.AccountExport {
+	return &accountExport{db: db}
+}
+
+// ExportAccountPayload is payload of object.JobExportAccount
+type ExportAccountPayload struct {
+	ExportID int64 `json:"export_id"`
+}
+
+// Create : エクスポートを登録し、作成ジョブを登録
+func (r *accountExport) Create(ctx context.Context, accountID int64) (*object.AccountExport, error) {
+	ctx, end := instrument(ctx, "accountExport.Create")
+	defer end()
+
+	var id int64
+	err := Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		const insert = `INSERT INTO account_export (account_id) VALUES (?)`
+		res, err := tx.ExecContext(ctx, insert, accountID)
+		if err != nil {
+			return err
+		}
+		id, err = res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		payload, err := json.Marshal(ExportAccountPayload{ExportID: id})
+		if err != nil {
+			return err
+		}
+		_, err = enqueueJob(ctx, tx, object.JobExportAccount, string(payload))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.FindByID(ctx, id)
+}
+
+// FindByID : IDからエクスポートを取得
+func (r *accountExport) FindByID(ctx context.Context, id int64) (*object.AccountExport, error) {
+	ctx, end := instrument(ctx, "accountExport.FindByID")
+	defer end()
+
+	export := &object.AccountExport{}
+	const find = `SELECT * FROM account_export WHERE id = ?`
+	if err := r.db.QueryRowxContext(ctx, find, id).StructScan(export); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// Complete : エクスポートを完了にする
+func (r *accountExport) Complete(ctx context.Context, id int64, path string) error {
+	ctx, end := instrument(ctx, "accountExport.Complete")
+	defer end()
+
+	const complete = `UPDATE account_export SET state = 'ready', file_path = ?, completed_at = NOW() WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, complete, path, id)
+	return err
+}
+
+// Fail : エクスポートを失敗にする
+func (r *accountExport) Fail(ctx context.Context, id int64) error {
+	ctx, end := instrument(ctx, "accountExport.Fail")
+	defer end()
+
+	const fail = `UPDATE account_export SET state = 'failed', completed_at = NOW() WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, fail, id)
+	return err
+}
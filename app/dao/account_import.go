@@ -0,0 +1,123 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type (
+	// Implementation for repository.AccountImport
+	accountImport struct {
+		db *sqlx.DB
+	}
+)
+
+// Create account import repository
+func NewAccountImport(db *sqlx.DB) repository.AccountImport {
+	return &accountImport{db: db}
+}
+
+// ImportAccountPayload is payload of object.JobImportAccount
+type ImportAccountPayload struct {
+	ImportID int64 `json:"import_id"`
+}
+
+// Create : インポートを登録し、適用ジョブを登録
+func (r *accountImport) Create(ctx context.Context, accountID int64, importType, data string, totalRows int) (*object.AccountImport, error) {
+	ctx, end := instrument(ctx, "accountImport.Create")
+	defer end()
+
+	var id int64
+	err := Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		const insert = `INSERT INTO account_import (account_id, type, data, total_rows) VALUES (?, ?, ?, ?)`
+		res, err := tx.ExecContext(ctx, insert, accountID, importType, data, totalRows)
+		if err != nil {
+			return err
+		}
+		id, err = res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		payload, err := json.Marshal(ImportAccountPayload{ImportID: id})
+		if err != nil {
+			return err
+		}
+		_, err = enqueueJob(ctx, tx, object.JobImportAccount, string(payload))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.FindByID(ctx, id)
+}
+
+// FindByID : IDからインポートと失敗した行を取得
+func (r *accountImport) FindByID(ctx context.Context, id int64) (*object.AccountImport, error) {
+	ctx, end := instrument(ctx, "accountImport.FindByID")
+	defer end()
+
+	i := &object.AccountImport{}
+	const find = `SELECT * FROM account_import WHERE id = ?`
+	if err := r.db.QueryRowxContext(ctx, find, id).StructScan(i); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	i.Failures = []object.ImportFailure{}
+	const findFailures = `SELECT row_index, acct, reason FROM account_import_failure WHERE import_id = ? ORDER BY row_index`
+	if err := r.db.SelectContext(ctx, &i.Failures, findFailures, id); err != nil {
+		return nil, err
+	}
+
+	return i, nil
+}
+
+// Progress : 処理済みの行数を更新し、失敗した行を追加
+func (r *accountImport) Progress(ctx context.Context, id int64, processedRows int, failures []object.ImportFailure) error {
+	ctx, end := instrument(ctx, "accountImport.Progress")
+	defer end()
+
+	return Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		const progress = `UPDATE account_import SET processed_rows = ? WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, progress, processedRows, id); err != nil {
+			return err
+		}
+		if len(failures) == 0 {
+			return nil
+		}
+
+		type failure struct {
+			ImportID int64 `db:"import_id"`
+			object.ImportFailure
+		}
+		rows := make([]failure, len(failures))
+		for i, f := range failures {
+			rows[i] = failure{ImportID: id, ImportFailure: f}
+		}
+		// rows of a retried batch are already recorded
+		const insert = `INSERT IGNORE INTO account_import_failure (import_id, row_index, acct, reason)
+						VALUES (:import_id, :row_index, :acct, :reason)`
+		_, err := tx.NamedExecContext(ctx, insert, rows)
+		return err
+	})
+}
+
+// Finish : インポートを完了または失敗にする
+func (r *accountImport) Finish(ctx context.Context, id int64, state string) error {
+	ctx, end := instrument(ctx, "accountImport.Finish")
+	defer end()
+
+	const finish = `UPDATE account_import SET state = ?, completed_at = NOW() WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, finish, state, id)
+	return err
+}
@@ -0,0 +1,65 @@
+package dao_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/suite"
+)
+
+type AccountPasswordTestSuite struct {
+	DatabaseTestSuite
+
+	repo repository.Account
+}
+
+func (s *AccountPasswordTestSuite) SetupTest() {
+	s.setupSuite()
+
+	s.repo = dao.NewAccount(s.sqlxDB)
+}
+
+func (s *AccountPasswordTestSuite) TearDownTest() {
+	s.tearDownSuite()
+}
+
+func TestAccountPasswordSuite(t *testing.T) {
+	suite.Run(t, new(AccountPasswordTestSuite))
+}
+
+func (s *AccountPasswordTestSuite) TestUpdatePasswordKeepsCurrentToken() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE account SET password_hash = ? WHERE id = ?`)).
+		WithArgs("hash", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM account_token WHERE account_id = ? AND purpose = ? AND used_at IS NULL`)).
+		WithArgs(1, "password_reset").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM account_token WHERE account_id = ? AND purpose = ? AND used_at IS NULL AND id <> ?`)).
+		WithArgs(1, "access", 7).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectCommit()
+
+	s.Require().NoError(s.repo.UpdatePassword(context.Background(), 1, "hash", 7))
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *AccountPasswordTestSuite) TestUpdatePasswordRollsBackWithoutRevocation() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE account SET password_hash = ? WHERE id = ?`)).
+		WithArgs("hash", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM account_token`)).
+		WillReturnError(errors.New("connection lost"))
+	s.mock.ExpectRollback()
+
+	s.Assert().Error(s.repo.UpdatePassword(context.Background(), 1, "hash", 0))
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
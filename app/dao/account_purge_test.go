@@ -0,0 +1,170 @@
+package dao_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/suite"
+)
+
+type AccountPurgeTestSuite struct {
+	DatabaseTestSuite
+
+	repo repository.Account
+}
+
+func (s *AccountPurgeTestSuite) SetupTest() {
+	s.setupSuite()
+
+	s.repo = dao.NewAccount(s.sqlxDB)
+}
+
+func (s *AccountPurgeTestSuite) TearDownTest() {
+	s.tearDownSuite()
+}
+
+func TestAccountPurgeSuite(t *testing.T) {
+	suite.Run(t, new(AccountPurgeTestSuite))
+}
+
+func (s *AccountPurgeTestSuite) TestDeleteLeavesTombstone() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(`UPDATE account\s+SET deleted_at = NOW\(\)`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO job (kind, payload) VALUES (?, ?)`)).
+		WithArgs("purge_account", `{"account_id":1}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
+
+	s.Require().NoError(s.repo.Delete(context.Background(), 1))
+
+	// deleting twice is not found
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(`UPDATE account\s+SET deleted_at = NOW\(\)`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectRollback()
+
+	var notFound *repository.NotFoundError
+	s.Assert().ErrorAs(s.repo.Delete(context.Background(), 1), &notFound)
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *AccountPurgeTestSuite) TestPurgeFixesFollowCounts() {
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM status WHERE account_id = ?`)).
+		WithArgs(1, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT followee_id FROM follow WHERE follower_id = ?`)).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"followee_id"}).AddRow(2).AddRow(3))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE account SET followers_count = followers_count - 1 WHERE id = 2`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE account SET followers_count = followers_count - 1 WHERE id = 3`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT follower_id FROM follow WHERE followee_id = ?`)).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"follower_id"}).AddRow(2))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE account SET following_count = following_count - 1 WHERE id = 2`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM follow WHERE follower_id = ? OR followee_id = ?`)).
+		WithArgs(1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE account SET followers_count = 0, following_count = 0 WHERE id = ?`)).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(`SELECT file_path FROM account_export`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"file_path"}))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM attachment WHERE account_id = ?`)).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	for _, table := range []string{"account_token", "recovery_code", "two_factor", "login_activity", "account_export", "account_import", "account_block", "account_mute"} {
+		s.mock.ExpectExec(regexp.QuoteMeta("DELETE FROM " + table + " WHERE account_id = ?")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	s.mock.ExpectCommit()
+
+	s.Require().NoError(s.repo.Purge(context.Background(), 1))
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *AccountPurgeTestSuite) TestPurgeRemovesAttachments() {
+	const avatar = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	const shared = "fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210"
+
+	fileDir := s.T().TempDir()
+	avatarPath := filepath.Join(fileDir, avatar+".png")
+	sharedPath := filepath.Join(fileDir, shared+".png")
+	for _, p := range []string{avatarPath, sharedPath} {
+		s.Require().NoError(os.WriteFile(p, []byte("x"), 0644))
+	}
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM status WHERE account_id = ?`)).
+		WithArgs(1, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(`SELECT followee_id FROM follow`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"followee_id"}))
+	s.mock.ExpectQuery(`SELECT follower_id FROM follow`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"follower_id"}))
+	s.mock.ExpectExec(`DELETE FROM follow`).
+		WithArgs(1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(`UPDATE account SET followers_count = 0`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(`SELECT file_path FROM account_export`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"file_path"}))
+	// the avatar and an upload never posted, whose blob is shared with another account
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM attachment WHERE account_id = ?`)).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4).AddRow(5))
+	s.mock.ExpectQuery(`SELECT blob_hash FROM attachment`).
+		WithArgs(4, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"blob_hash"}).AddRow(avatar).AddRow(shared))
+	s.mock.ExpectExec(`DELETE FROM attachment`).
+		WithArgs(4, 5).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectQuery(`SELECT path, ref_count FROM media_blob`).
+		WithArgs(avatar).
+		WillReturnRows(sqlmock.NewRows([]string{"path", "ref_count"}).AddRow(avatarPath, 1))
+	s.mock.ExpectExec(`DELETE FROM media_blob`).
+		WithArgs(avatar).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(`SELECT path, ref_count FROM media_blob`).
+		WithArgs(shared).
+		WillReturnRows(sqlmock.NewRows([]string{"path", "ref_count"}).AddRow(sharedPath, 2))
+	s.mock.ExpectExec(`UPDATE media_blob SET ref_count = ref_count - 1`).
+		WithArgs(shared).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	for _, table := range []string{"account_token", "recovery_code", "two_factor", "login_activity", "account_export", "account_import", "account_block", "account_mute"} {
+		s.mock.ExpectExec(regexp.QuoteMeta("DELETE FROM " + table + " WHERE account_id = ?")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	s.mock.ExpectCommit()
+
+	s.Require().NoError(s.repo.Purge(context.Background(), 1))
+
+	_, err := os.Stat(avatarPath)
+	s.Assert().True(os.IsNotExist(err))
+	_, err = os.Stat(sharedPath)
+	s.Assert().NoError(err)
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
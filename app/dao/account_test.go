@@ -11,6 +11,7 @@ import (
 	"time"
 	"yatter-backend-go/app/dao"
 	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
 
 	"github.com/go-sql-driver/mysql"
 	_ "github.com/go-sql-driver/mysql"
@@ -180,7 +181,7 @@ func TestAccount_Create(t *testing.T) {
 
 	for name, tt := range cases {
 		t.Run(name, func(t *testing.T) {
-			got, err := repo.CreateAccount(ctx, tt.args.name, tt.args.password)
+			got, err := repo.CreateAccount(ctx, &object.Registration{Username: tt.args.name, PasswordHash: tt.args.password})
 			assert.Equal(t, tt.want.id, got)
 			assert.Equal(t, tt.want.err, err)
 		})
@@ -259,10 +260,7 @@ func TestAccount_Follow(t *testing.T) {
 		err        error
 	}
 
-	ErrAlreadyFollowed := &mysql.MySQLError{
-		Number:  0x426,
-		Message: "Duplicate entry '1-2' for key 'follow_combination'",
-	}
+	ErrAlreadyFollowed := repository.NewConflict("follow", "username", "account is already followed")
 
 	cases := map[string]struct {
 		args args
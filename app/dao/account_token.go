@@ -0,0 +1,90 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"yatter-backend-go/app/domain/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type (
+	// Implementation for repository.AccountToken
+	accountToken struct {
+		db *sqlx.DB
+	}
+)
+
+// Create account token repository
+func NewAccountToken(db *sqlx.DB) repository.AccountToken {
+	return &accountToken{db: db}
+}
+
+// Create : トークンのハッシュを有効期限付きで保存
+func (r *accountToken) Create(ctx context.Context, accountID int64, purpose, tokenHash string, expiresAt time.Time) error {
+	ctx, end := instrument(ctx, "accountToken.Create")
+	defer end()
+
+	const insert = `INSERT INTO account_token (account_id, purpose, token_hash, expires_at) VALUES (?, ?, ?, ?)`
+	_, err := r.db.ExecContext(ctx, insert, accountID, purpose, tokenHash, expiresAt)
+	return err
+}
+
+// Use : 有効なトークンを使用済みにしてアカウントIDを返す
+func (r *accountToken) Use(ctx context.Context, purpose, tokenHash string) (int64, error) {
+	ctx, end := instrument(ctx, "accountToken.Use")
+	defer end()
+
+	var accountID int64
+	err := Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		const find = `SELECT id, account_id FROM account_token
+					WHERE token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?
+					FOR UPDATE`
+		var id int64
+		if err := tx.QueryRowxContext(ctx, find, tokenHash, purpose, time.Now()).Scan(&id, &accountID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return repository.NewNotFound("token")
+			}
+			return err
+		}
+
+		const use = `UPDATE account_token SET used_at = NOW() WHERE id = ?`
+		_, err := tx.ExecContext(ctx, use, id)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return accountID, nil
+}
+
+// Find : 有効なトークンのIDとアカウントIDを返す、トークンは使用済みにしない
+func (r *accountToken) Find(ctx context.Context, purpose, tokenHash string) (int64, int64, error) {
+	ctx, end := instrument(ctx, "accountToken.Find")
+	defer end()
+
+	var id, accountID int64
+	const find = `SELECT id, account_id FROM account_token
+				WHERE token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?`
+	if err := r.db.QueryRowxContext(ctx, find, tokenHash, purpose, time.Now()).Scan(&id, &accountID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, 0, repository.NewNotFound("token")
+		}
+		return 0, 0, err
+	}
+
+	return id, accountID, nil
+}
+
+// Revoke : 未使用のトークンを削除
+func (r *accountToken) Revoke(ctx context.Context, accountID int64, purpose string) error {
+	ctx, end := instrument(ctx, "accountToken.Revoke")
+	defer end()
+
+	const revoke = `DELETE FROM account_token WHERE account_id = ? AND purpose = ? AND used_at IS NULL`
+	_, err := r.db.ExecContext(ctx, revoke, accountID, purpose)
+	return err
+}
@@ -0,0 +1,70 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"yatter-backend-go/app/domain/object"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type (
+	// accountState is the moderation state of account in the action log
+	accountState struct {
+		Role         string           `json:"role"`
+		SuspendedAt  *object.DateTime `json:"suspended_at" db:"suspended_at"`
+		SilencedAt   *object.DateTime `json:"silenced_at" db:"silenced_at"`
+		SensitizedAt *object.DateTime `json:"sensitized_at" db:"sensitized_at"`
+		Pending      bool             `json:"pending"`
+		DeletedAt    *object.DateTime `json:"deleted_at" db:"deleted_at"`
+	}
+
+	// statusState is the deleted status in the action log
+	statusState struct {
+		ID        int64           `json:"id"`
+		AccountID int64           `json:"account_id" db:"account_id"`
+		Content   string          `json:"content"`
+		Sensitive bool            `json:"sensitive"`
+		CreateAt  object.DateTime `json:"create_at" db:"create_at"`
+		MediaIDs  []int64         `json:"media_ids" db:"-"`
+	}
+
+	// reportState is the state of report in the action log
+	reportState struct {
+		State             string `json:"state"`
+		AssignedAccountID *int64 `json:"assigned_account_id" db:"assigned_account_id"`
+	}
+
+	// noteState is the added note in the action log
+	noteState struct {
+		Content string `json:"content"`
+	}
+)
+
+// recordAction appends an action to admin_action_log in tx of the action
+// before and after are encoded as JSON, nil is recorded as NULL.
+func recordAction(ctx context.Context, tx *sqlx.Tx, actorID int64, action, targetType string, targetID int64, before, after interface{}) error {
+	log := object.ActionLog{
+		AccountID:  actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+	}
+
+	var err error
+	if before != nil {
+		if log.Before, err = json.Marshal(before); err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		if log.After, err = json.Marshal(after); err != nil {
+			return err
+		}
+	}
+
+	const insert = `INSERT INTO admin_action_log (account_id, action, target_type, target_id, before_state, after_state)
+					VALUES (:account_id, :action, :target_type, :target_id, :before_state, :after_state)`
+	_, err = tx.NamedExecContext(ctx, insert, log)
+	return err
+}
@@ -0,0 +1,292 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type (
+	// Implementation for repository.Admin
+	admin struct {
+		db *sqlx.DB
+	}
+)
+
+// Create admin repository
+func NewAdmin(db *sqlx.DB) repository.Admin {
+	return &admin{db: db}
+}
+
+// Conditions of object.AccountFilter.Status
+var accountStatusConditions = map[string]string{
+	object.AccountActive:     "suspended_at IS NULL AND silenced_at IS NULL AND deleted_at IS NULL AND NOT pending",
+	object.AccountSuspended:  "suspended_at IS NOT NULL",
+	object.AccountSilenced:   "silenced_at IS NOT NULL",
+	object.AccountSensitized: "sensitized_at IS NOT NULL",
+	object.AccountDeleted:    "deleted_at IS NOT NULL",
+	object.AccountPending:    "pending AND deleted_at IS NULL",
+}
+
+// Updates of moderation actions on account
+var moderationUpdates = map[string]string{
+	object.ActionSuspend:   "suspended_at = COALESCE(suspended_at, NOW())",
+	object.ActionUnsuspend: "suspended_at = NULL",
+	object.ActionSilence:   "silenced_at = COALESCE(silenced_at, NOW())",
+	object.ActionUnsilence: "silenced_at = NULL",
+	object.ActionSensitive: "sensitized_at = COALESCE(sensitized_at, NOW())",
+	object.ActionApprove:   "pending = FALSE",
+}
+
+// ListAccounts : 条件に一致するアカウントを新しい順に取得
+func (r *admin) ListAccounts(ctx context.Context, filter object.AccountFilter, maxID, sinceID, limit int64) ([]object.Account, error) {
+	ctx, end := instrument(ctx, "admin.ListAccounts")
+	defer end()
+
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.Username != "" {
+		conditions = append(conditions, "username LIKE ?")
+		args = append(args, "%"+escapeLike(filter.Username)+"%")
+	}
+	if filter.Email != "" {
+		conditions = append(conditions, "email = ?")
+		args = append(args, filter.Email)
+	}
+	if filter.Role != "" {
+		conditions = append(conditions, "role = ?")
+		args = append(args, filter.Role)
+	}
+	if filter.Status != "" {
+		condition, ok := accountStatusConditions[filter.Status]
+		if !ok {
+			return nil, repository.NewValidation("status", "ERR_INVALID", "is not included in the list")
+		}
+		conditions = append(conditions, condition)
+	}
+	if maxID != 0 {
+		conditions = append(conditions, "id <= ?")
+		args = append(args, maxID)
+	}
+	if sinceID != 0 {
+		conditions = append(conditions, "id >= ?")
+		args = append(args, sinceID)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	listAccounts := fmt.Sprintf(`SELECT * FROM account %s ORDER BY id DESC LIMIT ?`, where)
+	args = append(args, limit)
+
+	accounts := []object.Account{}
+	if err := r.db.SelectContext(ctx, &accounts, listAccounts, args...); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+// escapeLike escapes wildcards of LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// FindAccount : 削除済みを含めてIDからアカウントを取得
+func (r *admin) FindAccount(ctx context.Context, id int64) (*object.Account, error) {
+	ctx, end := instrument(ctx, "admin.FindAccount")
+	defer end()
+
+	account := &object.Account{}
+	const findAccount = `SELECT * FROM account WHERE id = ?`
+	if err := r.db.QueryRowxContext(ctx, findAccount, id).StructScan(account); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// ModerateAccount : アカウントにモデレーションを適用し、操作を記録
+func (r *admin) ModerateAccount(ctx context.Context, id, actorID int64, action string) error {
+	ctx, end := instrument(ctx, "admin.ModerateAccount")
+	defer end()
+
+	// rejected accounts are deleted instead of updated
+	update, ok := moderationUpdates[action]
+	if !ok && action != object.ActionReject {
+		return repository.NewValidation("action", "ERR_INVALID", "is not included in the list")
+	}
+
+	return Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		before, err := lockAccount(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if (action == object.ActionApprove || action == object.ActionReject) && !before.Pending {
+			return repository.NewConflict("account", "pending", "account is not pending approval")
+		}
+
+		if action == object.ActionReject {
+			if err := tombstoneAccount(ctx, tx, id); err != nil {
+				return err
+			}
+		} else if _, err := tx.ExecContext(ctx, "UPDATE account SET "+update+" WHERE id = ?", id); err != nil {
+			return err
+		}
+
+		if action == object.ActionSensitive {
+			// statuses posted later are marked on creation
+			const markStatuses = `UPDATE status SET sensitive = TRUE
+								WHERE account_id = ? AND id IN (SELECT status_id FROM status_attachment)`
+			if _, err := tx.ExecContext(ctx, markStatuses, id); err != nil {
+				return err
+			}
+		}
+
+		after, err := findAccountState(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		return recordAction(ctx, tx, actorID, action, object.TargetAccount, id, before, after)
+	})
+}
+
+// ChangeRole : アカウントの権限を変更し、操作を記録
+func (r *admin) ChangeRole(ctx context.Context, id, actorID int64, role string) error {
+	ctx, end := instrument(ctx, "admin.ChangeRole")
+	defer end()
+
+	if !object.ValidRole(role) {
+		return repository.NewValidation("role", "ERR_INVALID", "is not included in the list")
+	}
+
+	return Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		before, err := lockAccount(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		const changeRole = `UPDATE account SET role = ? WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, changeRole, role, id); err != nil {
+			return err
+		}
+
+		after := *before
+		after.Role = role
+		return recordAction(ctx, tx, actorID, object.ActionChangeRole, object.TargetAccount, id, before, &after)
+	})
+}
+
+// DeleteStatus : ステータスを削除し、削除前の内容を記録
+func (r *admin) DeleteStatus(ctx context.Context, id, actorID int64) error {
+	ctx, end := instrument(ctx, "admin.DeleteStatus")
+	defer end()
+
+	var blobs []string
+	err := Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		before := &statusState{}
+		const lock = `SELECT id, account_id, content, sensitive, create_at FROM status WHERE id = ? FOR UPDATE`
+		if err := tx.QueryRowxContext(ctx, lock, id).StructScan(before); errors.Is(err, sql.ErrNoRows) {
+			return repository.NewNotFound("status")
+		} else if err != nil {
+			return err
+		}
+		before.MediaIDs = []int64{}
+		const findMedia = `SELECT attachment_id FROM status_attachment WHERE status_id = ? ORDER BY attachment_id`
+		if err := tx.SelectContext(ctx, &before.MediaIDs, findMedia, id); err != nil {
+			return err
+		}
+
+		var err error
+		if blobs, err = deleteStatus(ctx, tx, id); err != nil {
+			return err
+		}
+		return recordAction(ctx, tx, actorID, object.ActionDeleteStatus, object.TargetStatus, id, before, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	removeBlobs(blobs)
+	return nil
+}
+
+// ListActionLogs : 条件に一致する操作記録を新しい順に取得
+func (r *admin) ListActionLogs(ctx context.Context, filter object.ActionLogFilter, maxID, sinceID, limit int64) ([]object.ActionLog, error) {
+	ctx, end := instrument(ctx, "admin.ListActionLogs")
+	defer end()
+
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.AccountID != 0 {
+		conditions = append(conditions, "account_id = ?")
+		args = append(args, filter.AccountID)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.TargetType != "" {
+		conditions = append(conditions, "target_type = ?")
+		args = append(args, filter.TargetType)
+		if filter.TargetID != 0 {
+			conditions = append(conditions, "target_id = ?")
+			args = append(args, filter.TargetID)
+		}
+	}
+	if maxID != 0 {
+		conditions = append(conditions, "id <= ?")
+		args = append(args, maxID)
+	}
+	if sinceID != 0 {
+		conditions = append(conditions, "id >= ?")
+		args = append(args, sinceID)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	list := fmt.Sprintf(`SELECT * FROM admin_action_log %s ORDER BY id DESC LIMIT ?`, where)
+	args = append(args, limit)
+
+	logs := []object.ActionLog{}
+	if err := r.db.SelectContext(ctx, &logs, list, args...); err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+// lockAccount locks account row which is not deleted and returns its state, NotFoundError if it does not exist
+func lockAccount(ctx context.Context, tx *sqlx.Tx, id int64) (*accountState, error) {
+	state := &accountState{}
+	const lock = `SELECT role, suspended_at, silenced_at, sensitized_at, pending, deleted_at FROM account WHERE id = ? AND deleted_at IS NULL FOR UPDATE`
+	if err := tx.QueryRowxContext(ctx, lock, id).StructScan(state); errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.NewNotFound("account")
+	} else if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// findAccountState reads state of the locked account
+func findAccountState(ctx context.Context, tx *sqlx.Tx, id int64) (*accountState, error) {
+	state := &accountState{}
+	const find = `SELECT role, suspended_at, silenced_at, sensitized_at, pending, deleted_at FROM account WHERE id = ?`
+	if err := tx.QueryRowxContext(ctx, find, id).StructScan(state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
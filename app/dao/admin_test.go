@@ -0,0 +1,159 @@
+package dao_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/suite"
+)
+
+type AdminTestSuite struct {
+	DatabaseTestSuite
+
+	repo repository.Admin
+}
+
+func (s *AdminTestSuite) SetupTest() {
+	s.setupSuite()
+
+	s.repo = dao.NewAdmin(s.sqlxDB)
+}
+
+func (s *AdminTestSuite) TearDownTest() {
+	s.tearDownSuite()
+}
+
+func TestAdminSuite(t *testing.T) {
+	suite.Run(t, new(AdminTestSuite))
+}
+
+func (s *AdminTestSuite) TestListAccountsFilter() {
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM account WHERE username LIKE ? AND role = ? AND suspended_at IS NOT NULL AND id <= ? ORDER BY id DESC LIMIT ?`)).
+		WithArgs(`%j\_n%`, object.RoleUser, 10, 40).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(3, "j_nn"))
+
+	accounts, err := s.repo.ListAccounts(context.Background(), object.AccountFilter{
+		Username: "j_n",
+		Role:     object.RoleUser,
+		Status:   object.AccountSuspended,
+	}, 10, 0, 40)
+	s.Require().NoError(err)
+	s.Assert().Len(accounts, 1)
+
+	_, err = s.repo.ListAccounts(context.Background(), object.AccountFilter{Status: "banned"}, 0, 0, 40)
+	var validation *repository.ValidationError
+	s.Assert().ErrorAs(err, &validation)
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *AdminTestSuite) TestModerateAccountSensitive() {
+	accountColumns := []string{"role", "suspended_at", "silenced_at", "sensitized_at", "pending", "deleted_at"}
+	sensitizedAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT role, suspended_at, silenced_at, sensitized_at, pending, deleted_at FROM account WHERE id = ? AND deleted_at IS NULL FOR UPDATE`)).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(object.RoleUser, nil, nil, nil, false, nil))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE account SET sensitized_at = COALESCE(sensitized_at, NOW()) WHERE id = ?`)).
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(`UPDATE status SET sensitive = TRUE`).
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT role, suspended_at, silenced_at, sensitized_at, pending, deleted_at FROM account WHERE id = ?`)).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(object.RoleUser, nil, nil, sensitizedAt, false, nil))
+	// the action is logged in the same transaction
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO admin_action_log`)).
+		WithArgs(1, object.ActionSensitive, object.TargetAccount, 3,
+			`{"role":"user","suspended_at":null,"silenced_at":null,"sensitized_at":null,"pending":false,"deleted_at":null}`,
+			`{"role":"user","suspended_at":null,"silenced_at":null,"sensitized_at":"2021-01-01T00:00:00Z","pending":false,"deleted_at":null}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
+
+	s.Require().NoError(s.repo.ModerateAccount(context.Background(), 3, 1, object.ActionSensitive))
+
+	// deleted or unknown accounts are not found
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT role, suspended_at, silenced_at, sensitized_at, pending, deleted_at FROM account WHERE id = ? AND deleted_at IS NULL FOR UPDATE`)).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows(accountColumns))
+	s.mock.ExpectRollback()
+
+	var notFound *repository.NotFoundError
+	s.Assert().ErrorAs(s.repo.ModerateAccount(context.Background(), 4, 1, object.ActionSuspend), &notFound)
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *AdminTestSuite) TestDeleteStatusLogsContent() {
+	createAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, account_id, content, sensitive, create_at FROM status WHERE id = ? FOR UPDATE`)).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "content", "sensitive", "create_at"}).AddRow(5, 3, "spam", false, createAt))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT attachment_id FROM status_attachment WHERE status_id = ? ORDER BY attachment_id`)).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"attachment_id"}))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT attachment_id FROM status_attachment WHERE status_id = ?`)).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"attachment_id"}))
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM status_attachment WHERE status_id = ?`)).
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(`delete\s+from status`).
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO admin_action_log`)).
+		WithArgs(1, object.ActionDeleteStatus, object.TargetStatus, 5,
+			`{"id":5,"account_id":3,"content":"spam","sensitive":false,"create_at":"2021-01-01T00:00:00Z","media_ids":[]}`,
+			nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
+
+	s.Require().NoError(s.repo.DeleteStatus(context.Background(), 5, 1))
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *AdminTestSuite) TestRejectOnlyPending() {
+	accountColumns := []string{"role", "suspended_at", "silenced_at", "sensitized_at", "pending", "deleted_at"}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT role, suspended_at, silenced_at, sensitized_at, pending, deleted_at FROM account WHERE id = ? AND deleted_at IS NULL FOR UPDATE`)).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(object.RoleUser, nil, nil, nil, false, nil))
+	s.mock.ExpectRollback()
+
+	var conflict *repository.ConflictError
+	s.Assert().ErrorAs(s.repo.ModerateAccount(context.Background(), 3, 1, object.ActionReject), &conflict)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT role, suspended_at, silenced_at, sensitized_at, pending, deleted_at FROM account WHERE id = ? AND deleted_at IS NULL FOR UPDATE`)).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(object.RoleUser, nil, nil, nil, true, nil))
+	s.mock.ExpectExec(`UPDATE account\s+SET deleted_at = NOW\(\)`).
+		WithArgs(4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO job (kind, payload) VALUES (?, ?)`)).
+		WithArgs(object.JobPurgeAccount, `{"account_id":4}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT role, suspended_at, silenced_at, sensitized_at, pending, deleted_at FROM account WHERE id = ?`)).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(object.RoleUser, nil, nil, nil, true, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)))
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO admin_action_log`)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
+
+	s.Require().NoError(s.repo.ModerateAccount(context.Background(), 4, 1, object.ActionReject))
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
@@ -2,10 +2,13 @@ package dao
 
 import (
 	"context"
-	"fmt"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"io"
 	"os"
-	"time"
+	"path/filepath"
+	"strings"
 	"yatter-backend-go/app/domain/object"
 	"yatter-backend-go/app/domain/repository"
 
@@ -23,41 +26,216 @@ func NewAttachment(db *sqlx.DB) repository.Attachment {
 	return &attachment{db: db}
 }
 
-const FilesPath = "localhost:8080/v1/media/"
+// FilesPath is the path files of fileDir are served at
+const FilesPath = "/v1/media/files/"
 
-func (r *attachment) UploadFile(ctx context.Context, file io.Reader, fileDir, filename, filetype, description string) (*object.Attachment, error) {
-	if err := os.MkdirAll(fileDir, os.ModePerm); err != nil {
+// FileURL returns URL of the file stored under fileDir, baseURL is the public URL of the server
+func FileURL(baseURL, fileDir, path string) string {
+	rel, err := filepath.Rel(fileDir, path)
+	if err != nil {
+		rel = filepath.Base(path)
+	}
+	return strings.TrimSuffix(baseURL, "/") + FilesPath + filepath.ToSlash(rel)
+}
+
+// ProcessAttachmentPayload is payload of object.JobProcessAttachment
+type ProcessAttachmentPayload struct {
+	AttachmentID int64 `json:"attachment_id"`
+}
+
+// UploadFile : アカウントのファイルを保存
+func (r *attachment) UploadFile(ctx context.Context, accountID int64, file io.Reader, fileDir, baseURL, ext, filetype, description string, meta *object.AttachmentMeta) (*object.Attachment, error) {
+	ctx, end := instrument(ctx, "attachment.UploadFile")
+	defer end()
+
+	var attachment *object.Attachment
+	err := r.store(ctx, file, fileDir, ext, func(tx *sqlx.Tx, hash, path string) error {
+		url := FileURL(baseURL, fileDir, path)
+
+		const query = `INSERT INTO attachment (account_id, type, url, description, file_path, blob_hash, meta) VALUES (?, ?, ?, ?, ?, ?, ?)`
+		res, err := tx.ExecContext(ctx, query, accountID, filetype, url, description, path, hash, meta)
+		if err != nil {
+			return err
+		}
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		attachment = &object.Attachment{
+			ID:          id,
+			AccountID:   accountID,
+			Type:        filetype,
+			URL:         &url,
+			Description: description,
+			Meta:        meta,
+			FilePath:    path,
+			BlobHash:    &hash,
+			State:       object.AttachmentReady,
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	dstName := fmt.Sprintf("%s/%d_%s", fileDir, time.Now().UnixNano(), filename)
-	dst, err := os.Create(dstName)
+	return attachment, nil
+}
+
+// StoreFile : ファイルを保存し、処理ジョブを登録
+func (r *attachment) StoreFile(ctx context.Context, accountID int64, file io.Reader, fileDir, ext, filetype, description string) (*object.Attachment, error) {
+	ctx, end := instrument(ctx, "attachment.StoreFile")
+	defer end()
+
+	var attachment *object.Attachment
+	err := r.store(ctx, file, fileDir, ext, func(tx *sqlx.Tx, hash, path string) error {
+		const query = `INSERT INTO attachment (account_id, type, description, file_path, blob_hash, state) VALUES (?, ?, ?, ?, ?, 'processing')`
+		res, err := tx.ExecContext(ctx, query, accountID, filetype, description, path, hash)
+		if err != nil {
+			return err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		payload, err := json.Marshal(ProcessAttachmentPayload{AttachmentID: id})
+		if err != nil {
+			return err
+		}
+		if _, err := enqueueJob(ctx, tx, object.JobProcessAttachment, string(payload)); err != nil {
+			return err
+		}
+
+		attachment = &object.Attachment{
+			ID:          id,
+			AccountID:   accountID,
+			Type:        filetype,
+			Description: description,
+			FilePath:    path,
+			BlobHash:    &hash,
+			State:       object.AttachmentProcessing,
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer dst.Close()
 
-	if _, err := io.Copy(dst, file); err != nil {
+	return attachment, nil
+}
+
+// store saves file as a content-addressed blob and calls insert in the same transaction
+func (r *attachment) store(ctx context.Context, file io.Reader, fileDir, ext string, insert func(tx *sqlx.Tx, hash, path string) error) error {
+	_, end := instrument(ctx, "storage.Spool")
+	tmp, hash, size, err := spoolFile(file, fileDir)
+	end()
+	if err != nil {
+		return err
+	}
+	// tmp is linked to the blob path, it is removed once the blob is in place
+	defer os.Remove(tmp)
+
+	var path string
+	err = Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		var err error
+		path, err = acquireBlob(ctx, tx, tmp, fileDir, hash, ext, size)
+		if err != nil {
+			return err
+		}
+		return insert(tx, hash, path)
+	})
+	if err != nil {
+		return err
+	}
+
+	// a release committed before this upload may have removed the blob after it was linked
+	return placeBlob(tmp, path)
+}
+
+// FindByID : IDから添付ファイルを取得
+func (r *attachment) FindByID(ctx context.Context, id int64) (*object.Attachment, error) {
+	ctx, end := instrument(ctx, "attachment.FindByID")
+	defer end()
+
+	attachment := &object.Attachment{}
+	const findAttachment = `SELECT * FROM attachment WHERE id = ?`
+	err := r.db.QueryRowxContext(ctx, findAttachment, id).StructScan(attachment)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
 		return nil, err
 	}
 
-	url := FilesPath + dstName
+	return attachment, nil
+}
+
+// UpdateProcessed : 処理結果を保存
+func (r *attachment) UpdateProcessed(ctx context.Context, attachment *object.Attachment) error {
+	ctx, end := instrument(ctx, "attachment.UpdateProcessed")
+	defer end()
+
+	const update = `UPDATE attachment
+					SET type = :type, url = :url, preview_url = :preview_url, meta = :meta, file_path = :file_path, state = :state
+					WHERE id = :id`
+	_, err := r.db.NamedExecContext(ctx, update, attachment)
+	return err
+}
+
+// Delete : 添付ファイルを削除し、最後の参照であればファイルも削除
+func (r *attachment) Delete(ctx context.Context, id int64) error {
+	ctx, end := instrument(ctx, "attachment.Delete")
+	defer end()
 
-	const query = `INSERT INTO attachment (type, url, description) VALUES (?, ?, ?)`
-	res, err := r.db.ExecContext(ctx, query, filetype, url, description)
+	var blobs []string
+	err := Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		var err error
+		blobs, err = deleteAttachments(ctx, tx, []int64{id})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	removeBlobs(blobs)
+	return nil
+}
+
+// deleteAttachments deletes attachments and returns the blobs released with them
+func deleteAttachments(ctx context.Context, tx *sqlx.Tx, ids []int64) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	findHashes, params, err := sqlx.In(`SELECT blob_hash FROM attachment WHERE id IN (?) AND blob_hash IS NOT NULL FOR UPDATE`, ids)
 	if err != nil {
 		return nil, err
 	}
+	hashes := []string{}
+	if err := tx.SelectContext(ctx, &hashes, findHashes, params...); err != nil {
+		return nil, err
+	}
 
-	id, err := res.LastInsertId()
+	deleteAttachments, params, err := sqlx.In(`DELETE FROM attachment WHERE id IN (?)`, ids)
 	if err != nil {
 		return nil, err
 	}
+	if _, err := tx.ExecContext(ctx, deleteAttachments, params...); err != nil {
+		return nil, err
+	}
+
+	blobs := []string{}
+	for _, hash := range hashes {
+		blob, err := releaseBlob(ctx, tx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if blob != "" {
+			blobs = append(blobs, blob)
+		}
+	}
 
-	return &object.Attachment{
-		ID:          id,
-		Type:        filetype,
-		URL:         url,
-		Description: description,
-	}, nil
+	return blobs, nil
 }
@@ -0,0 +1,150 @@
+package dao_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/suite"
+)
+
+type AttachmentTestSuite struct {
+	DatabaseTestSuite
+
+	repo repository.Attachment
+}
+
+func (s *AttachmentTestSuite) SetupTest() {
+	s.setupSuite()
+
+	s.repo = dao.NewAttachment(s.sqlxDB)
+}
+
+func (s *AttachmentTestSuite) TearDownTest() {
+	s.tearDownSuite()
+}
+
+func TestAttachmentSuite(t *testing.T) {
+	suite.Run(t, new(AttachmentTestSuite))
+}
+
+func (s *AttachmentTestSuite) TestUploadFileDeduplicates() {
+	const content = "same content"
+	sum := sha256.Sum256([]byte(content))
+	hash := hex.EncodeToString(sum[:])
+
+	fileDir := s.T().TempDir()
+	path := filepath.Join(fileDir, hash[:2], hash[2:4], hash+".png")
+
+	for i, ext := range []string{".png", ".jpg"} {
+		s.mock.ExpectBegin()
+		s.mock.ExpectExec(`INSERT INTO media_blob`).
+			WithArgs(hash, filepath.Join(fileDir, hash[:2], hash[2:4], hash+ext), len(content)).
+			WillReturnResult(sqlmock.NewResult(0, int64(i+1)))
+		s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT path FROM media_blob WHERE hash = ?`)).
+			WithArgs(hash).
+			WillReturnRows(sqlmock.NewRows([]string{"path"}).AddRow(path))
+		s.mock.ExpectExec(`INSERT INTO attachment`).
+			WithArgs(int64(1), "image", "http://localhost:8080/v1/media/files/"+hash[:2]+"/"+hash[2:4]+"/"+hash+".png", "", path, hash, nil).
+			WillReturnResult(sqlmock.NewResult(int64(i+1), 1))
+		s.mock.ExpectCommit()
+
+		attachment, err := s.repo.UploadFile(context.Background(), 1, strings.NewReader(content), fileDir, "http://localhost:8080/", ext, "image", "", nil)
+		s.Require().NoError(err)
+		s.Assert().Equal(path, attachment.FilePath)
+	}
+
+	b, err := os.ReadFile(path)
+	s.Require().NoError(err)
+	s.Assert().Equal(content, string(b))
+
+	// temporary files are cleaned up, only the blob is left
+	entries, err := os.ReadDir(fileDir)
+	s.Require().NoError(err)
+	s.Assert().Len(entries, 1)
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *AttachmentTestSuite) TestDeleteRemovesLastReference() {
+	const hash = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	fileDir := s.T().TempDir()
+	path := filepath.Join(fileDir, hash+".gif")
+	derived := filepath.Join(fileDir, hash+"_small.jpg")
+	for _, p := range []string{path, derived} {
+		s.Require().NoError(os.WriteFile(p, []byte("x"), 0644))
+	}
+
+	for _, refCount := range []int64{2, 1} {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery(`SELECT blob_hash FROM attachment`).
+			WithArgs(refCount).
+			WillReturnRows(sqlmock.NewRows([]string{"blob_hash"}).AddRow(hash))
+		s.mock.ExpectExec(`DELETE FROM attachment`).
+			WithArgs(refCount).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.mock.ExpectQuery(`SELECT path, ref_count FROM media_blob`).
+			WithArgs(hash).
+			WillReturnRows(sqlmock.NewRows([]string{"path", "ref_count"}).AddRow(path, refCount))
+		if refCount > 1 {
+			s.mock.ExpectExec(`UPDATE media_blob SET ref_count = ref_count - 1`).
+				WithArgs(hash).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		} else {
+			s.mock.ExpectExec(`DELETE FROM media_blob`).
+				WithArgs(hash).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		s.mock.ExpectCommit()
+
+		s.Require().NoError(s.repo.Delete(context.Background(), refCount))
+
+		_, err := os.Stat(path)
+		s.Assert().Equal(refCount == 1, os.IsNotExist(err))
+		_, err = os.Stat(derived)
+		s.Assert().Equal(refCount == 1, os.IsNotExist(err))
+	}
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *AttachmentTestSuite) TestDeleteKeepsFilesOnRollback() {
+	const hash = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	fileDir := s.T().TempDir()
+	path := filepath.Join(fileDir, hash+".gif")
+	s.Require().NoError(os.WriteFile(path, []byte("x"), 0644))
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(`SELECT blob_hash FROM attachment`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"blob_hash"}).AddRow(hash))
+	s.mock.ExpectExec(`DELETE FROM attachment`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(`SELECT path, ref_count FROM media_blob`).
+		WithArgs(hash).
+		WillReturnRows(sqlmock.NewRows([]string{"path", "ref_count"}).AddRow(path, 1))
+	s.mock.ExpectExec(`DELETE FROM media_blob`).
+		WithArgs(hash).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit().WillReturnError(errors.New("commit failed"))
+
+	s.Require().Error(s.repo.Delete(context.Background(), 1))
+
+	// the row is still there after rollback, so the file must be too
+	_, err := os.Stat(path)
+	s.Assert().NoError(err)
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
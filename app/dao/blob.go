@@ -0,0 +1,127 @@
+package dao
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"yatter-backend-go/app/logger"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// spoolFile writes file into a temporary file in fileDir and returns its SHA-256
+func spoolFile(file io.Reader, fileDir string) (string, string, int64, error) {
+	if err := os.MkdirAll(fileDir, os.ModePerm); err != nil {
+		return "", "", 0, err
+	}
+
+	tmp, err := ioutil.TempFile(fileDir, ".upload-*")
+	if err != nil {
+		return "", "", 0, err
+	}
+	defer tmp.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(tmp, io.TeeReader(file, h))
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", "", 0, err
+	}
+
+	return tmp.Name(), hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// blobPath returns path of the blob, which is sharded by the hash prefix
+func blobPath(fileDir, hash, ext string) string {
+	return filepath.Join(fileDir, hash[:2], hash[2:4], hash+ext)
+}
+
+// acquireBlob takes a reference to the blob of hash and returns its path
+// The spooled file at tmp is linked to the blob path if the blob is not stored yet,
+// tmp itself is kept so that placeBlob can restore the blob after commit
+func acquireBlob(ctx context.Context, tx *sqlx.Tx, tmp, fileDir, hash, ext string, size int64) (string, error) {
+	ctx, end := instrument(ctx, "storage.AcquireBlob")
+	defer end()
+
+	const acquire = `INSERT INTO media_blob (hash, path, size, ref_count) VALUES (?, ?, ?, 1)
+					ON DUPLICATE KEY UPDATE ref_count = ref_count + 1`
+	if _, err := tx.ExecContext(ctx, acquire, hash, blobPath(fileDir, hash, ext), size); err != nil {
+		return "", err
+	}
+
+	var path string
+	const findPath = `SELECT path FROM media_blob WHERE hash = ?`
+	if err := tx.QueryRowxContext(ctx, findPath, hash).Scan(&path); err != nil {
+		return "", err
+	}
+
+	// blobs are content-addressed, a file left by a rolled back upload is reused as is
+	if err := placeBlob(tmp, path); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// placeBlob links tmp to path unless the blob is already there
+func placeBlob(tmp, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	if err := os.Link(tmp, path); err != nil && !os.IsExist(err) {
+		return err
+	}
+	return nil
+}
+
+// releaseBlob drops a reference to the blob of hash
+// The path of the blob is returned with the last reference, its files are removed by removeBlobs after commit
+func releaseBlob(ctx context.Context, tx *sqlx.Tx, hash string) (string, error) {
+	ctx, end := instrument(ctx, "storage.ReleaseBlob")
+	defer end()
+
+	var blob struct {
+		Path     string
+		RefCount int64 `db:"ref_count"`
+	}
+	const findBlob = `SELECT path, ref_count FROM media_blob WHERE hash = ? FOR UPDATE`
+	if err := tx.QueryRowxContext(ctx, findBlob, hash).StructScan(&blob); err != nil {
+		return "", err
+	}
+
+	if blob.RefCount > 1 {
+		const release = `UPDATE media_blob SET ref_count = ref_count - 1 WHERE hash = ?`
+		_, err := tx.ExecContext(ctx, release, hash)
+		return "", err
+	}
+
+	const remove = `DELETE FROM media_blob WHERE hash = ?`
+	if _, err := tx.ExecContext(ctx, remove, hash); err != nil {
+		return "", err
+	}
+
+	return blob.Path, nil
+}
+
+// removeBlobs removes released blobs and the files derived from them
+// It is called after commit, the rows are gone so a failure only leaves unreferenced files
+func removeBlobs(paths []string) {
+	for _, blob := range paths {
+		// previews and transcoded files share the name of blob
+		derived, err := filepath.Glob(strings.TrimSuffix(blob, filepath.Ext(blob)) + "*")
+		if err != nil {
+			logger.Default().Error("can't find blob files", "path", blob, "error", err)
+			continue
+		}
+		for _, path := range derived {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				logger.Default().Error("can't remove blob file", "path", path, "error", err)
+			}
+		}
+	}
+}
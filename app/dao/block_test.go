@@ -0,0 +1,87 @@
+package dao_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/suite"
+)
+
+type BlockTestSuite struct {
+	DatabaseTestSuite
+
+	repo repository.Account
+}
+
+func (s *BlockTestSuite) SetupTest() {
+	s.setupSuite()
+
+	s.repo = dao.NewAccount(s.sqlxDB)
+}
+
+func (s *BlockTestSuite) TearDownTest() {
+	s.tearDownSuite()
+}
+
+func TestBlockSuite(t *testing.T) {
+	suite.Run(t, new(BlockTestSuite))
+}
+
+func (s *BlockTestSuite) TestBlockRemovesFollows() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT IGNORE INTO account_block (account_id, target_account_id) VALUES (?, ?)`)).
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// only the target followed the account
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM follow WHERE follower_id = ? AND followee_id = ?`)).
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM follow WHERE follower_id = ? AND followee_id = ?`)).
+		WithArgs(2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE account SET following_count = following_count - 1 WHERE id = 2`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE account SET followers_count = followers_count - 1 WHERE id = 1`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	s.Require().NoError(s.repo.Block(context.Background(), 1, 2))
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *BlockTestSuite) TestFollowBlocked() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(`SELECT COUNT\(\*\) FROM account_block`).
+		WithArgs(2, 1, 1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	s.mock.ExpectRollback()
+
+	_, _, err := s.repo.Follow(context.Background(), 2, 1)
+	var forbidden *repository.ForbiddenError
+	s.Assert().ErrorAs(err, &forbidden)
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *BlockTestSuite) TestFollowTwice() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(`SELECT COUNT\(\*\) FROM account_block`).
+		WithArgs(1, 2, 2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO follow (follower_id, followee_id) VALUES (?, ?)`)).
+		WithArgs(1, 2).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1-2' for key 'follow_combination'"})
+	s.mock.ExpectRollback()
+
+	_, _, err := s.repo.Follow(context.Background(), 1, 2)
+	var conflict *repository.ConflictError
+	s.Assert().ErrorAs(err, &conflict)
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
@@ -1,13 +1,18 @@
 package dao
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
-	"log"
 	"yatter-backend-go/app/domain/repository"
+	"yatter-backend-go/app/logger"
 
 	"github.com/jmoiron/sqlx"
 )
 
+// SchemaVersion is version of ddl/ddl.sql which this application expects
+const SchemaVersion = 16
+
 type (
 	// DAO interface
 	Dao interface {
@@ -20,8 +25,47 @@ type (
 		// Get attachment repository
 		Attachment() repository.Attachment
 
+		// Get job repository
+		Job() repository.Job
+
+		// Get login activity repository
+		LoginActivity() repository.LoginActivity
+
+		// Get two-factor repository
+		TwoFactor() repository.TwoFactor
+
+		// Get account token repository
+		AccountToken() repository.AccountToken
+
+		// Get account export repository
+		AccountExport() repository.AccountExport
+
+		// Get account import repository
+		AccountImport() repository.AccountImport
+
+		// Get admin repository
+		Admin() repository.Admin
+
+		// Get report repository
+		Report() repository.Report
+
+		// Get invite repository
+		Invite() repository.Invite
+
 		// Clear all data in DB
 		InitAll() error
+
+		// Close connections to DB
+		Close() error
+
+		// Check connection to DB
+		Ping(ctx context.Context) error
+
+		// Get version of the applied schema
+		SchemaVersion(ctx context.Context) (int, error)
+
+		// Get statistics of connection pool
+		Stats() sql.DBStats
 	}
 
 	// Implementation for DAO
@@ -56,6 +100,63 @@ func (d *dao) Attachment() repository.Attachment {
 	return NewAttachment(d.db)
 }
 
+func (d *dao) Job() repository.Job {
+	return NewJob(d.db)
+}
+
+func (d *dao) LoginActivity() repository.LoginActivity {
+	return NewLoginActivity(d.db)
+}
+
+func (d *dao) TwoFactor() repository.TwoFactor {
+	return NewTwoFactor(d.db)
+}
+
+func (d *dao) AccountToken() repository.AccountToken {
+	return NewAccountToken(d.db)
+}
+
+func (d *dao) AccountExport() repository.AccountExport {
+	return NewAccountExport(d.db)
+}
+
+func (d *dao) AccountImport() repository.AccountImport {
+	return NewAccountImport(d.db)
+}
+
+func (d *dao) Admin() repository.Admin {
+	return NewAdmin(d.db)
+}
+
+func (d *dao) Report() repository.Report {
+	return NewReport(d.db)
+}
+
+func (d *dao) Invite() repository.Invite {
+	return NewInvite(d.db)
+}
+
+func (d *dao) Close() error {
+	return d.db.Close()
+}
+
+func (d *dao) Ping(ctx context.Context) error {
+	return d.db.PingContext(ctx)
+}
+
+func (d *dao) SchemaVersion(ctx context.Context) (int, error) {
+	// NULL if no version is applied
+	var version sql.NullInt64
+	if err := d.db.QueryRowxContext(ctx, "SELECT MAX(version) FROM schema_version").Scan(&version); err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+func (d *dao) Stats() sql.DBStats {
+	return d.db.Stats()
+}
+
 func (d *dao) InitAll() error {
 	if err := d.exec("SET FOREIGN_KEY_CHECKS=0"); err != nil {
 		return fmt.Errorf("Can't disable FOREIGN_KEY_CHECKS: %w", err)
@@ -64,11 +165,11 @@ func (d *dao) InitAll() error {
 	defer func() {
 		err := d.exec("SET FOREIGN_KEY_CHECKS=0")
 		if err != nil {
-			log.Printf("Can't restore FOREIGN_KEY_CHECKS: %+v", err)
+			logger.Default().Error("can't restore FOREIGN_KEY_CHECKS", "error", err)
 		}
 	}()
 
-	for _, table := range []string{"account", "status", "attachment", "follow", "status_attachment"} {
+	for _, table := range []string{"account", "status", "attachment", "follow", "status_attachment", "job", "media_blob", "login_activity", "two_factor", "recovery_code", "account_token", "account_export", "account_import", "account_import_failure", "report", "report_status", "report_note", "report_history", "admin_action_log", "invite", "account_block", "account_mute"} {
 		if err := d.exec("TRUNCATE TABLE " + table); err != nil {
 			return fmt.Errorf("Can't truncate table "+table+": %w", err)
 		}
@@ -1,6 +1,8 @@
 package dao
 
 import (
+	"context"
+	"database/sql"
 	"yatter-backend-go/app/domain/mock"
 	"yatter-backend-go/app/domain/repository"
 )
@@ -10,6 +12,16 @@ type DaoMock struct {
 	AccountMock    *mock.AccountMock
 	StatusMock     *mock.StatusMock
 	AttachmentMock *mock.AttachmentMock
+	JobMock        *mock.JobMock
+
+	LoginActivityMock *mock.LoginActivityMock
+	TwoFactorMock     *mock.TwoFactorMock
+	AccountTokenMock  *mock.AccountTokenMock
+	AccountExportMock *mock.AccountExportMock
+	AccountImportMock *mock.AccountImportMock
+	AdminMock         *mock.AdminMock
+	ReportMock        *mock.ReportMock
+	InviteMock        *mock.InviteMock
 }
 
 func NewMock(accountMock *mock.AccountMock, statusMock *mock.StatusMock, attachmentMock *mock.AttachmentMock) *DaoMock {
@@ -32,6 +44,58 @@ func (d *DaoMock) Attachment() repository.Attachment {
 	return d.AttachmentMock
 }
 
+func (d *DaoMock) Job() repository.Job {
+	return d.JobMock
+}
+
+func (d *DaoMock) LoginActivity() repository.LoginActivity {
+	return d.LoginActivityMock
+}
+
+func (d *DaoMock) TwoFactor() repository.TwoFactor {
+	return d.TwoFactorMock
+}
+
+func (d *DaoMock) AccountToken() repository.AccountToken {
+	return d.AccountTokenMock
+}
+
+func (d *DaoMock) AccountExport() repository.AccountExport {
+	return d.AccountExportMock
+}
+
+func (d *DaoMock) AccountImport() repository.AccountImport {
+	return d.AccountImportMock
+}
+
+func (d *DaoMock) Admin() repository.Admin {
+	return d.AdminMock
+}
+
+func (d *DaoMock) Report() repository.Report {
+	return d.ReportMock
+}
+
+func (d *DaoMock) Invite() repository.Invite {
+	return d.InviteMock
+}
+
 func (d *DaoMock) InitAll() error {
 	return nil
 }
+
+func (d *DaoMock) Close() error {
+	return nil
+}
+
+func (d *DaoMock) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (d *DaoMock) SchemaVersion(ctx context.Context) (int, error) {
+	return SchemaVersion, nil
+}
+
+func (d *DaoMock) Stats() sql.DBStats {
+	return sql.DBStats{}
+}
@@ -1,9 +1,16 @@
 package dao
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
-	"log"
+	"strings"
+	"time"
+	"yatter-backend-go/app/logger"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -12,28 +19,55 @@ type DBConfig interface {
 	FormatDSN() string
 }
 
+// Optional interface of configuration to tune connection pool
+type DBPoolConfig interface {
+	PoolSize() (maxOpen, maxIdle int, maxLifetime time.Duration)
+}
+
+// ConnectorWrapper decorates connections to DB, e.g. for tracing
+type ConnectorWrapper func(driver.Connector) driver.Connector
+
+var connectorWrappers []ConnectorWrapper
+
+// WrapConnector registers w which is applied to DB opened after that
+func WrapConnector(w ConnectorWrapper) {
+	connectorWrappers = append(connectorWrappers, w)
+}
+
 // Prepare sqlx.DB
 func initDb(config DBConfig) (*sqlx.DB, error) {
 	driverName := "mysql"
-	db, err := sqlx.Open(driverName, config.FormatDSN())
+	connector, err := (&mysql.MySQLDriver{}).OpenConnector(config.FormatDSN())
 	if err != nil {
 		return nil, fmt.Errorf("sqlx.Open failed: %w", err)
 	}
+	for _, w := range connectorWrappers {
+		connector = w(connector)
+	}
+	db := sqlx.NewDb(sql.OpenDB(connector), driverName)
+
+	if pool, ok := config.(DBPoolConfig); ok {
+		maxOpen, maxIdle, maxLifetime := pool.PoolSize()
+		db.SetMaxOpenConns(maxOpen)
+		db.SetMaxIdleConns(maxIdle)
+		db.SetConnMaxLifetime(maxLifetime)
+	}
 
 	return db, nil
 }
 
 // Transaction handle specific process
 // Essentially, it should be abstracted by DB interface
-func Transaction(db *sqlx.DB, txFunc func(*sqlx.Tx) error) error {
-	tx, err := db.Beginx()
+func Transaction(ctx context.Context, db *sqlx.DB, txFunc func(*sqlx.Tx) error) (err error) {
+	tx, err := db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
+	// err is named so that a failed commit is returned to the caller
 	defer func() {
 		if err != nil {
-			log.Println("rollback")
+			logger.FromContext(ctx).Warn("transaction rolled back", "error", err)
 			tx.Rollback()
 		} else {
 			err = tx.Commit()
@@ -43,3 +77,18 @@ func Transaction(db *sqlx.DB, txFunc func(*sqlx.Tx) error) error {
 	err = txFunc(tx)
 	return err
 }
+
+// duplicateKey reports whether err is a violation of unique constraint and returns name of the key
+func duplicateKey(err error) (string, bool) {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) || mysqlErr.Number != 1062 {
+		return "", false
+	}
+	// e.g. "Duplicate entry 'x' for key 'email'", MySQL 8 prefixes the table name
+	msg := mysqlErr.Message
+	if i := strings.LastIndex(msg, "for key '"); i >= 0 {
+		key := strings.TrimSuffix(msg[i+len("for key '"):], "'")
+		return key[strings.LastIndex(key, ".")+1:], true
+	}
+	return "", true
+}
@@ -0,0 +1,46 @@
+package dao
+
+import (
+	"context"
+	"sync"
+)
+
+// QueryHook instruments repository methods
+// It is called with the name of the method when it starts, and the returned
+// function is called when it ends. The returned context is used in the method.
+type QueryHook func(ctx context.Context, name string) (context.Context, func())
+
+var (
+	hooksMu sync.RWMutex
+	hooks   []QueryHook
+)
+
+// AddQueryHook registers hook for all repository methods
+func AddQueryHook(h QueryHook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = append(hooks, h)
+}
+
+// instrument runs hooks for the method named name
+func instrument(ctx context.Context, name string) (context.Context, func()) {
+	hooksMu.RLock()
+	hs := hooks
+	hooksMu.RUnlock()
+
+	if len(hs) == 0 {
+		return ctx, func() {}
+	}
+
+	ends := make([]func(), 0, len(hs))
+	for _, h := range hs {
+		var end func()
+		ctx, end = h(ctx, name)
+		ends = append(ends, end)
+	}
+	return ctx, func() {
+		for i := len(ends) - 1; i >= 0; i-- {
+			ends[i]()
+		}
+	}
+}
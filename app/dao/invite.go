@@ -0,0 +1,118 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type (
+	// Implementation for repository.Invite
+	invite struct {
+		db *sqlx.DB
+	}
+)
+
+// Create invite repository
+func NewInvite(db *sqlx.DB) repository.Invite {
+	return &invite{db: db}
+}
+
+// Create : 招待を作成
+func (r *invite) Create(ctx context.Context, invite *object.Invite) (int64, error) {
+	ctx, end := instrument(ctx, "invite.Create")
+	defer end()
+
+	const insert = `INSERT INTO invite (account_id, code, max_uses, expires_at) VALUES (?, ?, ?, ?)`
+	var expiresAt interface{}
+	if invite.ExpiresAt != nil {
+		expiresAt = invite.ExpiresAt.Time
+	}
+	res, err := r.db.ExecContext(ctx, insert, invite.AccountID, invite.Code, invite.MaxUses, expiresAt)
+	if _, ok := duplicateKey(err); ok {
+		return 0, repository.NewConflict("invite", "code", "code is already taken")
+	} else if err != nil {
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
+
+// FindByID : IDから招待を取得
+func (r *invite) FindByID(ctx context.Context, id int64) (*object.Invite, error) {
+	ctx, end := instrument(ctx, "invite.FindByID")
+	defer end()
+
+	invite := &object.Invite{}
+	const find = `SELECT * FROM invite WHERE id = ?`
+	if err := r.db.QueryRowxContext(ctx, find, id).StructScan(invite); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return invite, nil
+}
+
+// ListByAccountID : アカウントが作成した招待を新しい順に取得
+func (r *invite) ListByAccountID(ctx context.Context, accountID, maxID, sinceID, limit int64) ([]object.Invite, error) {
+	ctx, end := instrument(ctx, "invite.ListByAccountID")
+	defer end()
+
+	connection := ""
+	idRange, ok := BuildRangeQuery("id", maxID, sinceID, 0)
+	if ok {
+		connection = "AND"
+	} else {
+		connection = "WHERE"
+	}
+	list := fmt.Sprintf(`SELECT * FROM invite %s %s account_id = ? ORDER BY id DESC LIMIT %d`, idRange, connection, limit)
+	invites := []object.Invite{}
+	if err := r.db.SelectContext(ctx, &invites, list, accountID); err != nil {
+		return nil, err
+	}
+
+	return invites, nil
+}
+
+// Expire : 招待を失効させる
+func (r *invite) Expire(ctx context.Context, id int64) error {
+	ctx, end := instrument(ctx, "invite.Expire")
+	defer end()
+
+	const expire = `UPDATE invite SET expires_at = NOW() WHERE id = ? AND (expires_at IS NULL OR expires_at > NOW())`
+	_, err := r.db.ExecContext(ctx, expire, id)
+	return err
+}
+
+// useInvite counts a use of the invite of code and returns its ID
+// Expired or used up invites, and invites of inactive accounts are invalid.
+func useInvite(ctx context.Context, tx *sqlx.Tx, code string) (int64, error) {
+	var id int64
+	const lock = `SELECT i.id
+				FROM invite as i
+				JOIN account as a
+				ON i.account_id = a.id
+				WHERE i.code = ?
+				AND (i.expires_at IS NULL OR i.expires_at > NOW())
+				AND (i.max_uses IS NULL OR i.uses < i.max_uses)
+				AND a.suspended_at IS NULL AND a.deleted_at IS NULL
+				FOR UPDATE`
+	if err := tx.QueryRowxContext(ctx, lock, code).Scan(&id); errors.Is(err, sql.ErrNoRows) {
+		return 0, repository.NewValidation("invite_code", "ERR_INVALID", "is invalid or expired")
+	} else if err != nil {
+		return 0, err
+	}
+
+	const use = `UPDATE invite SET uses = uses + 1 WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, use, id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
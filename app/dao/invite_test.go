@@ -0,0 +1,80 @@
+package dao_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/suite"
+)
+
+type RegistrationTestSuite struct {
+	DatabaseTestSuite
+
+	repo repository.Account
+}
+
+func (s *RegistrationTestSuite) SetupTest() {
+	s.setupSuite()
+
+	s.repo = dao.NewAccount(s.sqlxDB)
+}
+
+func (s *RegistrationTestSuite) TearDownTest() {
+	s.tearDownSuite()
+}
+
+func TestRegistrationSuite(t *testing.T) {
+	suite.Run(t, new(RegistrationTestSuite))
+}
+
+func (s *RegistrationTestSuite) TestCreateAccountUsesInvite() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(`SELECT i.id\s+FROM invite`).
+		WithArgs("abc").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE invite SET uses = uses + 1 WHERE id = ?`)).
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO account (username, password_hash, email, pending, registration_reason, invite_id)`)).
+		WithArgs("john", "hash", nil, false, nil, 7).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	s.mock.ExpectCommit()
+
+	id, err := s.repo.CreateAccount(context.Background(), &object.Registration{Username: "john", PasswordHash: "hash", InviteCode: "abc"})
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(3), id)
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *RegistrationTestSuite) TestCreateAccountRejectsInvalidInvite() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(`SELECT i.id\s+FROM invite`).
+		WithArgs("used").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	s.mock.ExpectRollback()
+
+	_, err := s.repo.CreateAccount(context.Background(), &object.Registration{Username: "john", PasswordHash: "hash", InviteCode: "used"})
+	var validation *repository.ValidationError
+	s.Assert().ErrorAs(err, &validation)
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *RegistrationTestSuite) TestCreatePendingAccount() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO account`)).
+		WithArgs("john", "hash", nil, true, "I like birds", nil).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	s.mock.ExpectCommit()
+
+	_, err := s.repo.CreateAccount(context.Background(), &object.Registration{Username: "john", PasswordHash: "hash", Reason: "I like birds", Pending: true})
+	s.Require().NoError(err)
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
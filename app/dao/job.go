@@ -0,0 +1,115 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type (
+	// Implementation for repository.Job
+	job struct {
+		db *sqlx.DB
+	}
+)
+
+// Create job repository
+func NewJob(db *sqlx.DB) repository.Job {
+	return &job{db: db}
+}
+
+// Enqueue : ジョブをキューに追加
+func (r *job) Enqueue(ctx context.Context, kind, payload string) (int64, error) {
+	ctx, end := instrument(ctx, "job.Enqueue")
+	defer end()
+
+	return enqueueJob(ctx, r.db, kind, payload)
+}
+
+func enqueueJob(ctx context.Context, db sqlx.ExecerContext, kind, payload string) (int64, error) {
+	const enqueue = `INSERT INTO job (kind, payload) VALUES (?, ?)`
+	res, err := db.ExecContext(ctx, enqueue, kind, payload)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
+
+// Claim : 実行可能なジョブを取得してロック
+func (r *job) Claim(ctx context.Context, lease time.Duration) (*object.Job, error) {
+	ctx, end := instrument(ctx, "job.Claim")
+	defer end()
+
+	var claimed *object.Job
+
+	err := Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		// a job whose lease expired on the last attempt killed or hung the worker, it is not retried
+		const failExhausted = `UPDATE job
+							SET state = 'failed', last_error = 'lease expired on the last attempt', locked_at = NULL
+							WHERE state = 'running' AND locked_at < NOW() - INTERVAL ? SECOND
+							AND attempts >= max_attempts`
+		if _, err := tx.ExecContext(ctx, failExhausted, int64(lease.Seconds())); err != nil {
+			return err
+		}
+
+		j := &object.Job{}
+		const findRunnable = `SELECT * FROM job
+							WHERE (state = 'pending' AND run_at <= NOW())
+							OR (state = 'running' AND locked_at < NOW() - INTERVAL ? SECOND AND attempts < max_attempts)
+							ORDER BY id
+							LIMIT 1
+							FOR UPDATE`
+		if err := tx.QueryRowxContext(ctx, findRunnable, int64(lease.Seconds())).StructScan(j); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+
+		const lock = `UPDATE job SET state = 'running', attempts = attempts + 1, locked_at = NOW() WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, lock, j.ID); err != nil {
+			return err
+		}
+		j.State = object.JobRunning
+		j.Attempts++
+
+		claimed = j
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return claimed, nil
+}
+
+// Complete : ジョブを完了にする
+func (r *job) Complete(ctx context.Context, id int64) error {
+	ctx, end := instrument(ctx, "job.Complete")
+	defer end()
+
+	const complete = `UPDATE job SET state = 'done', locked_at = NULL WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, complete, id)
+	return err
+}
+
+// Fail : ジョブの失敗を記録し、試行回数が残っていれば再実行を予約
+func (r *job) Fail(ctx context.Context, id int64, reason string, backoff time.Duration) error {
+	ctx, end := instrument(ctx, "job.Fail")
+	defer end()
+
+	const fail = `UPDATE job
+				SET state = IF(attempts >= max_attempts, 'failed', 'pending'),
+					last_error = ?,
+					run_at = NOW() + INTERVAL ? SECOND,
+					locked_at = NULL
+				WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, fail, reason, int64(backoff.Seconds()), id)
+	return err
+}
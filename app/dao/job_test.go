@@ -0,0 +1,69 @@
+package dao_test
+
+import (
+	"context"
+	"testing"
+	"time"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/suite"
+)
+
+type JobTestSuite struct {
+	DatabaseTestSuite
+
+	repo repository.Job
+}
+
+func (s *JobTestSuite) SetupTest() {
+	s.setupSuite()
+
+	s.repo = dao.NewJob(s.sqlxDB)
+}
+
+func (s *JobTestSuite) TearDownTest() {
+	s.tearDownSuite()
+}
+
+func TestJobSuite(t *testing.T) {
+	suite.Run(t, new(JobTestSuite))
+}
+
+func (s *JobTestSuite) TestClaimFailsExhaustedLeases() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(`UPDATE job\s+SET state = 'failed'.+AND attempts >= max_attempts`).
+		WithArgs(300).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(`SELECT \* FROM job\s+WHERE .+ AND attempts < max_attempts\)`).
+		WithArgs(300).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "kind", "payload", "state", "attempts", "max_attempts"}).
+			AddRow(1, object.JobPurgeAccount, `{"account_id":1}`, object.JobRunning, 2, 5))
+	s.mock.ExpectExec(`UPDATE job SET state = 'running', attempts = attempts \+ 1`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	job, err := s.repo.Claim(context.Background(), 5*time.Minute)
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(1), job.ID)
+	s.Assert().Equal(3, job.Attempts)
+
+	// nothing is runnable
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(`UPDATE job\s+SET state = 'failed'`).
+		WithArgs(300).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectQuery(`SELECT \* FROM job`).
+		WithArgs(300).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	s.mock.ExpectCommit()
+
+	job, err = s.repo.Claim(context.Background(), 5*time.Minute)
+	s.Require().NoError(err)
+	s.Assert().Nil(job)
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,67 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type (
+	// Implementation for repository.LoginActivity
+	loginActivity struct {
+		db *sqlx.DB
+	}
+)
+
+// Create login activity repository
+func NewLoginActivity(db *sqlx.DB) repository.LoginActivity {
+	return &loginActivity{db: db}
+}
+
+// Create : ログイン試行を記録、成功は同じ IP と User-Agent から1時間に1回のみ
+func (r *loginActivity) Create(ctx context.Context, activity *object.LoginActivity) error {
+	ctx, end := instrument(ctx, "loginActivity.Create")
+	defer end()
+
+	if !activity.Success {
+		const insert = `INSERT INTO login_activity (account_id, success, failure_reason, ip, user_agent) VALUES (?, FALSE, ?, ?, ?)`
+		_, err := r.db.ExecContext(ctx, insert, activity.AccountID, activity.FailureReason, activity.IP, activity.UserAgent)
+		return err
+	}
+
+	const insertSuccess = `INSERT INTO login_activity (account_id, success, ip, user_agent)
+						SELECT ?, TRUE, ?, ? FROM DUAL
+						WHERE NOT EXISTS (
+							SELECT 1 FROM login_activity
+							WHERE account_id = ? AND success AND ip = ? AND user_agent = ?
+							AND create_at > NOW() - INTERVAL 1 HOUR
+						)`
+	_, err := r.db.ExecContext(ctx, insertSuccess,
+		activity.AccountID, activity.IP, activity.UserAgent,
+		activity.AccountID, activity.IP, activity.UserAgent)
+	return err
+}
+
+// ListByAccountID : アカウントのログイン履歴を新しい順に取得
+func (r *loginActivity) ListByAccountID(ctx context.Context, accountID, maxID, sinceID, limit int64) ([]object.LoginActivity, error) {
+	ctx, end := instrument(ctx, "loginActivity.ListByAccountID")
+	defer end()
+
+	connection := ""
+	idRange, ok := BuildRangeQuery("id", maxID, sinceID, 0)
+	if ok {
+		connection = "AND"
+	} else {
+		connection = "WHERE"
+	}
+	list := fmt.Sprintf(`SELECT * FROM login_activity %s %s account_id = ? ORDER BY id DESC LIMIT %d`, idRange, connection, limit)
+	activities := []object.LoginActivity{}
+	if err := r.db.SelectContext(ctx, &activities, list, accountID); err != nil {
+		return nil, err
+	}
+
+	return activities, nil
+}
@@ -0,0 +1,177 @@
+package dao_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"regexp"
+	"testing"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ProfileTestSuite struct {
+	DatabaseTestSuite
+
+	repo repository.Account
+}
+
+func (s *ProfileTestSuite) SetupTest() {
+	s.setupSuite()
+
+	s.repo = dao.NewAccount(s.sqlxDB)
+}
+
+func (s *ProfileTestSuite) TearDownTest() {
+	s.tearDownSuite()
+}
+
+func TestProfileSuite(t *testing.T) {
+	suite.Run(t, new(ProfileTestSuite))
+}
+
+// fieldsArg matches fields stored as JSON
+type fieldsArg func(fields object.ProfileFields) bool
+
+func (f fieldsArg) Match(v driver.Value) bool {
+	var fields object.ProfileFields
+	if err := fields.Scan(v); err != nil {
+		return false
+	}
+	return f(fields)
+}
+
+func (s *ProfileTestSuite) TestUpdateCredentialsKeepsVerification() {
+	current := `[{"name":"Blog","value":"https://blog.example.com","verified_at":"2021-01-01T00:00:00Z"},` +
+		`{"name":"Site","value":"https://example.com","verified_at":"2021-01-01T00:00:00Z"}]`
+	bot := true
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT fields, avatar_attachment_id, header_attachment_id FROM account WHERE id = ? AND deleted_at IS NULL FOR UPDATE`)).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"fields", "avatar_attachment_id", "header_attachment_id"}).AddRow(current, nil, nil))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE account SET bot = ?, fields = ? WHERE id = ?`)).
+		WithArgs(true, fieldsArg(func(fields object.ProfileFields) bool {
+			return len(fields) == 2 && fields[0].VerifiedAt != nil && fields[1].VerifiedAt == nil
+		}), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO job (kind, payload) VALUES (?, ?)`)).
+		WithArgs(object.JobVerifyProfile, `{"account_id":1}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
+
+	fields := object.ProfileFields{
+		{Name: "Blog", Value: "https://blog.example.com"},
+		{Name: "Site", Value: "https://example.org"},
+	}
+	s.Require().NoError(s.repo.UpdateCredentials(context.Background(), 1, &object.CredentialsUpdate{Fields: &fields, Bot: &bot}))
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *ProfileTestSuite) TestUpdateCredentialsWithoutLinks() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT fields, avatar_attachment_id, header_attachment_id FROM account WHERE id = ? AND deleted_at IS NULL FOR UPDATE`)).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"fields", "avatar_attachment_id", "header_attachment_id"}).AddRow(nil, nil, nil))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE account SET fields = ? WHERE id = ?`)).
+		WithArgs(`[{"name":"Pronouns","value":"they/them","verified_at":null}]`, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	fields := object.ProfileFields{{Name: "Pronouns", Value: "they/them"}}
+	s.Require().NoError(s.repo.UpdateCredentials(context.Background(), 1, &object.CredentialsUpdate{Fields: &fields}))
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *ProfileTestSuite) TestUpdateCredentialsClearsEmptyStrings() {
+	empty, name, privacy := "", "John", object.PrivacyUnlisted
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT fields, avatar_attachment_id, header_attachment_id FROM account WHERE id = ? AND deleted_at IS NULL FOR UPDATE`)).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"fields", "avatar_attachment_id", "header_attachment_id"}).AddRow(nil, nil, nil))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE account SET display_name = ?, note = ?, default_privacy = ?, default_language = ? WHERE id = ?`)).
+		WithArgs(name, nil, privacy, nil, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	s.Require().NoError(s.repo.UpdateCredentials(context.Background(), 1, &object.CredentialsUpdate{
+		DisplayName: &name,
+		Note:        &empty,
+		Privacy:     &privacy,
+		Language:    &empty,
+	}))
+
+	// deleted account
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT fields, avatar_attachment_id, header_attachment_id FROM account WHERE id = ? AND deleted_at IS NULL FOR UPDATE`)).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"fields", "avatar_attachment_id", "header_attachment_id"}))
+	s.mock.ExpectRollback()
+
+	var notFound *repository.NotFoundError
+	s.Assert().ErrorAs(s.repo.UpdateCredentials(context.Background(), 2, &object.CredentialsUpdate{}), &notFound)
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *ProfileTestSuite) TestUpdateCredentialsReleasesReplacedImages() {
+	avatar, empty := "http://localhost/media/new.png", ""
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT fields, avatar_attachment_id, header_attachment_id FROM account WHERE id = ? AND deleted_at IS NULL FOR UPDATE`)).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"fields", "avatar_attachment_id", "header_attachment_id"}).AddRow(nil, 3, 4))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE account SET avatar = ?, avatar_attachment_id = ?, header = ?, header_attachment_id = ? WHERE id = ?`)).
+		WithArgs(avatar, 5, nil, nil, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// the old header is posted in a status as well
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM attachment WHERE id IN (?, ?) AND id NOT IN (SELECT attachment_id FROM status_attachment) FOR UPDATE`)).
+		WithArgs(3, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT blob_hash FROM attachment WHERE id IN (?) AND blob_hash IS NOT NULL FOR UPDATE`)).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"blob_hash"}))
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM attachment WHERE id IN (?)`)).
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	s.Require().NoError(s.repo.UpdateCredentials(context.Background(), 1, &object.CredentialsUpdate{
+		Avatar:             &avatar,
+		AvatarAttachmentID: 5,
+		Header:             &empty,
+	}))
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *ProfileTestSuite) TestVerifyFieldsSkipsChangedValues() {
+	current, err := json.Marshal(object.ProfileFields{
+		{Name: "Blog", Value: "https://blog.example.com"},
+		{Name: "Site", Value: "https://example.org"},
+	})
+	s.Require().NoError(err)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT fields FROM account WHERE id = ? AND deleted_at IS NULL FOR UPDATE`)).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"fields"}).AddRow(current))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE account SET fields = ? WHERE id = ?`)).
+		WithArgs(fieldsArg(func(fields object.ProfileFields) bool {
+			return fields[0].VerifiedAt != nil && fields[1].VerifiedAt == nil
+		}), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	// the value of Site was changed while its old value was verified
+	s.Require().NoError(s.repo.VerifyFields(context.Background(), 1, []string{"https://blog.example.com", "https://example.com"}))
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,303 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type (
+	// Implementation for repository.Report
+	report struct {
+		db *sqlx.DB
+	}
+)
+
+// Create report repository
+func NewReport(db *sqlx.DB) repository.Report {
+	return &report{db: db}
+}
+
+// Create : 通報を作成し、対象のステータスを複製
+func (r *report) Create(ctx context.Context, report *object.Report, statusIDs []int64) (int64, error) {
+	ctx, end := instrument(ctx, "report.Create")
+	defer end()
+
+	var id int64
+	err := Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		const insert = `INSERT INTO report (account_id, target_account_id, category, comment) VALUES (?, ?, ?, ?)`
+		res, err := tx.ExecContext(ctx, insert, report.AccountID, report.TargetAccountID, report.Category, report.Comment)
+		if err != nil {
+			return err
+		}
+		id, err = res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		if len(statusIDs) == 0 {
+			return nil
+		}
+
+		// the statuses are locked, so deletion waits for the snapshots
+		findStatuses, params, err := sqlx.In(`SELECT id, content, sensitive, create_at FROM status WHERE id IN (?) AND account_id = ? FOR UPDATE`, statusIDs, report.TargetAccountID)
+		if err != nil {
+			return err
+		}
+		statuses := []object.Status{}
+		if err := tx.SelectContext(ctx, &statuses, findStatuses, params...); err != nil {
+			return err
+		} else if len(statuses) != len(statusIDs) {
+			return repository.NewValidation("status_ids", "ERR_INVALID", "contains statuses not posted by the account")
+		}
+
+		for _, status := range statuses {
+			if err := snapshotStatus(ctx, tx, id, &status); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// snapshotStatus copies status into report_status
+// The media blobs are referenced by the snapshot, so they outlive the status.
+func snapshotStatus(ctx context.Context, tx *sqlx.Tx, reportID int64, status *object.Status) error {
+	const findAttachments = `SELECT a.*
+							FROM status_attachment as sa
+							JOIN attachment as a
+							ON sa.attachment_id = a.id
+							WHERE sa.status_id = ?`
+	attachments := object.Attachments{}
+	if err := tx.SelectContext(ctx, &attachments, findAttachments, status.ID); err != nil {
+		return err
+	}
+
+	for _, attachment := range attachments {
+		if attachment.BlobHash == nil {
+			continue
+		}
+		const acquire = `UPDATE media_blob SET ref_count = ref_count + 1 WHERE hash = ?`
+		if _, err := tx.ExecContext(ctx, acquire, *attachment.BlobHash); err != nil {
+			return err
+		}
+	}
+
+	const insert = `INSERT INTO report_status (report_id, status_id, content, sensitive, media_attachments, create_at)
+					VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := tx.ExecContext(ctx, insert, reportID, status.ID, status.Content, status.Sensitive, attachments, status.CreateAt.Time)
+	return err
+}
+
+// FindByID : IDから通報と複製したステータス、メモ、履歴を取得
+func (r *report) FindByID(ctx context.Context, id int64) (*object.Report, error) {
+	ctx, end := instrument(ctx, "report.FindByID")
+	defer end()
+
+	report := &object.Report{}
+	const find = `SELECT * FROM report WHERE id = ?`
+	if err := r.db.QueryRowxContext(ctx, find, id).StructScan(report); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	report.Statuses = []object.ReportStatus{}
+	const findStatuses = `SELECT status_id, content, sensitive, media_attachments, create_at FROM report_status WHERE report_id = ? ORDER BY status_id`
+	if err := r.db.SelectContext(ctx, &report.Statuses, findStatuses, id); err != nil {
+		return nil, err
+	}
+
+	report.Notes = []object.ReportNote{}
+	const findNotes = `SELECT id, account_id, content, create_at FROM report_note WHERE report_id = ? ORDER BY id`
+	if err := r.db.SelectContext(ctx, &report.Notes, findNotes, id); err != nil {
+		return nil, err
+	}
+
+	report.History = []object.ReportHistory{}
+	const findHistory = `SELECT id, account_id, action, assigned_account_id, create_at FROM report_history WHERE report_id = ? ORDER BY id`
+	if err := r.db.SelectContext(ctx, &report.History, findHistory, id); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// List : 条件に一致する通報を新しい順に取得
+func (r *report) List(ctx context.Context, filter object.ReportFilter, maxID, sinceID, limit int64) ([]object.Report, error) {
+	ctx, end := instrument(ctx, "report.List")
+	defer end()
+
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.State != "" {
+		conditions = append(conditions, "state = ?")
+		args = append(args, filter.State)
+	}
+	if filter.AssignedAccountID != 0 {
+		conditions = append(conditions, "assigned_account_id = ?")
+		args = append(args, filter.AssignedAccountID)
+	}
+	if filter.TargetAccountID != 0 {
+		conditions = append(conditions, "target_account_id = ?")
+		args = append(args, filter.TargetAccountID)
+	}
+	if maxID != 0 {
+		conditions = append(conditions, "id <= ?")
+		args = append(args, maxID)
+	}
+	if sinceID != 0 {
+		conditions = append(conditions, "id >= ?")
+		args = append(args, sinceID)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	list := fmt.Sprintf(`SELECT * FROM report %s ORDER BY id DESC LIMIT ?`, where)
+	args = append(args, limit)
+
+	reports := []object.Report{}
+	if err := r.db.SelectContext(ctx, &reports, list, args...); err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+// Assign : 通報の担当者を変更
+func (r *report) Assign(ctx context.Context, id, actorID int64, assigneeID *int64) error {
+	ctx, end := instrument(ctx, "report.Assign")
+	defer end()
+
+	return Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		before, err := lockReport(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		const assign = `UPDATE report SET assigned_account_id = ? WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, assign, assigneeID, id); err != nil {
+			return err
+		}
+
+		action, logAction := object.ReportActionAssign, object.ActionAssignReport
+		if assigneeID == nil {
+			action, logAction = object.ReportActionUnassign, object.ActionUnassignReport
+		}
+		if err := recordReportHistory(ctx, tx, id, actorID, action, assigneeID); err != nil {
+			return err
+		}
+
+		after := *before
+		after.AssignedAccountID = assigneeID
+		return recordAction(ctx, tx, actorID, logAction, object.TargetReport, id, before, &after)
+	})
+}
+
+// Resolve : 未解決の通報を解決済みにする
+func (r *report) Resolve(ctx context.Context, id, actorID int64) error {
+	ctx, end := instrument(ctx, "report.Resolve")
+	defer end()
+
+	return Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		before, err := lockReport(ctx, tx, id)
+		if err != nil {
+			return err
+		} else if before.State != object.ReportOpen {
+			return repository.NewConflict("report", "state", "report is already resolved")
+		}
+
+		const resolve = `UPDATE report SET state = ?, resolved_at = NOW() WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, resolve, object.ReportResolved, id); err != nil {
+			return err
+		}
+		if err := recordReportHistory(ctx, tx, id, actorID, object.ReportActionResolve, nil); err != nil {
+			return err
+		}
+
+		after := *before
+		after.State = object.ReportResolved
+		return recordAction(ctx, tx, actorID, object.ActionResolveReport, object.TargetReport, id, before, &after)
+	})
+}
+
+// Reopen : 解決済みの通報を未解決に戻す
+func (r *report) Reopen(ctx context.Context, id, actorID int64) error {
+	ctx, end := instrument(ctx, "report.Reopen")
+	defer end()
+
+	return Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		before, err := lockReport(ctx, tx, id)
+		if err != nil {
+			return err
+		} else if before.State != object.ReportResolved {
+			return repository.NewConflict("report", "state", "report is already open")
+		}
+
+		const reopen = `UPDATE report SET state = ?, resolved_at = NULL WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, reopen, object.ReportOpen, id); err != nil {
+			return err
+		}
+		if err := recordReportHistory(ctx, tx, id, actorID, object.ReportActionReopen, nil); err != nil {
+			return err
+		}
+
+		after := *before
+		after.State = object.ReportOpen
+		return recordAction(ctx, tx, actorID, object.ActionReopenReport, object.TargetReport, id, before, &after)
+	})
+}
+
+// AddNote : 通報にメモを追加
+func (r *report) AddNote(ctx context.Context, id, actorID int64, content string) error {
+	ctx, end := instrument(ctx, "report.AddNote")
+	defer end()
+
+	return Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		if _, err := lockReport(ctx, tx, id); err != nil {
+			return err
+		}
+
+		const insert = `INSERT INTO report_note (report_id, account_id, content) VALUES (?, ?, ?)`
+		if _, err := tx.ExecContext(ctx, insert, id, actorID, content); err != nil {
+			return err
+		}
+		if err := recordReportHistory(ctx, tx, id, actorID, object.ReportActionNote, nil); err != nil {
+			return err
+		}
+		return recordAction(ctx, tx, actorID, object.ActionNoteReport, object.TargetReport, id, nil, &noteState{Content: content})
+	})
+}
+
+// lockReport locks report row and returns its state, NotFoundError if it does not exist
+func lockReport(ctx context.Context, tx *sqlx.Tx, id int64) (*reportState, error) {
+	state := &reportState{}
+	const lock = `SELECT state, assigned_account_id FROM report WHERE id = ? FOR UPDATE`
+	if err := tx.QueryRowxContext(ctx, lock, id).StructScan(state); errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.NewNotFound("report")
+	} else if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// recordReportHistory appends action to the history of report
+func recordReportHistory(ctx context.Context, tx *sqlx.Tx, id, actorID int64, action string, assigneeID *int64) error {
+	const insert = `INSERT INTO report_history (report_id, account_id, action, assigned_account_id) VALUES (?, ?, ?, ?)`
+	_, err := tx.ExecContext(ctx, insert, id, actorID, action, assigneeID)
+	return err
+}
@@ -0,0 +1,114 @@
+package dao_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReportTestSuite struct {
+	DatabaseTestSuite
+
+	repo repository.Report
+}
+
+func (s *ReportTestSuite) SetupTest() {
+	s.setupSuite()
+
+	s.repo = dao.NewReport(s.sqlxDB)
+}
+
+func (s *ReportTestSuite) TearDownTest() {
+	s.tearDownSuite()
+}
+
+func TestReportSuite(t *testing.T) {
+	suite.Run(t, new(ReportTestSuite))
+}
+
+func (s *ReportTestSuite) TestCreateSnapshotsStatuses() {
+	createAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO report (account_id, target_account_id, category, comment) VALUES (?, ?, ?, ?)`)).
+		WithArgs(1, 2, object.ReportSpam, "spam").
+		WillReturnResult(sqlmock.NewResult(10, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, content, sensitive, create_at FROM status WHERE id IN (?) AND account_id = ? FOR UPDATE`)).
+		WithArgs(3, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "content", "sensitive", "create_at"}).AddRow(3, "buy now", false, createAt))
+	s.mock.ExpectQuery(`SELECT a.\*\s+FROM status_attachment`).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type", "url", "description", "file_path", "blob_hash"}).
+			AddRow(5, "image", "http://example.com/a.png", "", "/files/a.png", "abcd"))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE media_blob SET ref_count = ref_count + 1 WHERE hash = ?`)).
+		WithArgs("abcd").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO report_status`)).
+		WithArgs(10, 3, "buy now", false, `[{"id":5,"type":"image","url":"http://example.com/a.png","description":""}]`, createAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
+
+	id, err := s.repo.Create(context.Background(), &object.Report{
+		AccountID: 1, TargetAccountID: 2, Category: object.ReportSpam, Comment: "spam",
+	}, []int64{3})
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(10), id)
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *ReportTestSuite) TestCreateRejectsStatusesOfOthers() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO report`)).
+		WillReturnResult(sqlmock.NewResult(10, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, content, sensitive, create_at FROM status`)).
+		WithArgs(3, 4, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "content", "sensitive", "create_at"}).AddRow(3, "hi", false, time.Now()))
+	s.mock.ExpectRollback()
+
+	_, err := s.repo.Create(context.Background(), &object.Report{AccountID: 1, TargetAccountID: 2}, []int64{3, 4})
+	var validation *repository.ValidationError
+	s.Assert().ErrorAs(err, &validation)
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *ReportTestSuite) TestResolveRecordsHistory() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT state, assigned_account_id FROM report WHERE id = ? FOR UPDATE`)).
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"state", "assigned_account_id"}).AddRow(object.ReportOpen, 2))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE report SET state = ?, resolved_at = NOW() WHERE id = ?`)).
+		WithArgs(object.ReportResolved, 10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO report_history (report_id, account_id, action, assigned_account_id) VALUES (?, ?, ?, ?)`)).
+		WithArgs(10, 1, object.ReportActionResolve, nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO admin_action_log`)).
+		WithArgs(1, object.ActionResolveReport, object.TargetReport, 10,
+			`{"state":"open","assigned_account_id":2}`,
+			`{"state":"resolved","assigned_account_id":2}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
+
+	s.Require().NoError(s.repo.Resolve(context.Background(), 10, 1))
+
+	// resolving twice conflicts
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT state, assigned_account_id FROM report WHERE id = ? FOR UPDATE`)).
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"state", "assigned_account_id"}).AddRow(object.ReportResolved, nil))
+	s.mock.ExpectRollback()
+
+	var conflict *repository.ConflictError
+	s.Assert().ErrorAs(s.repo.Resolve(context.Background(), 10, 1), &conflict)
+
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
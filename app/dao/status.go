@@ -23,11 +23,16 @@ func NewStatus(db *sqlx.DB) repository.Status {
 
 // CreateAccount : content, accountIDから新しいステータスを作成
 func (r *status) Create(ctx context.Context, accountID int64, content string, attachmentIDs []int64) (int64, error) {
+	ctx, end := instrument(ctx, "status.Create")
+	defer end()
+
 	var id int64
 
-	err := Transaction(r.db, func(tx *sqlx.Tx) error {
-		const registerStatus = `INSERT INTO status (account_id, content) VALUES (?, ?)`
-		res, err := tx.ExecContext(ctx, registerStatus, accountID, content)
+	err := Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		// media of sensitized accounts is sensitive, as well as of accounts which mark it by default
+		const registerStatus = `INSERT INTO status (account_id, content, sensitive)
+								VALUES (?, ?, (SELECT sensitized_at IS NOT NULL OR default_sensitive FROM account WHERE id = ?))`
+		res, err := tx.ExecContext(ctx, registerStatus, accountID, content, accountID)
 		if err != nil {
 			return err
 		}
@@ -40,15 +45,21 @@ func (r *status) Create(ctx context.Context, accountID int64, content string, at
 			return nil
 		}
 
-		count := 0
-		findAttachments, params, err := sqlx.In(`SELECT COUNT(*) FROM attachment WHERE id IN (?)`, attachmentIDs)
+		// attachments of other accounts are unknown as well as failed ones
+		findAttachments, params, err := sqlx.In(`SELECT state FROM attachment WHERE id IN (?) AND account_id = ? AND state <> 'failed'`, attachmentIDs, accountID)
 		if err != nil {
 			return err
 		}
-		if err := tx.QueryRowxContext(ctx, findAttachments, params...).Scan(&count); err != nil {
+		states := []string{}
+		if err := tx.SelectContext(ctx, &states, findAttachments, params...); err != nil {
 			return err
-		} else if count != len(attachmentIDs) {
-			return fmt.Errorf("attachments %v specified by 'media_ids' is invalid", attachmentIDs)
+		} else if len(states) != len(attachmentIDs) {
+			return repository.NewValidation("media_ids", "ERR_INVALID", fmt.Sprintf("%v contains unknown attachments", attachmentIDs))
+		}
+		for _, state := range states {
+			if state == object.AttachmentProcessing {
+				return repository.ErrAttachmentProcessing
+			}
 		}
 
 		type StatusAttachment struct {
@@ -78,6 +89,9 @@ func (r *status) Create(ctx context.Context, accountID int64, content string, at
 
 // FindByID : IDからステータスを取得
 func (r *status) FindByID(ctx context.Context, id int64) (*object.Status, error) {
+	ctx, end := instrument(ctx, "status.FindByID")
+	defer end()
+
 	status := &object.Status{}
 
 	const findStatus = `SELECT * FROM status WHERE id = ?`
@@ -113,34 +127,73 @@ func (r *status) findAttachments(ctx context.Context, id int64) ([]object.Attach
 
 // DeleteByID : IDからステータスを削除
 func (r *status) DeleteByID(ctx context.Context, id int64) error {
+	ctx, end := instrument(ctx, "status.DeleteByID")
+	defer end()
+
+	var blobs []string
+	err := Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		var err error
+		blobs, err = deleteStatus(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	removeBlobs(blobs)
+	return nil
+}
+
+// deleteStatus deletes status with its attachments, NotFoundError if it does not exist
+// The blobs released with the attachments are returned to be removed after commit
+func deleteStatus(ctx context.Context, tx *sqlx.Tx, id int64) ([]string, error) {
+	attachmentIDs := []int64{}
+	const findAttachments = `SELECT attachment_id FROM status_attachment WHERE status_id = ?`
+	if err := tx.SelectContext(ctx, &attachmentIDs, findAttachments, id); err != nil {
+		return nil, err
+	}
+
+	const detach = `DELETE FROM status_attachment WHERE status_id = ?`
+	if _, err := tx.ExecContext(ctx, detach, id); err != nil {
+		return nil, err
+	}
+
 	query := `delete
 			  from status
 			  where id = ?`
 
-	res, err := r.db.ExecContext(ctx, query, id)
+	res, err := tx.ExecContext(ctx, query, id)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	count, err := res.RowsAffected()
 	if err != nil {
-		return err
+		return nil, err
 	} else if count == 0 {
-		return fmt.Errorf("status %d is not found", id)
+		return nil, repository.NewNotFound("status")
 	}
 
-	return nil
+	return deleteAttachments(ctx, tx, attachmentIDs)
 }
 
 // ListAll : maxID, sinceID, limit からタイムライン（ステータスのスライス）を取得
 func (r *status) ListAll(ctx context.Context, maxID, sinceID, limit int64) ([]object.Status, error) {
-	idRange, _ := BuildRangeQuery("s.id", maxID, sinceID, 0)
+	ctx, end := instrument(ctx, "status.ListAll")
+	defer end()
+
+	// statuses of moderated or deleted accounts are not public
+	const public = "a.suspended_at IS NULL AND a.silenced_at IS NULL AND a.deleted_at IS NULL"
+	where := "WHERE " + public
+	if idRange, ok := BuildRangeQuery("s.id", maxID, sinceID, 0); ok {
+		where = idRange + " AND " + public
+	}
 	listAll := fmt.Sprintf(`SELECT s.*, a.username AS "account.username", a.followers_count AS "account.followers_count", a.following_count AS "account.following_count", a.create_at AS "account.create_at"
 							FROM status as s
 							JOIN account as a
 							on s.account_id = a.id
 							%s
 							ORDER BY s.id
-							LIMIT %d`, idRange, limit)
+							LIMIT %d`, where, limit)
 	statuses := []object.Status{}
 	if err := r.db.SelectContext(ctx, &statuses, listAll); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -162,6 +215,9 @@ func (r *status) ListAll(ctx context.Context, maxID, sinceID, limit int64) ([]ob
 
 // ListByID : 認証されたアカウントのID, maxID, sinceID, limit からタイムライン（ステータスのスライス）を取得
 func (r *status) ListByID(ctx context.Context, id, maxID, sinceID, limit int64) ([]object.Status, error) {
+	ctx, end := instrument(ctx, "status.ListByID")
+	defer end()
+
 	connection := ""
 	idRange, ok := BuildRangeQuery("id", maxID, sinceID, 0)
 	if ok {
@@ -169,7 +225,7 @@ func (r *status) ListByID(ctx context.Context, id, maxID, sinceID, limit int64)
 	} else {
 		connection = "WHERE"
 	}
-	listByID := fmt.Sprintf(`SELECT * FROM status %s %s account_id = %d LIMIT %d`, idRange, connection, id, limit)
+	listByID := fmt.Sprintf(`SELECT * FROM status %s %s account_id = %d ORDER BY id LIMIT %d`, idRange, connection, id, limit)
 	statuses := []object.Status{}
 	if err := r.db.SelectContext(ctx, &statuses, listByID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -2,6 +2,8 @@ package dao_test
 
 import (
 	"context"
+	"errors"
+	"regexp"
 	"testing"
 	"yatter-backend-go/app/dao"
 	"yatter-backend-go/app/domain/repository"
@@ -61,7 +63,7 @@ func (s *StatusTestSuite) TestCreate() {
 
 			s.mock.ExpectBegin()
 			s.mock.ExpectExec(`INSERT INTO status`).
-				WithArgs(tt.in.ID, tt.in.Content).
+				WithArgs(tt.in.ID, tt.in.Content, tt.in.ID).
 				WillReturnResult(sqlmock.NewResult(1, 1))
 			// if tt.in.AttachmentIDs != nil {
 			// 	rows := sqlmock.NewRows([]string{"count"}).AddRow(len(tt.in.AttachmentIDs))
@@ -91,3 +93,20 @@ func (s *StatusTestSuite) TestCreate() {
 		})
 	}
 }
+
+func (s *StatusTestSuite) TestCreateWithAttachmentOfOtherAccount() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(`INSERT INTO status`).
+		WithArgs(int64(1), "stolen", int64(1)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	// the attachment 5 is uploaded by another account, so it is not found for the account 1
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT state FROM attachment WHERE id IN (?) AND account_id = ? AND state <> 'failed'`)).
+		WithArgs(int64(5), int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"state"}))
+	s.mock.ExpectRollback()
+
+	_, err := s.repo.Create(context.Background(), 1, "stolen", []int64{5})
+	var verr *repository.ValidationError
+	s.Assert().True(errors.As(err, &verr), "want validation error, but %v", err)
+	s.Assert().NoError(s.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,160 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type (
+	// Implementation for repository.TwoFactor
+	twoFactor struct {
+		db *sqlx.DB
+	}
+)
+
+// Create two-factor repository
+func NewTwoFactor(db *sqlx.DB) repository.TwoFactor {
+	return &twoFactor{db: db}
+}
+
+// FindByAccountID : アカウントの二要素認証設定を取得
+func (r *twoFactor) FindByAccountID(ctx context.Context, accountID int64) (*object.TwoFactor, error) {
+	ctx, end := instrument(ctx, "twoFactor.FindByAccountID")
+	defer end()
+
+	tf := &object.TwoFactor{}
+	const find = `SELECT * FROM two_factor WHERE account_id = ?`
+	if err := r.db.QueryRowxContext(ctx, find, accountID).StructScan(tf); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return tf, nil
+}
+
+// Setup : 未確認のシークレットを登録、有効化済みであれば Conflict
+func (r *twoFactor) Setup(ctx context.Context, accountID int64, secret string) error {
+	ctx, end := instrument(ctx, "twoFactor.Setup")
+	defer end()
+
+	const setup = `INSERT INTO two_factor (account_id, secret) VALUES (?, ?)
+					ON DUPLICATE KEY UPDATE
+					secret = IF(enabled, secret, VALUES(secret)),
+					last_counter = IF(enabled, last_counter, 0),
+					create_at = IF(enabled, create_at, NOW())`
+	if _, err := r.db.ExecContext(ctx, setup, accountID, secret); err != nil {
+		return err
+	}
+
+	// the secret is kept if it is already enabled
+	tf, err := r.FindByAccountID(ctx, accountID)
+	if err != nil {
+		return err
+	} else if tf != nil && tf.Enabled {
+		return repository.NewConflict("two_factor", "", "two-factor authentication is already enabled")
+	}
+	return nil
+}
+
+// Enable : 二要素認証を有効化し、リカバリーコードを置き換え
+func (r *twoFactor) Enable(ctx context.Context, accountID, counter int64, recoveryCodeHashes []string) error {
+	ctx, end := instrument(ctx, "twoFactor.Enable")
+	defer end()
+
+	return Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		const enable = `UPDATE two_factor SET enabled = TRUE, last_counter = ? WHERE account_id = ? AND NOT enabled`
+		res, err := tx.ExecContext(ctx, enable, counter, accountID)
+		if err != nil {
+			return err
+		}
+		if count, err := res.RowsAffected(); err != nil {
+			return err
+		} else if count == 0 {
+			return repository.NewNotFound("two_factor")
+		}
+
+		const clear = `DELETE FROM recovery_code WHERE account_id = ?`
+		if _, err := tx.ExecContext(ctx, clear, accountID); err != nil {
+			return err
+		}
+
+		type RecoveryCode struct {
+			AccountID int64  `db:"account_id"`
+			CodeHash  string `db:"code_hash"`
+		}
+		codes := make([]RecoveryCode, len(recoveryCodeHashes))
+		for i, hash := range recoveryCodeHashes {
+			codes[i] = RecoveryCode{AccountID: accountID, CodeHash: hash}
+		}
+		const insert = `INSERT INTO recovery_code (account_id, code_hash) VALUES (:account_id, :code_hash)`
+		_, err = tx.NamedExecContext(ctx, insert, codes)
+		return err
+	})
+}
+
+// Disable : 二要素認証を無効化し、リカバリーコードを削除
+func (r *twoFactor) Disable(ctx context.Context, accountID int64) error {
+	ctx, end := instrument(ctx, "twoFactor.Disable")
+	defer end()
+
+	return Transaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		const clear = `DELETE FROM recovery_code WHERE account_id = ?`
+		if _, err := tx.ExecContext(ctx, clear, accountID); err != nil {
+			return err
+		}
+
+		const disable = `DELETE FROM two_factor WHERE account_id = ?`
+		res, err := tx.ExecContext(ctx, disable, accountID)
+		if err != nil {
+			return err
+		}
+		if count, err := res.RowsAffected(); err != nil {
+			return err
+		} else if count == 0 {
+			return repository.NewNotFound("two_factor")
+		}
+		return nil
+	})
+}
+
+// UseCounter : 最後に受け付けたタイムステップより新しければ記録
+func (r *twoFactor) UseCounter(ctx context.Context, accountID, counter int64) (bool, error) {
+	ctx, end := instrument(ctx, "twoFactor.UseCounter")
+	defer end()
+
+	// the condition makes concurrent uses of the same code accept only one
+	const use = `UPDATE two_factor SET last_counter = ? WHERE account_id = ? AND last_counter < ?`
+	res, err := r.db.ExecContext(ctx, use, counter, accountID, counter)
+	if err != nil {
+		return false, err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// UseRecoveryCode : 未使用のリカバリーコードを使用済みにする
+func (r *twoFactor) UseRecoveryCode(ctx context.Context, accountID int64, codeHash string) (bool, error) {
+	ctx, end := instrument(ctx, "twoFactor.UseRecoveryCode")
+	defer end()
+
+	const use = `UPDATE recovery_code SET used_at = NOW() WHERE account_id = ? AND code_hash = ? AND used_at IS NULL`
+	res, err := r.db.ExecContext(ctx, use, accountID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
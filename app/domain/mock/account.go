@@ -9,13 +9,22 @@ import (
 type AccountMock struct {
 	FindByUsernameFunc    func(ctx context.Context, username string) (*object.Account, error)
 	FindByIDFunc          func(ctx context.Context, id int64) (*object.Account, error)
-	CreateAccountFunc     func(ctx context.Context, username, password string) (int64, error)
+	FindByEmailFunc       func(ctx context.Context, email string) (*object.Account, error)
+	CreateAccountFunc     func(ctx context.Context, registration *object.Registration) (int64, error)
 	FollowFunc            func(ctx context.Context, followerID, followeeID int64) (int64, bool, error)
 	UnfollowFunc          func(ctx context.Context, followerID, followeeID int64) (int64, bool, error)
 	FindRelationshipFunc  func(ctx context.Context, userID, targetID int64) (bool, bool, error)
+	BlockFunc             func(ctx context.Context, accountID, targetID int64) error
+	MuteFunc              func(ctx context.Context, accountID, targetID int64) error
+	FindBlockAndMuteFunc  func(ctx context.Context, accountID, targetID int64) (bool, bool, error)
 	FindFollowingFunc     func(ctx context.Context, followerID, limit int64) ([]object.Account, error)
 	FindFollowersFunc     func(ctx context.Context, followeeID, maxID, sinceID, limit int64) ([]object.Account, error)
-	UpdateCredentialsFunc func(ctx context.Context, id int64, displayName, note, avatar, header string) error
+	UpdateCredentialsFunc func(ctx context.Context, id int64, update *object.CredentialsUpdate) error
+	VerifyFieldsFunc      func(ctx context.Context, id int64, values []string) error
+	UpdatePasswordFunc    func(ctx context.Context, id int64, passwordHash string, keepTokenID int64) error
+	VerifyEmailFunc       func(ctx context.Context, id int64) error
+	DeleteFunc            func(ctx context.Context, id int64) error
+	PurgeFunc             func(ctx context.Context, id int64) error
 }
 
 // FindByUsername is a mock implementation of Account.FindByUsername
@@ -28,9 +37,14 @@ func (m *AccountMock) FindByID(ctx context.Context, id int64) (*object.Account,
 	return m.FindByIDFunc(ctx, id)
 }
 
+// FindByEmail is a mock implementation of Account.FindByEmail
+func (m *AccountMock) FindByEmail(ctx context.Context, email string) (*object.Account, error) {
+	return m.FindByEmailFunc(ctx, email)
+}
+
 // CreateAccount is a mock implementation of Account.CreateAccount
-func (m *AccountMock) CreateAccount(ctx context.Context, username, password string) (int64, error) {
-	return m.CreateAccountFunc(ctx, username, password)
+func (m *AccountMock) CreateAccount(ctx context.Context, registration *object.Registration) (int64, error) {
+	return m.CreateAccountFunc(ctx, registration)
 }
 
 // Follow is a mock implementation of Account.Follow
@@ -48,6 +62,21 @@ func (m *AccountMock) FindRelationship(ctx context.Context, userID, targetID int
 	return m.FindRelationshipFunc(ctx, userID, targetID)
 }
 
+// Block is a mock implementation of Account.Block
+func (m *AccountMock) Block(ctx context.Context, accountID, targetID int64) error {
+	return m.BlockFunc(ctx, accountID, targetID)
+}
+
+// Mute is a mock implementation of Account.Mute
+func (m *AccountMock) Mute(ctx context.Context, accountID, targetID int64) error {
+	return m.MuteFunc(ctx, accountID, targetID)
+}
+
+// FindBlockAndMute is a mock implementation of Account.FindBlockAndMute
+func (m *AccountMock) FindBlockAndMute(ctx context.Context, accountID, targetID int64) (bool, bool, error) {
+	return m.FindBlockAndMuteFunc(ctx, accountID, targetID)
+}
+
 // FindFollowing is a mock implementation of Account.FindFollowing
 func (m *AccountMock) FindFollowing(ctx context.Context, followerID, limit int64) ([]object.Account, error) {
 	return m.FindFollowingFunc(ctx, followerID, limit)
@@ -59,6 +88,31 @@ func (m *AccountMock) FindFollowers(ctx context.Context, followeeID, maxID, sinc
 }
 
 // UpdateCredentials is a mock implementation of Account.UpdateCredentials
-func (m *AccountMock) UpdateCredentials(ctx context.Context, id int64, displayName, note, avatar, header string) error {
-	return m.UpdateCredentialsFunc(ctx, id, displayName, note, avatar, header)
+func (m *AccountMock) UpdateCredentials(ctx context.Context, id int64, update *object.CredentialsUpdate) error {
+	return m.UpdateCredentialsFunc(ctx, id, update)
+}
+
+// VerifyFields is a mock implementation of Account.VerifyFields
+func (m *AccountMock) VerifyFields(ctx context.Context, id int64, values []string) error {
+	return m.VerifyFieldsFunc(ctx, id, values)
+}
+
+// UpdatePassword is a mock implementation of Account.UpdatePassword
+func (m *AccountMock) UpdatePassword(ctx context.Context, id int64, passwordHash string, keepTokenID int64) error {
+	return m.UpdatePasswordFunc(ctx, id, passwordHash, keepTokenID)
+}
+
+// VerifyEmail is a mock implementation of Account.VerifyEmail
+func (m *AccountMock) VerifyEmail(ctx context.Context, id int64) error {
+	return m.VerifyEmailFunc(ctx, id)
+}
+
+// Delete is a mock implementation of Account.Delete
+func (m *AccountMock) Delete(ctx context.Context, id int64) error {
+	return m.DeleteFunc(ctx, id)
+}
+
+// Purge is a mock implementation of Account.Purge
+func (m *AccountMock) Purge(ctx context.Context, id int64) error {
+	return m.PurgeFunc(ctx, id)
 }
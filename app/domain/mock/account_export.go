@@ -0,0 +1,34 @@
+package mock
+
+import (
+	"context"
+	"yatter-backend-go/app/domain/object"
+)
+
+// AccountExportMock is a mock implementation of AccountExport
+type AccountExportMock struct {
+	CreateFunc   func(ctx context.Context, accountID int64) (*object.AccountExport, error)
+	FindByIDFunc func(ctx context.Context, id int64) (*object.AccountExport, error)
+	CompleteFunc func(ctx context.Context, id int64, path string) error
+	FailFunc     func(ctx context.Context, id int64) error
+}
+
+// Create is a mock implementation of AccountExport.Create
+func (m *AccountExportMock) Create(ctx context.Context, accountID int64) (*object.AccountExport, error) {
+	return m.CreateFunc(ctx, accountID)
+}
+
+// FindByID is a mock implementation of AccountExport.FindByID
+func (m *AccountExportMock) FindByID(ctx context.Context, id int64) (*object.AccountExport, error) {
+	return m.FindByIDFunc(ctx, id)
+}
+
+// Complete is a mock implementation of AccountExport.Complete
+func (m *AccountExportMock) Complete(ctx context.Context, id int64, path string) error {
+	return m.CompleteFunc(ctx, id, path)
+}
+
+// Fail is a mock implementation of AccountExport.Fail
+func (m *AccountExportMock) Fail(ctx context.Context, id int64) error {
+	return m.FailFunc(ctx, id)
+}
@@ -0,0 +1,34 @@
+package mock
+
+import (
+	"context"
+	"yatter-backend-go/app/domain/object"
+)
+
+// AccountImportMock is a mock implementation of AccountImport
+type AccountImportMock struct {
+	CreateFunc   func(ctx context.Context, accountID int64, importType, data string, totalRows int) (*object.AccountImport, error)
+	FindByIDFunc func(ctx context.Context, id int64) (*object.AccountImport, error)
+	ProgressFunc func(ctx context.Context, id int64, processedRows int, failures []object.ImportFailure) error
+	FinishFunc   func(ctx context.Context, id int64, state string) error
+}
+
+// Create is a mock implementation of AccountImport.Create
+func (m *AccountImportMock) Create(ctx context.Context, accountID int64, importType, data string, totalRows int) (*object.AccountImport, error) {
+	return m.CreateFunc(ctx, accountID, importType, data, totalRows)
+}
+
+// FindByID is a mock implementation of AccountImport.FindByID
+func (m *AccountImportMock) FindByID(ctx context.Context, id int64) (*object.AccountImport, error) {
+	return m.FindByIDFunc(ctx, id)
+}
+
+// Progress is a mock implementation of AccountImport.Progress
+func (m *AccountImportMock) Progress(ctx context.Context, id int64, processedRows int, failures []object.ImportFailure) error {
+	return m.ProgressFunc(ctx, id, processedRows, failures)
+}
+
+// Finish is a mock implementation of AccountImport.Finish
+func (m *AccountImportMock) Finish(ctx context.Context, id int64, state string) error {
+	return m.FinishFunc(ctx, id, state)
+}
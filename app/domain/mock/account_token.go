@@ -0,0 +1,34 @@
+package mock
+
+import (
+	"context"
+	"time"
+)
+
+// AccountTokenMock is a mock implementation of AccountToken
+type AccountTokenMock struct {
+	CreateFunc func(ctx context.Context, accountID int64, purpose, tokenHash string, expiresAt time.Time) error
+	UseFunc    func(ctx context.Context, purpose, tokenHash string) (int64, error)
+	FindFunc   func(ctx context.Context, purpose, tokenHash string) (int64, int64, error)
+	RevokeFunc func(ctx context.Context, accountID int64, purpose string) error
+}
+
+// Create is a mock implementation of AccountToken.Create
+func (m *AccountTokenMock) Create(ctx context.Context, accountID int64, purpose, tokenHash string, expiresAt time.Time) error {
+	return m.CreateFunc(ctx, accountID, purpose, tokenHash, expiresAt)
+}
+
+// Use is a mock implementation of AccountToken.Use
+func (m *AccountTokenMock) Use(ctx context.Context, purpose, tokenHash string) (int64, error) {
+	return m.UseFunc(ctx, purpose, tokenHash)
+}
+
+// Find is a mock implementation of AccountToken.Find
+func (m *AccountTokenMock) Find(ctx context.Context, purpose, tokenHash string) (int64, int64, error) {
+	return m.FindFunc(ctx, purpose, tokenHash)
+}
+
+// Revoke is a mock implementation of AccountToken.Revoke
+func (m *AccountTokenMock) Revoke(ctx context.Context, accountID int64, purpose string) error {
+	return m.RevokeFunc(ctx, accountID, purpose)
+}
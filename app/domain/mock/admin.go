@@ -0,0 +1,46 @@
+package mock
+
+import (
+	"context"
+	"yatter-backend-go/app/domain/object"
+)
+
+// AdminMock is a mock implementation of Admin
+type AdminMock struct {
+	ListAccountsFunc    func(ctx context.Context, filter object.AccountFilter, maxID, sinceID, limit int64) ([]object.Account, error)
+	FindAccountFunc     func(ctx context.Context, id int64) (*object.Account, error)
+	ModerateAccountFunc func(ctx context.Context, id, actorID int64, action string) error
+	ChangeRoleFunc      func(ctx context.Context, id, actorID int64, role string) error
+	DeleteStatusFunc    func(ctx context.Context, id, actorID int64) error
+	ListActionLogsFunc  func(ctx context.Context, filter object.ActionLogFilter, maxID, sinceID, limit int64) ([]object.ActionLog, error)
+}
+
+// ListAccounts is a mock implementation of Admin.ListAccounts
+func (m *AdminMock) ListAccounts(ctx context.Context, filter object.AccountFilter, maxID, sinceID, limit int64) ([]object.Account, error) {
+	return m.ListAccountsFunc(ctx, filter, maxID, sinceID, limit)
+}
+
+// FindAccount is a mock implementation of Admin.FindAccount
+func (m *AdminMock) FindAccount(ctx context.Context, id int64) (*object.Account, error) {
+	return m.FindAccountFunc(ctx, id)
+}
+
+// ModerateAccount is a mock implementation of Admin.ModerateAccount
+func (m *AdminMock) ModerateAccount(ctx context.Context, id, actorID int64, action string) error {
+	return m.ModerateAccountFunc(ctx, id, actorID, action)
+}
+
+// ChangeRole is a mock implementation of Admin.ChangeRole
+func (m *AdminMock) ChangeRole(ctx context.Context, id, actorID int64, role string) error {
+	return m.ChangeRoleFunc(ctx, id, actorID, role)
+}
+
+// DeleteStatus is a mock implementation of Admin.DeleteStatus
+func (m *AdminMock) DeleteStatus(ctx context.Context, id, actorID int64) error {
+	return m.DeleteStatusFunc(ctx, id, actorID)
+}
+
+// ListActionLogs is a mock implementation of Admin.ListActionLogs
+func (m *AdminMock) ListActionLogs(ctx context.Context, filter object.ActionLogFilter, maxID, sinceID, limit int64) ([]object.ActionLog, error) {
+	return m.ListActionLogsFunc(ctx, filter, maxID, sinceID, limit)
+}
@@ -8,10 +8,34 @@ import (
 
 // AttachmentMock is a mock implementation of Attachment
 type AttachmentMock struct {
-	UploadFileFunc func(ctx context.Context, file io.Reader, fileDir, filename, filetype, description string) (*object.Attachment, error)
+	UploadFileFunc      func(ctx context.Context, accountID int64, file io.Reader, fileDir, baseURL, ext, filetype, description string, meta *object.AttachmentMeta) (*object.Attachment, error)
+	StoreFileFunc       func(ctx context.Context, accountID int64, file io.Reader, fileDir, ext, filetype, description string) (*object.Attachment, error)
+	FindByIDFunc        func(ctx context.Context, id int64) (*object.Attachment, error)
+	UpdateProcessedFunc func(ctx context.Context, attachment *object.Attachment) error
+	DeleteFunc          func(ctx context.Context, id int64) error
 }
 
 // UploadFile is a mock implementation of Attachment.UploadFile
-func (m *AttachmentMock) UploadFile(ctx context.Context, file io.Reader, fileDir, filename, filetype, description string) (*object.Attachment, error) {
-	return m.UploadFileFunc(ctx, file, fileDir, filename, filetype, description)
+func (m *AttachmentMock) UploadFile(ctx context.Context, accountID int64, file io.Reader, fileDir, baseURL, ext, filetype, description string, meta *object.AttachmentMeta) (*object.Attachment, error) {
+	return m.UploadFileFunc(ctx, accountID, file, fileDir, baseURL, ext, filetype, description, meta)
+}
+
+// StoreFile is a mock implementation of Attachment.StoreFile
+func (m *AttachmentMock) StoreFile(ctx context.Context, accountID int64, file io.Reader, fileDir, ext, filetype, description string) (*object.Attachment, error) {
+	return m.StoreFileFunc(ctx, accountID, file, fileDir, ext, filetype, description)
+}
+
+// FindByID is a mock implementation of Attachment.FindByID
+func (m *AttachmentMock) FindByID(ctx context.Context, id int64) (*object.Attachment, error) {
+	return m.FindByIDFunc(ctx, id)
+}
+
+// UpdateProcessed is a mock implementation of Attachment.UpdateProcessed
+func (m *AttachmentMock) UpdateProcessed(ctx context.Context, attachment *object.Attachment) error {
+	return m.UpdateProcessedFunc(ctx, attachment)
+}
+
+// Delete is a mock implementation of Attachment.Delete
+func (m *AttachmentMock) Delete(ctx context.Context, id int64) error {
+	return m.DeleteFunc(ctx, id)
 }
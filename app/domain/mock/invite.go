@@ -0,0 +1,34 @@
+package mock
+
+import (
+	"context"
+	"yatter-backend-go/app/domain/object"
+)
+
+// InviteMock is a mock implementation of Invite
+type InviteMock struct {
+	CreateFunc          func(ctx context.Context, invite *object.Invite) (int64, error)
+	FindByIDFunc        func(ctx context.Context, id int64) (*object.Invite, error)
+	ListByAccountIDFunc func(ctx context.Context, accountID, maxID, sinceID, limit int64) ([]object.Invite, error)
+	ExpireFunc          func(ctx context.Context, id int64) error
+}
+
+// Create is a mock implementation of Invite.Create
+func (m *InviteMock) Create(ctx context.Context, invite *object.Invite) (int64, error) {
+	return m.CreateFunc(ctx, invite)
+}
+
+// FindByID is a mock implementation of Invite.FindByID
+func (m *InviteMock) FindByID(ctx context.Context, id int64) (*object.Invite, error) {
+	return m.FindByIDFunc(ctx, id)
+}
+
+// ListByAccountID is a mock implementation of Invite.ListByAccountID
+func (m *InviteMock) ListByAccountID(ctx context.Context, accountID, maxID, sinceID, limit int64) ([]object.Invite, error) {
+	return m.ListByAccountIDFunc(ctx, accountID, maxID, sinceID, limit)
+}
+
+// Expire is a mock implementation of Invite.Expire
+func (m *InviteMock) Expire(ctx context.Context, id int64) error {
+	return m.ExpireFunc(ctx, id)
+}
@@ -0,0 +1,35 @@
+package mock
+
+import (
+	"context"
+	"time"
+	"yatter-backend-go/app/domain/object"
+)
+
+// JobMock is a mock implementation of Job
+type JobMock struct {
+	EnqueueFunc  func(ctx context.Context, kind, payload string) (int64, error)
+	ClaimFunc    func(ctx context.Context, lease time.Duration) (*object.Job, error)
+	CompleteFunc func(ctx context.Context, id int64) error
+	FailFunc     func(ctx context.Context, id int64, reason string, backoff time.Duration) error
+}
+
+// Enqueue is a mock implementation of Job.Enqueue
+func (m *JobMock) Enqueue(ctx context.Context, kind, payload string) (int64, error) {
+	return m.EnqueueFunc(ctx, kind, payload)
+}
+
+// Claim is a mock implementation of Job.Claim
+func (m *JobMock) Claim(ctx context.Context, lease time.Duration) (*object.Job, error) {
+	return m.ClaimFunc(ctx, lease)
+}
+
+// Complete is a mock implementation of Job.Complete
+func (m *JobMock) Complete(ctx context.Context, id int64) error {
+	return m.CompleteFunc(ctx, id)
+}
+
+// Fail is a mock implementation of Job.Fail
+func (m *JobMock) Fail(ctx context.Context, id int64, reason string, backoff time.Duration) error {
+	return m.FailFunc(ctx, id, reason, backoff)
+}
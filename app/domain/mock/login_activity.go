@@ -0,0 +1,22 @@
+package mock
+
+import (
+	"context"
+	"yatter-backend-go/app/domain/object"
+)
+
+// LoginActivityMock is a mock implementation of LoginActivity
+type LoginActivityMock struct {
+	CreateFunc          func(ctx context.Context, activity *object.LoginActivity) error
+	ListByAccountIDFunc func(ctx context.Context, accountID, maxID, sinceID, limit int64) ([]object.LoginActivity, error)
+}
+
+// Create is a mock implementation of LoginActivity.Create
+func (m *LoginActivityMock) Create(ctx context.Context, activity *object.LoginActivity) error {
+	return m.CreateFunc(ctx, activity)
+}
+
+// ListByAccountID is a mock implementation of LoginActivity.ListByAccountID
+func (m *LoginActivityMock) ListByAccountID(ctx context.Context, accountID, maxID, sinceID, limit int64) ([]object.LoginActivity, error) {
+	return m.ListByAccountIDFunc(ctx, accountID, maxID, sinceID, limit)
+}
@@ -0,0 +1,52 @@
+package mock
+
+import (
+	"context"
+	"yatter-backend-go/app/domain/object"
+)
+
+// ReportMock is a mock implementation of Report
+type ReportMock struct {
+	CreateFunc   func(ctx context.Context, report *object.Report, statusIDs []int64) (int64, error)
+	FindByIDFunc func(ctx context.Context, id int64) (*object.Report, error)
+	ListFunc     func(ctx context.Context, filter object.ReportFilter, maxID, sinceID, limit int64) ([]object.Report, error)
+	AssignFunc   func(ctx context.Context, id, actorID int64, assigneeID *int64) error
+	ResolveFunc  func(ctx context.Context, id, actorID int64) error
+	ReopenFunc   func(ctx context.Context, id, actorID int64) error
+	AddNoteFunc  func(ctx context.Context, id, actorID int64, content string) error
+}
+
+// Create is a mock implementation of Report.Create
+func (m *ReportMock) Create(ctx context.Context, report *object.Report, statusIDs []int64) (int64, error) {
+	return m.CreateFunc(ctx, report, statusIDs)
+}
+
+// FindByID is a mock implementation of Report.FindByID
+func (m *ReportMock) FindByID(ctx context.Context, id int64) (*object.Report, error) {
+	return m.FindByIDFunc(ctx, id)
+}
+
+// List is a mock implementation of Report.List
+func (m *ReportMock) List(ctx context.Context, filter object.ReportFilter, maxID, sinceID, limit int64) ([]object.Report, error) {
+	return m.ListFunc(ctx, filter, maxID, sinceID, limit)
+}
+
+// Assign is a mock implementation of Report.Assign
+func (m *ReportMock) Assign(ctx context.Context, id, actorID int64, assigneeID *int64) error {
+	return m.AssignFunc(ctx, id, actorID, assigneeID)
+}
+
+// Resolve is a mock implementation of Report.Resolve
+func (m *ReportMock) Resolve(ctx context.Context, id, actorID int64) error {
+	return m.ResolveFunc(ctx, id, actorID)
+}
+
+// Reopen is a mock implementation of Report.Reopen
+func (m *ReportMock) Reopen(ctx context.Context, id, actorID int64) error {
+	return m.ReopenFunc(ctx, id, actorID)
+}
+
+// AddNote is a mock implementation of Report.AddNote
+func (m *ReportMock) AddNote(ctx context.Context, id, actorID int64, content string) error {
+	return m.AddNoteFunc(ctx, id, actorID, content)
+}
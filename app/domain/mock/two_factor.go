@@ -0,0 +1,46 @@
+package mock
+
+import (
+	"context"
+	"yatter-backend-go/app/domain/object"
+)
+
+// TwoFactorMock is a mock implementation of TwoFactor
+type TwoFactorMock struct {
+	FindByAccountIDFunc func(ctx context.Context, accountID int64) (*object.TwoFactor, error)
+	SetupFunc           func(ctx context.Context, accountID int64, secret string) error
+	EnableFunc          func(ctx context.Context, accountID, counter int64, recoveryCodeHashes []string) error
+	DisableFunc         func(ctx context.Context, accountID int64) error
+	UseCounterFunc      func(ctx context.Context, accountID, counter int64) (bool, error)
+	UseRecoveryCodeFunc func(ctx context.Context, accountID int64, codeHash string) (bool, error)
+}
+
+// FindByAccountID is a mock implementation of TwoFactor.FindByAccountID
+func (m *TwoFactorMock) FindByAccountID(ctx context.Context, accountID int64) (*object.TwoFactor, error) {
+	return m.FindByAccountIDFunc(ctx, accountID)
+}
+
+// Setup is a mock implementation of TwoFactor.Setup
+func (m *TwoFactorMock) Setup(ctx context.Context, accountID int64, secret string) error {
+	return m.SetupFunc(ctx, accountID, secret)
+}
+
+// Enable is a mock implementation of TwoFactor.Enable
+func (m *TwoFactorMock) Enable(ctx context.Context, accountID, counter int64, recoveryCodeHashes []string) error {
+	return m.EnableFunc(ctx, accountID, counter, recoveryCodeHashes)
+}
+
+// Disable is a mock implementation of TwoFactor.Disable
+func (m *TwoFactorMock) Disable(ctx context.Context, accountID int64) error {
+	return m.DisableFunc(ctx, accountID)
+}
+
+// UseCounter is a mock implementation of TwoFactor.UseCounter
+func (m *TwoFactorMock) UseCounter(ctx context.Context, accountID, counter int64) (bool, error) {
+	return m.UseCounterFunc(ctx, accountID, counter)
+}
+
+// UseRecoveryCode is a mock implementation of TwoFactor.UseRecoveryCode
+func (m *TwoFactorMock) UseRecoveryCode(ctx context.Context, accountID int64, codeHash string) (bool, error) {
+	return m.UseRecoveryCodeFunc(ctx, accountID, codeHash)
+}
@@ -7,6 +7,24 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	// Regular account
+	RoleUser = "user"
+
+	// Account which can moderate accounts and statuses
+	RoleModerator = "moderator"
+
+	// Account which can moderate and change roles
+	RoleAdmin = "admin"
+)
+
+// Rank of roles, a role has privileges of lower ones
+var roleRanks = map[string]int{
+	RoleUser:      0,
+	RoleModerator: 1,
+	RoleAdmin:     2,
+}
+
 type (
 	AccountID    = int64
 	PasswordHash = string
@@ -37,14 +55,80 @@ type (
 		// URL to the header image
 		Header *string `json:"header,omitempty"`
 
+		// Attachment of the avatar, released when the avatar is replaced
+		AvatarAttachmentID *int64 `json:"-" db:"avatar_attachment_id"`
+
+		// Attachment of the header, released when the header is replaced
+		HeaderAttachmentID *int64 `json:"-" db:"header_attachment_id"`
+
 		// Biography of user
 		Note *string `json:"note,omitempty"`
 
+		// Metadata shown on the profile, at most MaxProfileFields
+		Fields ProfileFields `json:"fields"`
+
+		// Whether the account is operated by a program
+		Bot bool `json:"bot"`
+
+		// Whether the account opts in to be listed in directories
+		Discoverable bool `json:"discoverable"`
+
+		// Default visibility of new statuses, one of: "public", "unlisted", "private"
+		DefaultPrivacy string `json:"-" db:"default_privacy"`
+
+		// Whether media of new statuses is marked as sensitive
+		DefaultSensitive bool `json:"-" db:"default_sensitive"`
+
+		// Default language of new statuses
+		DefaultLanguage *string `json:"-" db:"default_language"`
+
 		// The time the account was created
 		CreateAt DateTime `json:"create_at,omitempty" db:"create_at"`
+
+		// Email address of the account, not exposed
+		Email *string `json:"-"`
+
+		// The time the email address was verified
+		EmailVerifiedAt *DateTime `json:"-" db:"email_verified_at"`
+
+		// The time the account was deleted, the row is kept to reserve the username
+		DeletedAt *DateTime `json:"-" db:"deleted_at"`
+
+		// One of: "user", "moderator", "admin"
+		Role string `json:"-"`
+
+		// The time the account was suspended, suspended accounts cannot log in
+		SuspendedAt *DateTime `json:"-" db:"suspended_at"`
+
+		// The time the account was silenced, statuses of silenced accounts are hidden from the public timeline
+		SilencedAt *DateTime `json:"-" db:"silenced_at"`
+
+		// The time media of the account was forced to be sensitive
+		SensitizedAt *DateTime `json:"-" db:"sensitized_at"`
+
+		// Whether the account waits for approval of an admin, pending accounts cannot log in
+		Pending bool `json:"-"`
+
+		// Why the user wants to join, given in approval mode
+		RegistrationReason *string `json:"-" db:"registration_reason"`
+
+		// The invite used to register
+		InviteID *int64 `json:"-" db:"invite_id"`
 	}
 )
 
+// Check if the account has role or a higher one
+func (a *Account) HasRole(role string) bool {
+	rank, ok := roleRanks[role]
+	return ok && roleRanks[a.Role] >= rank
+}
+
+// Check if role is one of the known roles
+func ValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
 // Check if given password is match to account's password
 func (a *Account) CheckPassword(pass string) bool {
 	return bcrypt.CompareHashAndPassword([]byte(a.PasswordHash), []byte(pass)) == nil
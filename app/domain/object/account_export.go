@@ -0,0 +1,33 @@
+package object
+
+const (
+	// The archive is not built yet
+	ExportPending = "pending"
+
+	// The archive is ready to download
+	ExportReady = "ready"
+
+	// The archive could not be built
+	ExportFailed = "failed"
+)
+
+// AccountExport archive of account data requested by the account
+type AccountExport struct {
+	// The ID of the export
+	ID int64 `json:"id"`
+
+	// The internal ID of the account
+	AccountID int64 `json:"-" db:"account_id"`
+
+	// One of: "pending", "ready", "failed"
+	State string `json:"state"`
+
+	// The path of the archive, set when ready
+	FilePath *string `json:"-" db:"file_path"`
+
+	// The time the export was requested
+	CreateAt DateTime `json:"create_at" db:"create_at"`
+
+	// The time the archive was built or given up
+	CompletedAt *DateTime `json:"completed_at,omitempty" db:"completed_at"`
+}
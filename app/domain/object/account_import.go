@@ -0,0 +1,70 @@
+package object
+
+const (
+	// Follow the listed accounts
+	ImportFollowing = "following"
+
+	// Block the listed accounts
+	ImportBlocking = "blocking"
+
+	// Mute the listed accounts
+	ImportMuting = "muting"
+)
+
+const (
+	// The rows are being applied
+	ImportPending = "pending"
+
+	// All rows were applied, some of them may have failed
+	ImportDone = "done"
+
+	// The import was given up
+	ImportFailed = "failed"
+)
+
+type (
+	// AccountImport list of accounts imported from a CSV file
+	AccountImport struct {
+		// The ID of the import
+		ID int64 `json:"id"`
+
+		// The internal ID of the importing account
+		AccountID int64 `json:"-" db:"account_id"`
+
+		// One of: "following", "blocking", "muting"
+		Type string `json:"type"`
+
+		// One of: "pending", "done", "failed"
+		State string `json:"state"`
+
+		// JSON encoded account addresses of the rows
+		Data string `json:"-"`
+
+		// Number of rows in the file
+		TotalRows int `json:"total_rows" db:"total_rows"`
+
+		// Number of rows applied or failed
+		ProcessedRows int `json:"processed_rows" db:"processed_rows"`
+
+		// Rows which could not be applied
+		Failures []ImportFailure `json:"failures" db:"-"`
+
+		// The time the import was requested
+		CreateAt DateTime `json:"create_at" db:"create_at"`
+
+		// The time all rows were processed or the import was given up
+		CompletedAt *DateTime `json:"completed_at,omitempty" db:"completed_at"`
+	}
+
+	// ImportFailure row of an import which could not be applied
+	ImportFailure struct {
+		// 1-based index of the row, the header is not counted
+		Row int `json:"row" db:"row_index"`
+
+		// The account address of the row
+		Acct string `json:"acct"`
+
+		// Why the row was not applied
+		Reason string `json:"reason"`
+	}
+)
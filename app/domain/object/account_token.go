@@ -0,0 +1,12 @@
+package object
+
+const (
+	// Token sent to verify the email address
+	TokenEmailVerification = "email_verification"
+
+	// Token sent to reset the password
+	TokenPasswordReset = "password_reset"
+
+	// Bearer token issued for API access, reused until it expires
+	TokenAccess = "access"
+)
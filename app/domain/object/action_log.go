@@ -0,0 +1,94 @@
+package object
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+const (
+	// The target of the action is an account
+	TargetAccount = "account"
+
+	// The target of the action is a status
+	TargetStatus = "status"
+
+	// The target of the action is a report
+	TargetReport = "report"
+)
+
+type (
+	// ActionLog record of an action by a moderator or an admin
+	ActionLog struct {
+		ID int64 `json:"id"`
+
+		// The internal ID of the moderator who took the action
+		AccountID int64 `json:"account_id" db:"account_id"`
+
+		// e.g. "suspend", "delete_status", "resolve_report", "change_role"
+		Action string `json:"action"`
+
+		// One of: "account", "status", "report"
+		TargetType string `json:"target_type" db:"target_type"`
+
+		// The internal ID of the target
+		TargetID int64 `json:"target_id" db:"target_id"`
+
+		// The target before the action, null if it did not exist
+		Before RawJSON `json:"before" db:"before_state"`
+
+		// The target after the action, null if it was deleted
+		After RawJSON `json:"after" db:"after_state"`
+
+		// The time the action was taken
+		CreateAt DateTime `json:"create_at" db:"create_at"`
+	}
+
+	// ActionLogFilter conditions to list action logs, zero values match all
+	ActionLogFilter struct {
+		// The internal ID of the moderator
+		AccountID int64
+
+		Action string
+
+		// One of: "account", "status", "report"
+		TargetType string
+
+		// Applied with TargetType
+		TargetID int64
+	}
+
+	// RawJSON JSON stored as it is
+	RawJSON []byte
+)
+
+// encoding/json/Marshaler
+func (j RawJSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+// database/sql/driver/Valuer
+func (j RawJSON) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+// database/sql/Scanner
+func (j *RawJSON) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		// the driver reuses the buffer
+		*j = append(RawJSON(nil), v...)
+	case string:
+		*j = RawJSON(v)
+	default:
+		return fmt.Errorf("can't scan %T into RawJSON", value)
+	}
+	return nil
+}
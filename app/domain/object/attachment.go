@@ -1,19 +1,117 @@
 package object
 
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// The attachment is stored but not processed yet
+	AttachmentProcessing = "processing"
+
+	// The attachment is ready to be attached to statuses
+	AttachmentReady = "ready"
+
+	// The attachment could not be processed
+	AttachmentFailed = "failed"
+)
+
 type (
 	// Attachment attachment
 	Attachment struct {
 		// The internal ID of attachment
 		ID int64 `json:"id"`
 
+		// The ID of the account which uploaded attachment
+		AccountID int64 `json:"-" db:"account_id"`
+
 		// The type of attachment
-		// One of: "image", "video", "gifv", "unknown"
+		// One of: "image", "video", "gifv", "audio", "unknown"
 		Type string `json:"type"`
 
-		// The URL of image
-		URL string `json:"url"`
+		// The URL of image, null while the attachment is processing
+		URL *string `json:"url"`
+
+		// The URL of the scaled-down preview
+		PreviewURL *string `json:"preview_url,omitempty" db:"preview_url"`
 
 		// The description of the image
 		Description string `json:"description"`
+
+		// The metadata of the media, available after processing
+		Meta *AttachmentMeta `json:"meta,omitempty"`
+
+		// The path of the stored file
+		FilePath string `json:"-" db:"file_path"`
+
+		// The SHA-256 of the stored file, shared by identical uploads
+		BlobHash *string `json:"-" db:"blob_hash"`
+
+		// The processing state of attachment
+		// One of: "processing", "ready", "failed"
+		State string `json:"-"`
+	}
+
+	// AttachmentMeta metadata of the original media and its preview
+	AttachmentMeta struct {
+		Original *MediaInfo `json:"original,omitempty"`
+		Small    *MediaInfo `json:"small,omitempty"`
+	}
+
+	// MediaInfo dimensions and stream information of a media
+	MediaInfo struct {
+		Width  int     `json:"width,omitempty"`
+		Height int     `json:"height,omitempty"`
+		Size   string  `json:"size,omitempty"`
+		Aspect float64 `json:"aspect,omitempty"`
+
+		// Duration in seconds
+		Duration float64 `json:"duration,omitempty"`
+
+		// Frames per second as a fraction like "30/1"
+		FrameRate string `json:"frame_rate,omitempty"`
+
+		// Average bitrate in bits per second
+		Bitrate int64 `json:"bitrate,omitempty"`
 	}
 )
+
+// Ready reports whether the attachment finished processing
+func (a *Attachment) Ready() bool {
+	return a.State == "" || a.State == AttachmentReady
+}
+
+// NewMediaInfo build MediaInfo from width and height
+func NewMediaInfo(width, height int) *MediaInfo {
+	info := &MediaInfo{
+		Width:  width,
+		Height: height,
+		Size:   fmt.Sprintf("%dx%d", width, height),
+	}
+	if height > 0 {
+		info.Aspect = float64(width) / float64(height)
+	}
+	return info
+}
+
+// database/sql/driver/Valuer
+func (m AttachmentMeta) Value() (driver.Value, error) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// database/sql/Scanner
+func (m *AttachmentMeta) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("can't scan %T into AttachmentMeta", value)
+	}
+}
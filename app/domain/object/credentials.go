@@ -0,0 +1,82 @@
+package object
+
+const (
+	// Visible on public timelines
+	PrivacyPublic = "public"
+
+	// Visible to everyone, but not on public timelines
+	PrivacyUnlisted = "unlisted"
+
+	// Visible to followers only
+	PrivacyPrivate = "private"
+)
+
+type (
+	// Source private settings of an account, shown only to the account itself
+	Source struct {
+		// Default visibility of new statuses
+		Privacy string `json:"privacy"`
+
+		// Whether media of new statuses is marked as sensitive by default
+		Sensitive bool `json:"sensitive"`
+
+		// Default language of new statuses, ISO 639-1 code
+		Language *string `json:"language"`
+
+		Note string `json:"note"`
+
+		Fields ProfileFields `json:"fields"`
+	}
+
+	// CredentialsUpdate changes of credentials of an account
+	// Nil fields are kept, and empty strings clear nullable columns.
+	CredentialsUpdate struct {
+		DisplayName *string
+		Note        *string
+
+		// URL of the uploaded avatar
+		Avatar *string
+
+		// Attachment of the uploaded avatar, zero when it is cleared
+		AvatarAttachmentID int64
+
+		// URL of the uploaded header
+		Header *string
+
+		// Attachment of the uploaded header, zero when it is cleared
+		HeaderAttachmentID int64
+
+		// Replace all fields, verification of new links is enqueued
+		Fields *ProfileFields
+
+		Bot          *bool
+		Discoverable *bool
+
+		Privacy   *string
+		Sensitive *bool
+		Language  *string
+	}
+)
+
+// Source returns private settings of the account
+func (a *Account) Source() *Source {
+	source := &Source{
+		Privacy:   a.DefaultPrivacy,
+		Sensitive: a.DefaultSensitive,
+		Language:  a.DefaultLanguage,
+		Fields:    a.Fields,
+	}
+	if a.Note != nil {
+		source.Note = *a.Note
+	}
+	return source
+}
+
+// ValidPrivacy reports whether privacy can be the default visibility
+func ValidPrivacy(privacy string) bool {
+	switch privacy {
+	case PrivacyPublic, PrivacyUnlisted, PrivacyPrivate:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package object
+
+// Invite invite to register an account
+type Invite struct {
+	// The internal ID of the invite
+	ID int64 `json:"id"`
+
+	// The internal ID of the account who created the invite
+	AccountID int64 `json:"-" db:"account_id"`
+
+	// The code to pass as invite_code of `POST /v1/accounts`
+	Code string `json:"code"`
+
+	// How many times the invite can be used, null is unlimited
+	MaxUses *int `json:"max_uses" db:"max_uses"`
+
+	// How many times the invite was used
+	Uses int `json:"uses"`
+
+	// The time the invite expires, null never expires
+	ExpiresAt *DateTime `json:"expires_at" db:"expires_at"`
+
+	// The time the invite was created
+	CreateAt DateTime `json:"create_at" db:"create_at"`
+}
@@ -0,0 +1,65 @@
+package object
+
+const (
+	// Process an uploaded attachment: probe, thumbnail and transcode
+	JobProcessAttachment = "process_attachment"
+
+	// Purge data of a deleted account
+	JobPurgeAccount = "purge_account"
+
+	// Build the archive of an account export
+	JobExportAccount = "export_account"
+
+	// Apply rows of an account import
+	JobImportAccount = "import_account"
+
+	// Verify links of profile fields
+	JobVerifyProfile = "verify_profile"
+)
+
+const (
+	// The job waits for its run_at
+	JobPending = "pending"
+
+	// The job is claimed by a worker
+	JobRunning = "running"
+
+	// The job finished successfully
+	JobDone = "done"
+
+	// The job exhausted its attempts
+	JobFailed = "failed"
+)
+
+// Job background job stored in the durable queue
+type Job struct {
+	// The internal ID of the job
+	ID int64
+
+	// The kind of job, which selects the handler
+	Kind string
+
+	// JSON encoded arguments of the job
+	Payload string
+
+	// One of: "pending", "running", "done", "failed"
+	State string
+
+	// Number of times the job was claimed
+	Attempts int
+
+	// Number of attempts before the job is given up
+	MaxAttempts int `db:"max_attempts"`
+
+	// The error of the last failed attempt
+	LastError *string `db:"last_error"`
+
+	// The time the job becomes runnable
+	RunAt DateTime `db:"run_at"`
+
+	// The time the job was claimed by a worker
+	LockedAt *DateTime `db:"locked_at"`
+
+	// The time the job was enqueued
+	CreateAt DateTime `db:"create_at"`
+}
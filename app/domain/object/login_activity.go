@@ -0,0 +1,39 @@
+package object
+
+const (
+	// The password did not match
+	LoginInvalidPassword = "invalid_password"
+
+	// The one-time password of two-factor authentication did not match
+	LoginInvalidOTP = "invalid_otp"
+
+	// The credentials matched but the account is suspended
+	LoginSuspended = "suspended"
+
+	// The credentials matched but the account waits for approval
+	LoginPending = "pending"
+)
+
+// LoginActivity attempt to log in to an account
+type LoginActivity struct {
+	// The internal ID of the login activity
+	ID int64 `json:"id"`
+
+	// The account which was attempted to log in
+	AccountID AccountID `json:"-" db:"account_id"`
+
+	// Whether the attempt succeeded
+	Success bool `json:"success"`
+
+	// Why the attempt failed, e.g. "invalid_password"
+	FailureReason *string `json:"failure_reason,omitempty" db:"failure_reason"`
+
+	// The address of the client
+	IP string `json:"ip"`
+
+	// The User-Agent header of the client
+	UserAgent string `json:"user_agent" db:"user_agent"`
+
+	// The time of the attempt
+	CreateAt DateTime `json:"create_at" db:"create_at"`
+}
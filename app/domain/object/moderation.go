@@ -0,0 +1,80 @@
+package object
+
+const (
+	// Reject logins of the account and hide its statuses from the public timeline
+	ActionSuspend = "suspend"
+
+	// Lift the suspension
+	ActionUnsuspend = "unsuspend"
+
+	// Hide statuses of the account from the public timeline
+	ActionSilence = "silence"
+
+	// Lift the silence
+	ActionUnsilence = "unsilence"
+
+	// Mark media of the account as sensitive, including statuses posted later
+	ActionSensitive = "sensitive"
+
+	// Approve the pending account
+	ActionApprove = "approve"
+
+	// Reject the pending account, which is deleted
+	ActionReject = "reject"
+
+	// Delete a status
+	ActionDeleteStatus = "delete_status"
+
+	// Change the role of the account
+	ActionChangeRole = "change_role"
+
+	// Assign a report to a moderator
+	ActionAssignReport = "assign_report"
+
+	// Remove the assignee of a report
+	ActionUnassignReport = "unassign_report"
+
+	// Resolve a report
+	ActionResolveReport = "resolve_report"
+
+	// Open a resolved report again
+	ActionReopenReport = "reopen_report"
+
+	// Add a note to a report
+	ActionNoteReport = "note_report"
+)
+
+const (
+	// Accounts which are not suspended, silenced nor pending
+	AccountActive = "active"
+
+	// Suspended accounts
+	AccountSuspended = "suspended"
+
+	// Silenced accounts
+	AccountSilenced = "silenced"
+
+	// Accounts whose media is forced to be sensitive
+	AccountSensitized = "sensitized"
+
+	// Deleted accounts
+	AccountDeleted = "deleted"
+
+	// Accounts waiting for approval
+	AccountPending = "pending"
+)
+
+// AccountFilter conditions to list accounts for moderation, empty fields match any account
+type AccountFilter struct {
+	// Part of the username
+	Username string
+
+	// The exact email address
+	Email string
+
+	// One of: "user", "moderator", "admin"
+	Role string
+
+	// One of: "active", "suspended", "silenced", "sensitized", "deleted", "pending"
+	Status string
+}
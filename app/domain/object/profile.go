@@ -0,0 +1,76 @@
+package object
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// Number of fields an account can show on its profile
+	MaxProfileFields = 4
+
+	// Maximum length of name and value of a field
+	MaxProfileFieldLength = 255
+)
+
+type (
+	// ProfileField name and value pair shown on the profile
+	ProfileField struct {
+		Name string `json:"name"`
+
+		Value string `json:"value"`
+
+		// The time the page of value was found linking back to the profile with rel="me"
+		VerifiedAt *DateTime `json:"verified_at"`
+	}
+
+	// ProfileFields fields stored as JSON
+	ProfileFields []ProfileField
+)
+
+// IsLink reports whether value of the field can be verified
+func (f *ProfileField) IsLink() bool {
+	return strings.HasPrefix(f.Value, "https://") || strings.HasPrefix(f.Value, "http://")
+}
+
+// ProfileURL returns URL of the profile of the account under base, the public URL of the server
+func (a *Account) ProfileURL(base string) string {
+	return strings.TrimSuffix(base, "/") + "/@" + a.Username
+}
+
+// encoding/json/Marshaler, no fields are encoded as an empty array
+func (f ProfileFields) MarshalJSON() ([]byte, error) {
+	if f == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]ProfileField(f))
+}
+
+// database/sql/driver/Valuer
+func (f ProfileFields) Value() (driver.Value, error) {
+	if len(f) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal([]ProfileField(f))
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// database/sql/Scanner
+func (f *ProfileFields) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*f = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, (*[]ProfileField)(f))
+	case string:
+		return json.Unmarshal([]byte(v), (*[]ProfileField)(f))
+	default:
+		return fmt.Errorf("can't scan %T into ProfileFields", value)
+	}
+}
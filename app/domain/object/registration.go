@@ -0,0 +1,19 @@
+package object
+
+// Registration request of a new account
+type Registration struct {
+	Username     string
+	PasswordHash string
+
+	// Optional email address
+	Email string
+
+	// Optional, why the user wants to join
+	Reason string
+
+	// Whether the account waits for approval
+	Pending bool
+
+	// Optional, the code of invite to use
+	InviteCode string
+}
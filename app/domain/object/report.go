@@ -0,0 +1,171 @@
+package object
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// The account posts spam
+	ReportSpam = "spam"
+
+	// The account violates the rules
+	ReportViolation = "violation"
+
+	// Other reasons
+	ReportOther = "other"
+)
+
+const (
+	// The report waits for moderation
+	ReportOpen = "open"
+
+	// The report was handled by a moderator
+	ReportResolved = "resolved"
+)
+
+const (
+	// The report was assigned to a moderator
+	ReportActionAssign = "assign"
+
+	// The assignee of the report was removed
+	ReportActionUnassign = "unassign"
+
+	// The report was resolved
+	ReportActionResolve = "resolve"
+
+	// The resolved report was opened again
+	ReportActionReopen = "reopen"
+
+	// A note was added to the report
+	ReportActionNote = "note"
+)
+
+type (
+	// Report report of an account by another account
+	Report struct {
+		// The internal ID of the report
+		ID int64 `json:"id"`
+
+		// The internal ID of the reporting account
+		AccountID int64 `json:"account_id" db:"account_id"`
+
+		// The internal ID of the reported account
+		TargetAccountID int64 `json:"target_account_id" db:"target_account_id"`
+
+		// One of: "spam", "violation", "other"
+		Category string `json:"category"`
+
+		// The reason of the report
+		Comment string `json:"comment"`
+
+		// One of: "open", "resolved"
+		State string `json:"state"`
+
+		// The internal ID of the moderator handling the report
+		AssignedAccountID *int64 `json:"assigned_account_id" db:"assigned_account_id"`
+
+		// The time the report was created
+		CreateAt DateTime `json:"create_at" db:"create_at"`
+
+		// The time the report was resolved, null while it is open
+		ResolvedAt *DateTime `json:"resolved_at" db:"resolved_at"`
+
+		// Snapshots of the reported statuses, only in details
+		Statuses []ReportStatus `json:"statuses,omitempty" db:"-"`
+
+		// Notes by moderators, only for staff
+		Notes []ReportNote `json:"notes,omitempty" db:"-"`
+
+		// Actions taken on the report, only for staff
+		History []ReportHistory `json:"history,omitempty" db:"-"`
+	}
+
+	// ReportStatus snapshot of a status at the time of the report
+	// It stays after the status is deleted.
+	ReportStatus struct {
+		// The internal ID of the status, which may not exist anymore
+		StatusID int64 `json:"status_id" db:"status_id"`
+
+		// The contents of status
+		Content string `json:"content"`
+
+		// Whether the media of status was marked as sensitive
+		Sensitive bool `json:"sensitive"`
+
+		// The attachments of status
+		MediaAttachments Attachments `json:"media_attachments" db:"media_attachments"`
+
+		// The time the status was created
+		CreateAt DateTime `json:"create_at" db:"create_at"`
+	}
+
+	// ReportNote note of a moderator on a report
+	ReportNote struct {
+		ID int64 `json:"id"`
+
+		// The internal ID of the moderator
+		AccountID int64 `json:"account_id" db:"account_id"`
+
+		Content string `json:"content"`
+
+		CreateAt DateTime `json:"create_at" db:"create_at"`
+	}
+
+	// ReportHistory action taken on a report
+	ReportHistory struct {
+		ID int64 `json:"id"`
+
+		// The internal ID of the moderator who took the action
+		AccountID int64 `json:"account_id" db:"account_id"`
+
+		// One of: "assign", "unassign", "resolve", "reopen", "note"
+		Action string `json:"action"`
+
+		// The new assignee of "assign" action
+		AssignedAccountID *int64 `json:"assigned_account_id,omitempty" db:"assigned_account_id"`
+
+		CreateAt DateTime `json:"create_at" db:"create_at"`
+	}
+
+	// ReportFilter conditions to list reports, zero values match all
+	ReportFilter struct {
+		State             string
+		AssignedAccountID int64
+		TargetAccountID   int64
+	}
+
+	// Attachments attachments stored as JSON
+	Attachments []Attachment
+)
+
+// ValidReportCategory reports whether category is known
+func ValidReportCategory(category string) bool {
+	switch category {
+	case ReportSpam, ReportViolation, ReportOther:
+		return true
+	}
+	return false
+}
+
+// database/sql/driver/Valuer
+func (a Attachments) Value() (driver.Value, error) {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// database/sql/Scanner
+func (a *Attachments) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return fmt.Errorf("can't scan %T into Attachments", value)
+	}
+}
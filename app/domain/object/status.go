@@ -14,6 +14,9 @@ type Status struct {
 	// The contents of status
 	Content string `json:"content,omitempty"`
 
+	// Whether the media of status is marked as sensitive
+	Sensitive bool `json:"sensitive"`
+
 	// The time the status was created
 	CreateAt DateTime `json:"create_at,omitempty" db:"create_at"`
 
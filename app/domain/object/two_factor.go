@@ -0,0 +1,19 @@
+package object
+
+// TwoFactor two-factor authentication setting of an account
+type TwoFactor struct {
+	// The account which the setting belongs to
+	AccountID AccountID `db:"account_id"`
+
+	// Base32 encoded TOTP secret
+	Secret string
+
+	// Whether the enrollment was confirmed, unconfirmed secrets are not required on login
+	Enabled bool
+
+	// The last accepted time step, codes up to it are rejected
+	LastCounter int64 `db:"last_counter"`
+
+	// The time the secret was generated
+	CreateAt DateTime `db:"create_at"`
+}
@@ -13,8 +13,11 @@ type Account interface {
 	// Fetch account which has specified ID
 	FindByID(ctx context.Context, id int64) (*object.Account, error)
 
-	// Create an account which has specified username and password
-	CreateAccount(ctx context.Context, username, password string) (int64, error)
+	// Fetch account which has specified verified email address
+	FindByEmail(ctx context.Context, email string) (*object.Account, error)
+
+	// Create an account, the invite of registration is used in the same transaction
+	CreateAccount(ctx context.Context, registration *object.Registration) (int64, error)
 
 	// Follow an account
 	Follow(ctx context.Context, followerID, followeeID int64) (int64, bool, error)
@@ -25,12 +28,38 @@ type Account interface {
 	// Account relationship about follow
 	FindRelationship(ctx context.Context, userID, targetID int64) (bool, bool, error)
 
+	// Block an account, follows between them are removed and ForbiddenError is returned on following again
+	Block(ctx context.Context, accountID, targetID int64) error
+
+	// Mute an account
+	Mute(ctx context.Context, accountID, targetID int64) error
+
+	// Account relationship about block and mute, whether account blocks and mutes target
+	FindBlockAndMute(ctx context.Context, accountID, targetID int64) (bool, bool, error)
+
 	// Fetch accounts that followed by follower
 	FindFollowing(ctx context.Context, followerID, limit int64) ([]object.Account, error)
 
 	// Fetch accounts that following followee
 	FindFollowers(ctx context.Context, followeeID, maxID, sinceID, limit int64) ([]object.Account, error)
 
-	// Update credentials
-	UpdateCredentials(ctx context.Context, id int64, displayName, note, avatar, header string) error
+	// Update credentials, nil fields of update are kept and empty strings clear them
+	// Verification of links in new fields is enqueued in the same transaction,
+	// where the attachments of replaced or cleared avatar and header are deleted.
+	UpdateCredentials(ctx context.Context, id int64, update *object.CredentialsUpdate) error
+
+	// Mark fields whose value is one of values as verified
+	VerifyFields(ctx context.Context, id int64, values []string) error
+
+	// Replace password hash, and revoke reset tokens and access tokens other than keepTokenID in the same transaction
+	UpdatePassword(ctx context.Context, id int64, passwordHash string, keepTokenID int64) error
+
+	// Mark email address as verified
+	VerifyEmail(ctx context.Context, id int64) error
+
+	// Leave a tombstone of account and enqueue the job which purges its data
+	Delete(ctx context.Context, id int64) error
+
+	// Purge statuses, attachments, follows and tokens of a deleted account
+	Purge(ctx context.Context, id int64) error
 }
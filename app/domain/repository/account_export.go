@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"yatter-backend-go/app/domain/object"
+)
+
+type AccountExport interface {
+	// Create a pending export of account and enqueue the job which builds it
+	Create(ctx context.Context, accountID int64) (*object.AccountExport, error)
+
+	// Fetch export which has specified ID
+	FindByID(ctx context.Context, id int64) (*object.AccountExport, error)
+
+	// Mark export as ready with the path of the archive
+	Complete(ctx context.Context, id int64, path string) error
+
+	// Mark export as failed
+	Fail(ctx context.Context, id int64) error
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"yatter-backend-go/app/domain/object"
+)
+
+type AccountImport interface {
+	// Create a pending import of account and enqueue the job which applies it
+	Create(ctx context.Context, accountID int64, importType, data string, totalRows int) (*object.AccountImport, error)
+
+	// Fetch import which has specified ID with its failures
+	FindByID(ctx context.Context, id int64) (*object.AccountImport, error)
+
+	// Record number of processed rows and append failures of them
+	Progress(ctx context.Context, id int64, processedRows int, failures []object.ImportFailure) error
+
+	// Mark import as done or failed
+	Finish(ctx context.Context, id int64, state string) error
+}
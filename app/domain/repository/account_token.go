@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+type AccountToken interface {
+	// Issue a token of purpose valid until expiresAt, only its hash is stored
+	Create(ctx context.Context, accountID int64, purpose, tokenHash string, expiresAt time.Time) error
+
+	// Consume an unexpired token of purpose and return its account, NotFoundError if it is invalid
+	Use(ctx context.Context, purpose, tokenHash string) (int64, error)
+
+	// Find an unexpired token of purpose without consuming it and return its ID and account, NotFoundError if it is invalid
+	Find(ctx context.Context, purpose, tokenHash string) (int64, int64, error)
+
+	// Revoke unused tokens of purpose of account
+	Revoke(ctx context.Context, accountID int64, purpose string) error
+}
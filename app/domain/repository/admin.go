@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"yatter-backend-go/app/domain/object"
+)
+
+// Actions of Admin and Report are recorded in the action log with actorID
+type Admin interface {
+	// Fetch accounts matching filter, including deleted ones, newest first
+	ListAccounts(ctx context.Context, filter object.AccountFilter, maxID, sinceID, limit int64) ([]object.Account, error)
+
+	// Fetch account which has specified ID, including deleted one
+	FindAccount(ctx context.Context, id int64) (*object.Account, error)
+
+	// Apply a moderation action (e.g. object.ActionSuspend) to account by actorID
+	ModerateAccount(ctx context.Context, id, actorID int64, action string) error
+
+	// Change role of account by actorID
+	ChangeRole(ctx context.Context, id, actorID int64, role string) error
+
+	// Delete status which has specified ID by actorID
+	DeleteStatus(ctx context.Context, id, actorID int64) error
+
+	// Fetch action logs matching filter, newest first
+	ListActionLogs(ctx context.Context, filter object.ActionLogFilter, maxID, sinceID, limit int64) ([]object.ActionLog, error)
+}
@@ -2,11 +2,27 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"io"
 	"yatter-backend-go/app/domain/object"
 )
 
+// ErrAttachmentProcessing is returned when attachments are not processed yet
+var ErrAttachmentProcessing = errors.New("cannot attach files that have not finished processing")
+
 type Attachment interface {
-	// Upload file
-	UploadFile(ctx context.Context, file io.Reader, fileDir, filename, filetype, description string) (*object.Attachment, error)
+	// Upload file of the account, its URL is under baseURL
+	UploadFile(ctx context.Context, accountID int64, file io.Reader, fileDir, baseURL, ext, filetype, description string, meta *object.AttachmentMeta) (*object.Attachment, error)
+
+	// Store file of the account and enqueue the job to process it
+	StoreFile(ctx context.Context, accountID int64, file io.Reader, fileDir, ext, filetype, description string) (*object.Attachment, error)
+
+	// Fetch attachment which has specified ID
+	FindByID(ctx context.Context, id int64) (*object.Attachment, error)
+
+	// Save the result of processing
+	UpdateProcessed(ctx context.Context, attachment *object.Attachment) error
+
+	// Delete attachment, the stored file is removed with the last reference
+	Delete(ctx context.Context, id int64) error
 }
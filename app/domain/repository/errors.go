@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NotFoundError is returned when the resource does not exist
+type NotFoundError struct {
+	Resource string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Resource + " not found"
+}
+
+// Create NotFoundError of resource
+func NewNotFound(resource string) error {
+	return &NotFoundError{Resource: resource}
+}
+
+// ConflictError is returned when the resource conflicts with existing one
+type ConflictError struct {
+	Resource string
+	// Field which must be unique
+	Field   string
+	Message string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
+
+// Create ConflictError of field of resource
+func NewConflict(resource, field, message string) error {
+	return &ConflictError{Resource: resource, Field: field, Message: message}
+}
+
+// ForbiddenError is returned when the operation is not permitted
+type ForbiddenError struct {
+	Message string
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.Message
+}
+
+// Create ForbiddenError
+func NewForbidden(format string, args ...interface{}) error {
+	return &ForbiddenError{Message: fmt.Sprintf(format, args...)}
+}
+
+// FieldError is a problem of a field
+type FieldError struct {
+	Field string
+	// Machine readable code, e.g. "ERR_BLANK"
+	Code    string
+	Message string
+}
+
+// ValidationError has problems of fields
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + " " + f.Message
+	}
+	return "validation failed: " + strings.Join(msgs, ", ")
+}
+
+// Add a problem of field
+func (e *ValidationError) Add(field, code, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Err returns e if it has problems, otherwise nil
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Create ValidationError of a field
+func NewValidation(field, code, message string) error {
+	e := &ValidationError{}
+	e.Add(field, code, message)
+	return e
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"yatter-backend-go/app/domain/object"
+)
+
+type Invite interface {
+	// Create an invite, ConflictError if the code is taken
+	Create(ctx context.Context, invite *object.Invite) (int64, error)
+
+	// Fetch invite which has specified ID
+	FindByID(ctx context.Context, id int64) (*object.Invite, error)
+
+	// Fetch invites created by the account, newest first
+	ListByAccountID(ctx context.Context, accountID, maxID, sinceID, limit int64) ([]object.Invite, error)
+
+	// Expire the invite now
+	Expire(ctx context.Context, id int64) error
+}
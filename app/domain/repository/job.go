@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"yatter-backend-go/app/domain/object"
+)
+
+type Job interface {
+	// Enqueue a job which runs as soon as possible
+	Enqueue(ctx context.Context, kind, payload string) (int64, error)
+
+	// Claim next runnable job, jobs whose lease expired are claimed again
+	Claim(ctx context.Context, lease time.Duration) (*object.Job, error)
+
+	// Mark job as done
+	Complete(ctx context.Context, id int64) error
+
+	// Record failure and retry after backoff unless attempts are exhausted
+	Fail(ctx context.Context, id int64, reason string, backoff time.Duration) error
+}
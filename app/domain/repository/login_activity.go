@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"yatter-backend-go/app/domain/object"
+)
+
+type LoginActivity interface {
+	// Record a login attempt
+	// Successes from the same IP and user agent are recorded once an hour, as every request is authenticated.
+	Create(ctx context.Context, activity *object.LoginActivity) error
+
+	// Fetch login activities of account, newest first
+	ListByAccountID(ctx context.Context, accountID, maxID, sinceID, limit int64) ([]object.LoginActivity, error)
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"yatter-backend-go/app/domain/object"
+)
+
+type Report interface {
+	// Create a report with snapshots of statusIDs, which must be posted by the target account
+	Create(ctx context.Context, report *object.Report, statusIDs []int64) (int64, error)
+
+	// Fetch report which has specified ID with its statuses, notes and history
+	FindByID(ctx context.Context, id int64) (*object.Report, error)
+
+	// Fetch reports matching filter, newest first
+	List(ctx context.Context, filter object.ReportFilter, maxID, sinceID, limit int64) ([]object.Report, error)
+
+	// Assign the report to assigneeID by actorID, nil removes the assignee
+	Assign(ctx context.Context, id, actorID int64, assigneeID *int64) error
+
+	// Resolve the open report by actorID
+	Resolve(ctx context.Context, id, actorID int64) error
+
+	// Open the resolved report again by actorID
+	Reopen(ctx context.Context, id, actorID int64) error
+
+	// Add a note to the report by actorID
+	AddNote(ctx context.Context, id, actorID int64, content string) error
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"yatter-backend-go/app/domain/object"
+)
+
+type TwoFactor interface {
+	// Fetch two-factor setting of account, nil if not set up
+	FindByAccountID(ctx context.Context, accountID int64) (*object.TwoFactor, error)
+
+	// Start enrollment with secret, replacing the unconfirmed one
+	Setup(ctx context.Context, accountID int64, secret string) error
+
+	// Enable two-factor with the time step of the confirmed code and hashes of recovery codes
+	Enable(ctx context.Context, accountID, counter int64, recoveryCodeHashes []string) error
+
+	// Disable two-factor and remove recovery codes
+	Disable(ctx context.Context, accountID int64) error
+
+	// Accept time step of a code, false if it is not newer than the last accepted one
+	UseCounter(ctx context.Context, accountID, counter int64) (bool, error)
+
+	// Consume a recovery code, false if it does not exist or is already used
+	UseRecoveryCode(ctx context.Context, accountID int64, codeHash string) (bool, error)
+}
@@ -0,0 +1,303 @@
+package accounts
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+	"yatter-backend-go/app/handler/auth"
+	"yatter-backend-go/app/handler/httperror"
+	"yatter-backend-go/app/handler/request"
+	"yatter-backend-go/app/mediatype"
+)
+
+const (
+	// Maximum length of display name
+	maxDisplayNameLength = 30
+
+	// Maximum length of note
+	maxNoteLength = 500
+)
+
+// ISO 639-1 code of language
+var languagePattern = regexp.MustCompile(`^[a-z]{2}$`)
+
+// CredentialAccount account with its private settings, returned only to the account itself
+type CredentialAccount struct {
+	*object.Account
+
+	Source *object.Source `json:"source"`
+}
+
+// Handle request for `POST /v1/accounts/update_credentials` with JSON
+// Request body, omitted fields are kept and empty strings clear them
+type UpdateCredentialsRequest struct {
+	DisplayName *string `json:"display_name"`
+	Note        *string `json:"note"`
+
+	// Only empty string to clear is accepted, images are uploaded as multipart
+	Avatar *string `json:"avatar"`
+	Header *string `json:"header"`
+
+	// Replace all fields when given
+	FieldsAttributes *[]FieldAttributes `json:"fields_attributes"`
+
+	Bot          *bool `json:"bot"`
+	Discoverable *bool `json:"discoverable"`
+
+	Source *SourceRequest `json:"source"`
+}
+
+// FieldAttributes profile field of UpdateCredentialsRequest
+type FieldAttributes struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// SourceRequest private settings of UpdateCredentialsRequest
+type SourceRequest struct {
+	Privacy   *string `json:"privacy"`
+	Sensitive *bool   `json:"sensitive"`
+	Language  *string `json:"language"`
+}
+
+// VerifyCredentials handles request for `GET /v1/accounts/verify_credentials`
+func (h *handler) VerifyCredentials(w http.ResponseWriter, r *http.Request) {
+	h.respondCredentials(w, r)
+}
+
+// UpdateCredentials handles request for `POST /v1/accounts/update_credentials`
+// The body is either multipart form or JSON of UpdateCredentialsRequest.
+// Omitted fields are kept and empty values clear them. In multipart form,
+// profile fields are `fields_attributes[i][name]` and `fields_attributes[i][value]`,
+// private settings are `source[privacy]`, `source[sensitive]` and `source[language]`,
+// and avatar and header are uploaded as files or cleared with empty values.
+// Replaced avatar and header are deleted, and new uploads are deleted when the update fails.
+// Fields whose name and value are both empty are dropped. Links in new fields are
+// verified in background.
+func (h *handler) UpdateCredentials(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	account := auth.AccountOf(r)
+
+	var update *object.CredentialsUpdate
+	var uploads []*object.Attachment
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		var req UpdateCredentialsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httperror.BadRequest(w, err)
+			return
+		}
+		var err error
+		if update, err = credentialsOfJSON(&req); err != nil {
+			httperror.Respond(w, r, err)
+			return
+		}
+	} else {
+		form, err := request.ParseUpload(r, mediatype.LimitsFromConfig(h.app.Config.Get().Media))
+		if err != nil {
+			httperror.Status(w, request.UploadErrorCode(err), err)
+			return
+		}
+		defer form.RemoveAll()
+
+		if update, err = credentialsOfForm(form); err != nil {
+			httperror.Respond(w, r, err)
+			return
+		}
+
+		avatar, code, err := h.uploadFormFile(ctx, account.ID, form, "avatar")
+		if err != nil {
+			httperror.Status(w, code, err)
+			return
+		} else if avatar != nil {
+			uploads = append(uploads, avatar)
+			update.Avatar, update.AvatarAttachmentID = avatar.URL, avatar.ID
+		}
+		header, code, err := h.uploadFormFile(ctx, account.ID, form, "header")
+		if err != nil {
+			h.discardUploads(ctx, uploads)
+			httperror.Status(w, code, err)
+			return
+		} else if header != nil {
+			uploads = append(uploads, header)
+			update.Header, update.HeaderAttachmentID = header.URL, header.ID
+		}
+	}
+
+	// uploads are committed before the update, so they are deleted when it fails
+	if err := h.app.Dao.Account().UpdateCredentials(ctx, account.ID, update); err != nil {
+		h.discardUploads(ctx, uploads)
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	h.respondCredentials(w, r)
+}
+
+// respondCredentials responds the authenticated account read again with its private settings
+func (h *handler) respondCredentials(w http.ResponseWriter, r *http.Request) {
+	account, err := h.app.Dao.Account().FindByID(r.Context(), auth.AccountOf(r).ID)
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	} else if account == nil {
+		httperror.Error(w, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(CredentialAccount{Account: account, Source: account.Source()}); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// credentialsOfJSON validates req and returns its update
+func credentialsOfJSON(req *UpdateCredentialsRequest) (*object.CredentialsUpdate, error) {
+	verr := &repository.ValidationError{}
+	update := &object.CredentialsUpdate{
+		DisplayName:  req.DisplayName,
+		Note:         req.Note,
+		Avatar:       req.Avatar,
+		Header:       req.Header,
+		Bot:          req.Bot,
+		Discoverable: req.Discoverable,
+	}
+	if req.Avatar != nil && *req.Avatar != "" {
+		verr.Add("avatar", "ERR_INVALID", "can only be cleared in JSON, upload it as multipart form")
+	}
+	if req.Header != nil && *req.Header != "" {
+		verr.Add("header", "ERR_INVALID", "can only be cleared in JSON, upload it as multipart form")
+	}
+	if req.FieldsAttributes != nil {
+		update.Fields = fieldsOf(verr, *req.FieldsAttributes, len(*req.FieldsAttributes) > object.MaxProfileFields)
+	}
+	if req.Source != nil {
+		update.Privacy = req.Source.Privacy
+		update.Sensitive = req.Source.Sensitive
+		update.Language = req.Source.Language
+	}
+
+	validateCredentials(verr, update)
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+// credentialsOfForm validates form and returns its update, uploaded files are not read
+func credentialsOfForm(form *request.Form) (*object.CredentialsUpdate, error) {
+	verr := &repository.ValidationError{}
+	update := &object.CredentialsUpdate{}
+
+	str := func(name string) *string {
+		if s, ok := form.Values[name]; ok {
+			return &s
+		}
+		return nil
+	}
+	flag := func(name string) *bool {
+		s, ok := form.Values[name]
+		if !ok {
+			return nil
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			verr.Add(name, "ERR_INVALID", "must be true or false")
+			return nil
+		}
+		return &b
+	}
+
+	update.DisplayName = str("display_name")
+	update.Note = str("note")
+	// files are not in Values, a text value only clears the image
+	image := func(name string) *string {
+		s := str(name)
+		if s != nil && *s != "" {
+			verr.Add(name, "ERR_INVALID", "must be an uploaded image or empty")
+			return nil
+		}
+		return s
+	}
+	update.Avatar = image("avatar")
+	update.Header = image("header")
+	update.Bot = flag("bot")
+	update.Discoverable = flag("discoverable")
+	update.Privacy = str("source[privacy]")
+	update.Sensitive = flag("source[sensitive]")
+	update.Language = str("source[language]")
+
+	given, tooMany := false, false
+	for key := range form.Values {
+		if !strings.HasPrefix(key, "fields_attributes[") {
+			continue
+		}
+		given = true
+		var i int
+		if n, _ := fmt.Sscanf(key, "fields_attributes[%d]", &i); n == 1 && i >= object.MaxProfileFields {
+			tooMany = true
+		}
+	}
+	if given {
+		attrs := make([]FieldAttributes, object.MaxProfileFields)
+		for i := range attrs {
+			prefix := fmt.Sprintf("fields_attributes[%d]", i)
+			attrs[i] = FieldAttributes{Name: form.Value(prefix + "[name]"), Value: form.Value(prefix + "[value]")}
+		}
+		update.Fields = fieldsOf(verr, attrs, tooMany)
+	}
+
+	validateCredentials(verr, update)
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+// fieldsOf returns profile fields of attrs dropping empty ones
+func fieldsOf(verr *repository.ValidationError, attrs []FieldAttributes, tooMany bool) *object.ProfileFields {
+	if tooMany {
+		verr.Add("fields_attributes", "ERR_LENGTH", fmt.Sprintf("is too long (maximum is %d)", object.MaxProfileFields))
+	}
+
+	fields := object.ProfileFields{}
+	for i, attr := range attrs {
+		field := object.ProfileField{Name: strings.TrimSpace(attr.Name), Value: strings.TrimSpace(attr.Value)}
+		if field.Name == "" && field.Value == "" {
+			continue
+		}
+		prefix := fmt.Sprintf("fields_attributes[%d]", i)
+		if utf8.RuneCountInString(field.Name) > object.MaxProfileFieldLength {
+			verr.Add(prefix+"[name]", "ERR_LENGTH", fmt.Sprintf("is too long (maximum is %d)", object.MaxProfileFieldLength))
+		}
+		if utf8.RuneCountInString(field.Value) > object.MaxProfileFieldLength {
+			verr.Add(prefix+"[value]", "ERR_LENGTH", fmt.Sprintf("is too long (maximum is %d)", object.MaxProfileFieldLength))
+		}
+		fields = append(fields, field)
+	}
+	return &fields
+}
+
+// validateCredentials checks values of update common to JSON and multipart form
+func validateCredentials(verr *repository.ValidationError, update *object.CredentialsUpdate) {
+	if update.DisplayName != nil && utf8.RuneCountInString(*update.DisplayName) > maxDisplayNameLength {
+		verr.Add("display_name", "ERR_LENGTH", fmt.Sprintf("is too long (maximum is %d)", maxDisplayNameLength))
+	}
+	if update.Note != nil && utf8.RuneCountInString(*update.Note) > maxNoteLength {
+		verr.Add("note", "ERR_LENGTH", fmt.Sprintf("is too long (maximum is %d)", maxNoteLength))
+	}
+	if update.Privacy != nil && !object.ValidPrivacy(*update.Privacy) {
+		verr.Add("source[privacy]", "ERR_INVALID", "is not one of public, unlisted and private")
+	}
+	if update.Language != nil && *update.Language != "" && !languagePattern.MatchString(*update.Language) {
+		verr.Add("source[language]", "ERR_INVALID", "is not an ISO 639-1 code")
+	}
+}
@@ -0,0 +1,276 @@
+package accounts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"yatter-backend-go/app/app"
+	"yatter-backend-go/app/config"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/mock"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/handler/auth"
+
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupCredentials(john *object.Account, updated **object.CredentialsUpdate) *handler {
+	d := dao.NewMock(&mock.AccountMock{
+		UpdateCredentialsFunc: func(ctx context.Context, id int64, update *object.CredentialsUpdate) error {
+			*updated = update
+			return nil
+		},
+		FindByIDFunc: func(ctx context.Context, id int64) (*object.Account, error) {
+			return john, nil
+		},
+	}, nil, nil)
+	return &handler{app: &app.App{Dao: d}, validator: validator.New()}
+}
+
+func TestAccount_UpdateCredentialsForm(t *testing.T) {
+	yes, no, empty := true, false, ""
+	unlisted, ja := object.PrivacyUnlisted, "ja"
+
+	cases := map[string]struct {
+		values map[string]string
+		status int
+		want   *object.CredentialsUpdate
+	}{
+		"fields and flags": {
+			values: map[string]string{
+				"fields_attributes[0][name]":  "Blog",
+				"fields_attributes[0][value]": " https://blog.example.com ",
+				"fields_attributes[1][name]":  "",
+				"fields_attributes[1][value]": "",
+				"fields_attributes[2][name]":  "Pronouns",
+				"fields_attributes[2][value]": "they/them",
+				"bot":                         "true",
+				"discoverable":                "0",
+			},
+			status: http.StatusOK,
+			want: &object.CredentialsUpdate{
+				Fields: &object.ProfileFields{
+					{Name: "Blog", Value: "https://blog.example.com"},
+					{Name: "Pronouns", Value: "they/them"},
+				},
+				Bot:          &yes,
+				Discoverable: &no,
+			},
+		},
+		"clear": {
+			values: map[string]string{"fields_attributes[0][name]": "", "note": "", "avatar": "", "source[language]": ""},
+			status: http.StatusOK,
+			want:   &object.CredentialsUpdate{Fields: &object.ProfileFields{}, Note: &empty, Avatar: &empty, Language: &empty},
+		},
+		"source": {
+			values: map[string]string{"source[privacy]": "unlisted", "source[sensitive]": "true", "source[language]": "ja"},
+			status: http.StatusOK,
+			want:   &object.CredentialsUpdate{Privacy: &unlisted, Sensitive: &yes, Language: &ja},
+		},
+		"nothing given": {
+			status: http.StatusOK,
+			want:   &object.CredentialsUpdate{},
+		},
+		"too many fields":   {values: map[string]string{"fields_attributes[4][name]": "Fifth"}, status: http.StatusBadRequest},
+		"too long value":    {values: map[string]string{"fields_attributes[0][value]": strings.Repeat("a", object.MaxProfileFieldLength+1)}, status: http.StatusBadRequest},
+		"invalid flag":      {values: map[string]string{"bot": "maybe"}, status: http.StatusBadRequest},
+		"invalid privacy":   {values: map[string]string{"source[privacy]": "direct"}, status: http.StatusBadRequest},
+		"invalid language":  {values: map[string]string{"source[language]": "japanese"}, status: http.StatusBadRequest},
+		"avatar as text":    {values: map[string]string{"avatar": "https://example.com/a.png"}, status: http.StatusBadRequest},
+		"long display name": {values: map[string]string{"display_name": strings.Repeat("a", 31)}, status: http.StatusBadRequest},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			for k, v := range tt.values {
+				assert.NoError(t, mw.WriteField(k, v))
+			}
+			assert.NoError(t, mw.Close())
+
+			r := httptest.NewRequest(http.MethodPost, "/update_credentials", &body)
+			r.Header.Set("Content-Type", mw.FormDataContentType())
+			john := &object.Account{ID: 1, Username: "john"}
+			r = auth.SetAccount(r, john)
+
+			var updated *object.CredentialsUpdate
+			h := setupCredentials(john, &updated)
+
+			w := httptest.NewRecorder()
+			h.UpdateCredentials(w, r)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.want, updated)
+		})
+	}
+}
+
+func TestAccount_UpdateCredentialsAvatar(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 10, 10))))
+	avatar := buf.Bytes()
+
+	cases := map[string]struct {
+		updateErr error
+		status    int
+		deleted   []int64
+	}{
+		"updated":       {status: http.StatusOK},
+		"update failed": {updateErr: errors.New("connection lost"), status: http.StatusInternalServerError, deleted: []int64{5}},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			var updated *object.CredentialsUpdate
+			var deleted []int64
+			john := &object.Account{ID: 1, Username: "john"}
+			url := "http://localhost/media/avatar.png"
+			d := dao.NewMock(&mock.AccountMock{
+				UpdateCredentialsFunc: func(ctx context.Context, id int64, update *object.CredentialsUpdate) error {
+					updated = update
+					return tt.updateErr
+				},
+				FindByIDFunc: func(ctx context.Context, id int64) (*object.Account, error) {
+					return john, nil
+				},
+			}, nil, &mock.AttachmentMock{
+				UploadFileFunc: func(ctx context.Context, accountID int64, file io.Reader, fileDir, baseURL, ext, filetype, description string, meta *object.AttachmentMeta) (*object.Attachment, error) {
+					return &object.Attachment{ID: 5, AccountID: accountID, Type: filetype, URL: &url}, nil
+				},
+				DeleteFunc: func(ctx context.Context, id int64) error {
+					deleted = append(deleted, id)
+					return nil
+				},
+			})
+			h := &handler{app: &app.App{Dao: d, Config: config.NewStore("", config.Default())}, validator: validator.New()}
+
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			fw, err := mw.CreateFormFile("avatar", "avatar.png")
+			assert.NoError(t, err)
+			_, err = fw.Write(avatar)
+			assert.NoError(t, err)
+			assert.NoError(t, mw.Close())
+
+			r := httptest.NewRequest(http.MethodPost, "/update_credentials", &body)
+			r.Header.Set("Content-Type", mw.FormDataContentType())
+			r = auth.SetAccount(r, john)
+			w := httptest.NewRecorder()
+			h.UpdateCredentials(w, r)
+
+			assert.Equal(t, tt.status, w.Code)
+			if assert.NotNil(t, updated) {
+				assert.Equal(t, &url, updated.Avatar)
+				assert.Equal(t, int64(5), updated.AvatarAttachmentID)
+			}
+			assert.Equal(t, tt.deleted, deleted)
+		})
+	}
+}
+
+func TestAccount_UpdateCredentialsJSON(t *testing.T) {
+	name, empty, private := "John", "", object.PrivacyPrivate
+
+	cases := map[string]struct {
+		body   string
+		status int
+		want   *object.CredentialsUpdate
+	}{
+		"set and clear": {
+			body:   `{"display_name":"John","note":"","header":"","source":{"privacy":"private"}}`,
+			status: http.StatusOK,
+			want:   &object.CredentialsUpdate{DisplayName: &name, Note: &empty, Header: &empty, Privacy: &private},
+		},
+		"fields": {
+			body:   `{"fields_attributes":[{"name":"Site","value":"https://example.com"},{"name":" ","value":""}]}`,
+			status: http.StatusOK,
+			want:   &object.CredentialsUpdate{Fields: &object.ProfileFields{{Name: "Site", Value: "https://example.com"}}},
+		},
+		"too many fields": {
+			body:   `{"fields_attributes":[{"name":"a"},{"name":"b"},{"name":"c"},{"name":"d"},{"name":"e"}]}`,
+			status: http.StatusBadRequest,
+		},
+		"avatar url": {
+			body:   `{"avatar":"https://example.com/a.png"}`,
+			status: http.StatusBadRequest,
+		},
+		"malformed": {
+			body:   `{"bot":"yes"}`,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/update_credentials", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json; charset=utf-8")
+			john := &object.Account{ID: 1, Username: "john"}
+			r = auth.SetAccount(r, john)
+
+			var updated *object.CredentialsUpdate
+			h := setupCredentials(john, &updated)
+
+			w := httptest.NewRecorder()
+			h.UpdateCredentials(w, r)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.want, updated)
+		})
+	}
+}
+
+func TestAccount_VerifyCredentials(t *testing.T) {
+	note, lang := "hello", "ja"
+	john := &object.Account{
+		ID:               1,
+		Username:         "john",
+		Note:             &note,
+		DefaultPrivacy:   object.PrivacyUnlisted,
+		DefaultSensitive: true,
+		DefaultLanguage:  &lang,
+		Fields:           object.ProfileFields{{Name: "Pronouns", Value: "they/them"}},
+	}
+	var updated *object.CredentialsUpdate
+	h := setupCredentials(john, &updated)
+
+	r := auth.SetAccount(httptest.NewRequest(http.MethodGet, "/verify_credentials", nil), john)
+	w := httptest.NewRecorder()
+	h.VerifyCredentials(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var got struct {
+		Username string        `json:"username"`
+		Source   object.Source `json:"source"`
+	}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Equal(t, "john", got.Username)
+	assert.Equal(t, object.Source{
+		Privacy:   object.PrivacyUnlisted,
+		Sensitive: true,
+		Language:  &lang,
+		Note:      "hello",
+		Fields:    object.ProfileFields{{Name: "Pronouns", Value: "they/them"}},
+	}, got.Source)
+
+	// the route is not taken by the username
+	_, router := newHandlerAndRouter(chi.NewRouter(), h.app, h.validator)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/verify_credentials", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,47 @@
+package accounts
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"yatter-backend-go/app/handler/auth"
+	"yatter-backend-go/app/handler/httperror"
+	"yatter-backend-go/app/handler/validate"
+)
+
+// Handle request for `POST /v1/accounts/delete`
+// Request body
+type DeleteRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// Delete handles request for `POST /v1/accounts/delete`
+// The account is left as a tombstone which keeps the username, and its statuses,
+// media, follows and tokens are purged in background.
+func (h *handler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	account := auth.AccountOf(r)
+
+	var req DeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+	if err := validate.Validate(h.validator, req); err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	if !account.CheckPassword(req.Password) {
+		httperror.Status(w, http.StatusForbidden, errors.New("password is incorrect"))
+		return
+	}
+
+	if err := h.app.Dao.Account().Delete(ctx, account.ID); err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,96 @@
+package accounts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/handler/auth"
+	"yatter-backend-go/app/handler/httperror"
+	"yatter-backend-go/app/handler/request"
+)
+
+// Export handles request for `POST /v1/accounts/export`
+// The archive is built in background, its state is polled with `GET /v1/accounts/export/{id}`.
+func (h *handler) Export(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	account := auth.AccountOf(r)
+
+	export, err := h.app.Dao.AccountExport().Create(ctx, account.ID)
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(export); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// GetExport handles request for `GET /v1/accounts/export/{id}`
+func (h *handler) GetExport(w http.ResponseWriter, r *http.Request) {
+	export, ok := h.findExport(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(export); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// DownloadExport handles request for `GET /v1/accounts/export/{id}/download`
+func (h *handler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	export, ok := h.findExport(w, r)
+	if !ok {
+		return
+	}
+	if export.State != object.ExportReady || export.FilePath == nil {
+		httperror.Status(w, http.StatusConflict, fmt.Errorf("export is %s", export.State))
+		return
+	}
+
+	f, err := os.Open(*export.FilePath)
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+	defer f.Close()
+
+	var modtime time.Time
+	if export.CompletedAt != nil {
+		modtime = export.CompletedAt.Time
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%d.zip"`, auth.AccountOf(r).Username, export.ID))
+	http.ServeContent(w, r, "export.zip", modtime, f)
+}
+
+// findExport reads export of path parameter, which must be owned by the authenticated account
+func (h *handler) findExport(w http.ResponseWriter, r *http.Request) (*object.AccountExport, bool) {
+	id, err := request.IDOf(r)
+	if err != nil {
+		httperror.BadRequest(w, err)
+		return nil, false
+	}
+
+	export, err := h.app.Dao.AccountExport().FindByID(r.Context(), id)
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return nil, false
+	}
+	if export == nil || export.AccountID != auth.AccountOf(r).ID {
+		httperror.Status(w, http.StatusNotFound, errors.New("export not found"))
+		return nil, false
+	}
+
+	return export, true
+}
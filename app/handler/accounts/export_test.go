@@ -0,0 +1,100 @@
+package accounts
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"yatter-backend-go/app/app"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/mock"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/handler/auth"
+
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccount_Delete(t *testing.T) {
+	cases := map[string]struct {
+		req     DeleteRequest
+		status  int
+		deleted bool
+	}{
+		"success":          {DeleteRequest{"secret"}, http.StatusAccepted, true},
+		"wrong password":   {DeleteRequest{"wrong"}, http.StatusForbidden, false},
+		"missing password": {DeleteRequest{""}, http.StatusBadRequest, false},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			h, john, _, d := newPasswordTestHandler(t)
+
+			var deleted int64
+			d.AccountMock.DeleteFunc = func(ctx context.Context, id int64) error {
+				deleted = id
+				return nil
+			}
+
+			r := auth.SetAccount(httptest.NewRequest(http.MethodPost, "/", nil), john)
+			w := postJSON(t, h.Delete, r, tt.req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.deleted, deleted == john.ID)
+		})
+	}
+}
+
+func TestAccount_DownloadExport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export-1.zip")
+	if err := ioutil.WriteFile(path, []byte("zip"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	exports := map[int64]*object.AccountExport{
+		1: {ID: 1, AccountID: 1, State: object.ExportReady, FilePath: &path, CompletedAt: &object.DateTime{}},
+		2: {ID: 2, AccountID: 1, State: object.ExportPending},
+		3: {ID: 3, AccountID: 2, State: object.ExportReady, FilePath: &path, CompletedAt: &object.DateTime{}},
+	}
+	d := dao.NewMock(nil, nil, nil)
+	d.AccountExportMock = &mock.AccountExportMock{
+		FindByIDFunc: func(ctx context.Context, id int64) (*object.AccountExport, error) {
+			return exports[id], nil
+		},
+	}
+	h, _ := newHandlerAndRouter(chi.NewRouter(), &app.App{Dao: d}, validator.New())
+
+	cases := map[string]struct {
+		id     string
+		status int
+	}{
+		"ready":            {"1", http.StatusOK},
+		"pending":          {"2", http.StatusConflict},
+		"of other account": {"3", http.StatusNotFound},
+		"unknown":          {"4", http.StatusNotFound},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.id)
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+			r = auth.SetAccount(r, &object.Account{ID: 1, Username: "john"})
+
+			w := httptest.NewRecorder()
+			h.DownloadExport(w, r)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, "zip", w.Body.String())
+				assert.Equal(t, `attachment; filename="john-1.zip"`, w.Header().Get("Content-Disposition"))
+			}
+		})
+	}
+}
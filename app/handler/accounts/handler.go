@@ -8,12 +8,18 @@ import (
 	"math"
 	"net/http"
 	"strings"
+	"yatter-backend-go/app/config"
 	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
 	"yatter-backend-go/app/handler/auth"
 	"yatter-backend-go/app/handler/httperror"
-	"yatter-backend-go/app/handler/media"
 	"yatter-backend-go/app/handler/request"
 	"yatter-backend-go/app/handler/validate"
+	"yatter-backend-go/app/logger"
+	"yatter-backend-go/app/mail"
+	"yatter-backend-go/app/mediatype"
+	"yatter-backend-go/app/metrics"
+	"yatter-backend-go/app/policy"
 
 	"github.com/go-chi/chi"
 )
@@ -29,6 +35,20 @@ type Handler interface {
 	Followers(w http.ResponseWriter, r *http.Request)
 	Relationships(w http.ResponseWriter, r *http.Request)
 	UpdateCredentials(w http.ResponseWriter, r *http.Request)
+	VerifyCredentials(w http.ResponseWriter, r *http.Request)
+	LoginActivity(w http.ResponseWriter, r *http.Request)
+	SetupTwoFactor(w http.ResponseWriter, r *http.Request)
+	ConfirmTwoFactor(w http.ResponseWriter, r *http.Request)
+	DisableTwoFactor(w http.ResponseWriter, r *http.Request)
+	IssueToken(w http.ResponseWriter, r *http.Request)
+	ChangePassword(w http.ResponseWriter, r *http.Request)
+	VerifyEmail(w http.ResponseWriter, r *http.Request)
+	RequestPasswordReset(w http.ResponseWriter, r *http.Request)
+	ResetPassword(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+	Export(w http.ResponseWriter, r *http.Request)
+	GetExport(w http.ResponseWriter, r *http.Request)
+	DownloadExport(w http.ResponseWriter, r *http.Request)
 }
 
 // Handle request for `POST /v1/accounts`
@@ -36,8 +56,23 @@ type Handler interface {
 type CreateRequest struct {
 	Username string `validate:"required"`
 	Password string `validate:"required"`
+
+	// Optional, a token to verify it is sent
+	Email string `validate:"omitempty,email"`
+
+	// Why the user wants to join, required in approval mode without invite_code
+	Reason string `json:"reason" validate:"max=500"`
+
+	// The code of invite, required in invite mode
+	InviteCode string `json:"invite_code"`
 }
 
+// Create handles request for `POST /v1/accounts`
+// Registration follows the registration mode of the configuration.
+//   - open: the account is created
+//   - approval: the account waits for approval with 202 unless registered with an invite
+//   - invite: invite_code is required
+//   - closed: registration is refused with 403
 func (h *handler) Create(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -48,35 +83,83 @@ func (h *handler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := validate.Validate(h.validator, req); err != nil {
-		httperror.BadRequest(w, err)
+		httperror.Respond(w, r, err)
 		return
 	}
 
+	cfg := h.app.Config.Get()
+	if err := policy.CheckRegistration(ctx, cfg.Accounts, h.app.Breach, req.Username, req.Password); err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	mode := cfg.Registration.Mode
+	switch {
+	case mode == config.RegistrationClosed:
+		httperror.Respond(w, r, repository.NewForbidden("registration is closed"))
+		return
+	case mode == config.RegistrationInvite && req.InviteCode == "":
+		httperror.Respond(w, r, repository.NewValidation("invite_code", "ERR_BLANK", "can't be blank"))
+		return
+	case mode == config.RegistrationApproval && req.InviteCode == "" && strings.TrimSpace(req.Reason) == "":
+		httperror.Respond(w, r, repository.NewValidation("reason", "ERR_BLANK", "can't be blank"))
+		return
+	}
+	// an invite is trusted as an approval
+	pending := mode == config.RegistrationApproval && req.InviteCode == ""
+
 	account := new(object.Account)
 	account.Username = req.Username
 	if err := account.SetPassword(req.Password); err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 
 	repo := h.app.Dao.Account()
-	id, err := repo.CreateAccount(ctx, account.Username, account.PasswordHash)
+	id, err := repo.CreateAccount(ctx, &object.Registration{
+		Username:     account.Username,
+		PasswordHash: account.PasswordHash,
+		Email:        req.Email,
+		Reason:       strings.TrimSpace(req.Reason),
+		Pending:      pending,
+		InviteCode:   req.InviteCode,
+	})
 	if err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.Respond(w, r, err)
 		return
 	}
+	account.ID = id
+
+	if req.Email != "" {
+		token, err := h.issueToken(ctx, account, object.TokenEmailVerification, emailVerificationTTL)
+		if err != nil {
+			httperror.InternalServerError(w, r, err)
+			return
+		}
+		h.sendMail(ctx, &mail.Message{
+			To:      req.Email,
+			Subject: "Verify your email address",
+			Body: fmt.Sprintf("Welcome to yatter, @%s.\n\n"+
+				"Verification token: %s\n\n"+
+				"Send it to POST /v1/accounts/verify_email within %s.\n", account.Username, token, emailVerificationTTL),
+		})
+	}
 
 	res, err := repo.FindByID(ctx, id)
 	if err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 	account.CreateAt = res.CreateAt
 
-	w.WriteHeader(http.StatusCreated)
+	status := http.StatusCreated
+	if pending {
+		status = http.StatusAccepted
+	}
+	w.WriteHeader(status)
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(account); err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 }
@@ -90,7 +173,7 @@ func (h *handler) Get(w http.ResponseWriter, r *http.Request) {
 	repo := h.app.Dao.Account()
 	account, err := repo.FindByUsername(ctx, username)
 	if err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 	if account == nil {
@@ -100,7 +183,7 @@ func (h *handler) Get(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(account); err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 }
@@ -110,6 +193,8 @@ type Relationship struct {
 	ID         int64 `json:"id"`
 	Following  bool  `json:"following"`
 	FollowedBy bool  `json:"followed_by"`
+	Blocking   bool  `json:"blocking"`
+	Muting     bool  `json:"muting"`
 }
 
 // Handle request for `POST /v1/accounts/{username}/follow`
@@ -118,7 +203,7 @@ func (h *handler) Follow(w http.ResponseWriter, r *http.Request) {
 
 	follower := auth.AccountOf(r)
 	username := chi.URLParam(r, "username")
-	if username == follower.Username {
+	if strings.EqualFold(username, follower.Username) {
 		httperror.BadRequest(w, errors.New("following yourself is forbidden"))
 		return
 	}
@@ -126,7 +211,7 @@ func (h *handler) Follow(w http.ResponseWriter, r *http.Request) {
 	repo := h.app.Dao.Account()
 	followee, err := repo.FindByUsername(ctx, username)
 	if err != nil {
-		httperror.BadRequest(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	} else if followee == nil {
 		httperror.BadRequest(w, fmt.Errorf("%s you want to follow is not found", username))
@@ -135,7 +220,15 @@ func (h *handler) Follow(w http.ResponseWriter, r *http.Request) {
 
 	id, followedBy, err := repo.Follow(ctx, follower.ID, followee.ID)
 	if err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.Respond(w, r, err)
+		return
+	}
+	metrics.Followed()
+
+	// following is refused while either blocks, so only muting is left
+	_, muting, err := repo.FindBlockAndMute(ctx, follower.ID, followee.ID)
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 
@@ -143,10 +236,11 @@ func (h *handler) Follow(w http.ResponseWriter, r *http.Request) {
 		ID:         id,
 		Following:  true,
 		FollowedBy: followedBy,
+		Muting:     muting,
 	}
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(res); err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 }
@@ -157,7 +251,7 @@ func (h *handler) Unfollow(w http.ResponseWriter, r *http.Request) {
 
 	follower := auth.AccountOf(r)
 	username := chi.URLParam(r, "username")
-	if username == follower.Username {
+	if strings.EqualFold(username, follower.Username) {
 		httperror.BadRequest(w, errors.New("unfollowing yourself is forbidden"))
 		return
 	}
@@ -165,7 +259,7 @@ func (h *handler) Unfollow(w http.ResponseWriter, r *http.Request) {
 	repo := h.app.Dao.Account()
 	followee, err := repo.FindByUsername(ctx, username)
 	if err != nil {
-		httperror.BadRequest(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	} else if followee == nil {
 		httperror.BadRequest(w, fmt.Errorf("%s you want to unfollow is not found", username))
@@ -174,7 +268,14 @@ func (h *handler) Unfollow(w http.ResponseWriter, r *http.Request) {
 
 	id, followedBy, err := repo.Unfollow(ctx, follower.ID, followee.ID)
 	if err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.Respond(w, r, err)
+		return
+	}
+	metrics.Unfollowed()
+
+	blocking, muting, err := repo.FindBlockAndMute(ctx, follower.ID, followee.ID)
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 
@@ -182,10 +283,12 @@ func (h *handler) Unfollow(w http.ResponseWriter, r *http.Request) {
 		ID:         id,
 		Following:  false,
 		FollowedBy: followedBy,
+		Blocking:   blocking,
+		Muting:     muting,
 	}
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(res); err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 }
@@ -207,7 +310,7 @@ func (h *handler) Following(w http.ResponseWriter, r *http.Request) {
 	repo := h.app.Dao.Account()
 	follower, err := repo.FindByUsername(ctx, username)
 	if err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 	if follower == nil {
@@ -217,13 +320,13 @@ func (h *handler) Following(w http.ResponseWriter, r *http.Request) {
 
 	accounts, err := repo.FindFollowing(ctx, follower.ID, params[limit])
 	if err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(accounts); err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 }
@@ -254,7 +357,7 @@ func (h *handler) Followers(w http.ResponseWriter, r *http.Request) {
 	repo := h.app.Dao.Account()
 	followee, err := repo.FindByUsername(ctx, username)
 	if err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	} else if followee == nil {
 		httperror.Error(w, http.StatusNotFound)
@@ -263,13 +366,13 @@ func (h *handler) Followers(w http.ResponseWriter, r *http.Request) {
 
 	accounts, err := repo.FindFollowers(ctx, followee.ID, params[maxID], params[sinceID], params[limit])
 	if err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(accounts); err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 }
@@ -286,13 +389,13 @@ func (h *handler) Relationships(w http.ResponseWriter, r *http.Request) {
 
 	accounts := make(map[string]int64)
 	for _, username := range usernames {
-		if username == user.Username {
+		if strings.EqualFold(username, user.Username) {
 			httperror.BadRequest(w, errors.New("specifying yourself is forbidden"))
 			return
 		}
 		account, err := repo.FindByUsername(ctx, username)
 		if err != nil {
-			httperror.InternalServerError(w, err)
+			httperror.InternalServerError(w, r, err)
 			return
 		} else if account == nil {
 			httperror.BadRequest(w, errors.New("account you want to know relationship is not found"))
@@ -303,90 +406,103 @@ func (h *handler) Relationships(w http.ResponseWriter, r *http.Request) {
 
 	relationships := make([]Relationship, 0)
 	for _, targetID := range accounts {
-		following, followedBy, err := repo.FindRelationship(ctx, user.ID, targetID)
+		relationship, err := h.relationship(ctx, user.ID, targetID)
 		if err != nil {
-			httperror.InternalServerError(w, err)
+			httperror.InternalServerError(w, r, err)
 			return
 		}
-		relationship := Relationship{
-			ID:         targetID,
-			Following:  following,
-			FollowedBy: followedBy,
-		}
-		relationships = append(relationships, relationship)
+		relationships = append(relationships, *relationship)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(relationships); err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 }
 
-// UpdateCredentials handles request for `POST /v1/accounts/update_credentials`
-func (h *handler) UpdateCredentials(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
+// relationship reads the relationship of account with target
+func (h *handler) relationship(ctx context.Context, accountID, targetID int64) (*Relationship, error) {
 	repo := h.app.Dao.Account()
+	following, followedBy, err := repo.FindRelationship(ctx, accountID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	blocking, muting, err := repo.FindBlockAndMute(ctx, accountID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	return &Relationship{
+		ID:         targetID,
+		Following:  following,
+		FollowedBy: followedBy,
+		Blocking:   blocking,
+		Muting:     muting,
+	}, nil
+}
+
+// LoginActivity handles request for `GET /v1/accounts/login_activity`
+func (h *handler) LoginActivity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
 	account := auth.AccountOf(r)
 
-	displayName := r.FormValue("display_name")
-	note := r.FormValue("note")
+	const (
+		maxID   = "max_id"
+		sinceID = "since_id"
+		limit   = "limit"
+	)
 
-	avatar, code, err := h.uploadFormFile(r, ctx, "avatar")
-	if err != nil {
-		http.Error(w, err.Error(), code)
-		return
+	options := []request.Option{
+		{maxID, 0, 1, math.MaxInt64},
+		{sinceID, 0, 1, math.MaxInt64},
+		{limit, 40, 0, 80},
 	}
-
-	header, code, err := h.uploadFormFile(r, ctx, "header")
+	params, err := request.GetOptionParams(r, options)
 	if err != nil {
-		http.Error(w, err.Error(), code)
-		return
-	}
-
-	if err := repo.UpdateCredentials(ctx, account.ID, displayName, note, avatar, header); err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.BadRequest(w, err)
 		return
 	}
 
-	account, err = repo.FindByID(ctx, account.ID)
+	activities, err := h.app.Dao.LoginActivity().ListByAccountID(ctx, account.ID, params[maxID], params[sinceID], params[limit])
 	if err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(account); err != nil {
-		httperror.InternalServerError(w, err)
+	if err := json.NewEncoder(w).Encode(activities); err != nil {
+		httperror.InternalServerError(w, r, err)
 		return
 	}
-
 }
 
-func (h *handler) uploadFormFile(r *http.Request, ctx context.Context, name string) (string, int, error) {
-	file, header, err := r.FormFile(name)
-	if err != nil {
-		if errors.Is(err, http.ErrMissingFile) {
-			return "", http.StatusOK, nil
-		}
-		return "", http.StatusBadRequest, err
+func (h *handler) uploadFormFile(ctx context.Context, accountID int64, form *request.Form, name string) (*object.Attachment, int, error) {
+	file := form.File(name)
+	if file == nil {
+		return nil, http.StatusOK, nil
 	}
-	defer file.Close()
 
-	filetype, err := request.DetectAttachmentType(file)
-	if err != nil {
-		return "", http.StatusInternalServerError, err
-	} else if filetype != request.Image {
-		return "", http.StatusBadRequest, errors.New("invalid file type, please image (jpeg, png, etc.)")
+	if file.Type != mediatype.Image {
+		return nil, http.StatusBadRequest, errors.New("invalid file type, please image (jpeg, png, etc.)")
 	}
 
 	repo := h.app.Dao.Attachment()
-	attachment, err := repo.UploadFile(ctx, file, media.FilesDir, header.Filename, filetype, "")
+	cfg := h.app.Config.Get()
+	attachment, err := repo.UploadFile(ctx, accountID, file, cfg.Media.FilesDir, cfg.Server.PublicURL, file.Ext, file.Type, "", nil)
 	if err != nil {
-		return "", http.StatusInternalServerError, err
+		return nil, http.StatusInternalServerError, err
 	}
+	metrics.Uploaded(file.Type, file.Size)
 
-	return attachment.URL, http.StatusOK, nil
+	return attachment, http.StatusOK, nil
+}
+
+// discardUploads deletes attachments uploaded for a request which failed afterwards
+func (h *handler) discardUploads(ctx context.Context, attachments []*object.Attachment) {
+	for _, attachment := range attachments {
+		if err := h.app.Dao.Attachment().Delete(ctx, attachment.ID); err != nil {
+			logger.FromContext(ctx).Error("can't delete discarded upload", "attachment_id", attachment.ID, "error", err)
+		}
+	}
 }
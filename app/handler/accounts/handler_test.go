@@ -14,6 +14,7 @@ import (
 	"yatter-backend-go/app/domain/mock"
 	"yatter-backend-go/app/domain/object"
 	"yatter-backend-go/app/handler/auth"
+	"yatter-backend-go/app/handler/httperror"
 
 	"github.com/go-chi/chi"
 	"github.com/go-playground/validator/v10"
@@ -50,7 +51,7 @@ func TestAccount_Create(t *testing.T) {
 			args: args{
 				CreateRequest: &CreateRequest{
 					Username: "test",
-					Password: "secret",
+					Password: "long secret",
 				},
 			},
 			want: want{
@@ -80,7 +81,7 @@ func TestAccount_Create(t *testing.T) {
 			args: args{
 				CreateRequest: &CreateRequest{
 					Username: "test",
-					Password: "secret",
+					Password: "long secret",
 				},
 			},
 			want: want{
@@ -110,7 +111,7 @@ func TestAccount_Create(t *testing.T) {
 
 			app := &app.App{Dao: dao.NewMock(
 				&mock.AccountMock{
-					CreateAccountFunc: func(ctx context.Context, username string, passwordHash string) (int64, error) {
+					CreateAccountFunc: func(ctx context.Context, registration *object.Registration) (int64, error) {
 						return tt.want.id, tt.want.err
 					},
 					FindByIDFunc: func(ctx context.Context, id int64) (*object.Account, error) {
@@ -130,11 +131,12 @@ func TestAccount_Create(t *testing.T) {
 			assert.Equal(t, tt.want.status, w.Code)
 
 			var got object.Account
-			err = json.NewDecoder(w.Body).Decode(&got)
 			if tt.want.err == nil {
-				assert.Nil(t, err)
+				assert.Nil(t, json.NewDecoder(w.Body).Decode(&got))
 			} else {
-				assert.NotNil(t, err)
+				var res httperror.Response
+				assert.Nil(t, json.NewDecoder(w.Body).Decode(&res))
+				assert.NotEmpty(t, res.Error)
 			}
 
 			if tt.want.account != nil {
@@ -241,13 +243,13 @@ func TestAccount_Get(t *testing.T) {
 
 			assert.Equal(t, tt.want.status, w.Code)
 
-			var err error
 			var got object.Account
-			err = json.NewDecoder(w.Body).Decode(&got)
 			if tt.expectErr {
-				assert.NotNil(t, err)
+				var res httperror.Response
+				assert.Nil(t, json.NewDecoder(w.Body).Decode(&res))
+				assert.NotEmpty(t, res.Error)
 			} else {
-				assert.Nil(t, err)
+				assert.Nil(t, json.NewDecoder(w.Body).Decode(&got))
 			}
 
 			if tt.want.account != nil {
@@ -357,6 +359,9 @@ func TestAccount_Follow(t *testing.T) {
 					FollowFunc: func(ctx context.Context, followingID, followeeID int64) (int64, bool, error) {
 						return tt.want.relationship.ID, tt.want.relationship.FollowedBy, tt.want.err
 					},
+					FindBlockAndMuteFunc: func(ctx context.Context, accountID, targetID int64) (bool, bool, error) {
+						return false, false, nil
+					},
 				},
 				nil,
 				nil,
@@ -369,13 +374,13 @@ func TestAccount_Follow(t *testing.T) {
 
 			assert.Equal(t, tt.want.status, w.Code)
 
-			var err error
 			var got Relationship
-			err = json.NewDecoder(w.Body).Decode(&got)
 			if tt.expectErr {
-				assert.NotNil(t, err)
+				var res httperror.Response
+				assert.Nil(t, json.NewDecoder(w.Body).Decode(&res))
+				assert.NotEmpty(t, res.Error)
 			} else {
-				assert.Nil(t, err)
+				assert.Nil(t, json.NewDecoder(w.Body).Decode(&got))
 			}
 
 			if !tt.expectErr {
@@ -0,0 +1,230 @@
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/handler/auth"
+	"yatter-backend-go/app/handler/httperror"
+	"yatter-backend-go/app/handler/validate"
+	"yatter-backend-go/app/logger"
+	"yatter-backend-go/app/mail"
+	"yatter-backend-go/app/policy"
+)
+
+const (
+	// Lifetime of the token sent to verify email address
+	emailVerificationTTL = 24 * time.Hour
+
+	// Lifetime of the token sent to reset password
+	passwordResetTTL = time.Hour
+)
+
+// Handle request for `POST /v1/accounts/change_password`
+// Request body
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+// ChangePassword handles request for `POST /v1/accounts/change_password`
+// As clients authenticate with the password on each request, other clients are
+// rejected from the next request. Outstanding reset tokens and access tokens are
+// revoked as well, except the token the request is authorized with.
+func (h *handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	account := auth.AccountOf(r)
+
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+	if err := validate.Validate(h.validator, req); err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	if !account.CheckPassword(req.CurrentPassword) {
+		httperror.Status(w, http.StatusForbidden, errors.New("current password is incorrect"))
+		return
+	}
+	if err := policy.CheckPassword(ctx, h.app.Config.Get().Accounts, h.app.Breach, "new_password", account.Username, req.NewPassword); err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	if err := h.updatePassword(ctx, account, req.NewPassword, auth.TokenIDOf(r)); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Handle request for `POST /v1/accounts/verify_email`
+// Request body
+type VerifyEmailRequest struct {
+	Token string `validate:"required"`
+}
+
+// VerifyEmail handles request for `POST /v1/accounts/verify_email`
+func (h *handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+	if err := validate.Validate(h.validator, req); err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	id, err := h.app.Dao.AccountToken().Use(ctx, object.TokenEmailVerification, auth.HashToken(req.Token))
+	if err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	if err := h.app.Dao.Account().VerifyEmail(ctx, id); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Handle request for `POST /v1/accounts/password_reset`
+// Request body
+type PasswordResetRequest struct {
+	Email string `validate:"required,email"`
+}
+
+// RequestPasswordReset handles request for `POST /v1/accounts/password_reset`
+// It is accepted whether the address is registered or not, not to reveal accounts.
+// Only verified addresses receive the token.
+func (h *handler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req PasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+	if err := validate.Validate(h.validator, req); err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	account, err := h.app.Dao.Account().FindByEmail(ctx, req.Email)
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+
+	if account != nil {
+		token, err := h.issueToken(ctx, account, object.TokenPasswordReset, passwordResetTTL)
+		if err != nil {
+			httperror.InternalServerError(w, r, err)
+			return
+		}
+		h.sendMail(ctx, &mail.Message{
+			To:      req.Email,
+			Subject: "Reset your password",
+			Body: fmt.Sprintf("Someone requested to reset the password of @%s.\n\n"+
+				"Reset token: %s\n\n"+
+				"Send it to POST /v1/accounts/password_reset/confirm within %s.\n"+
+				"If you did not request it, ignore this mail.\n", account.Username, token, passwordResetTTL),
+		})
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Handle request for `POST /v1/accounts/password_reset/confirm`
+// Request body
+type ResetPasswordRequest struct {
+	Token    string `validate:"required"`
+	Password string `validate:"required"`
+}
+
+// ResetPassword handles request for `POST /v1/accounts/password_reset/confirm`
+// The password is checked before the token is used, so that a rejected password
+// does not spend the token. The username is unknown at the moment and not checked.
+func (h *handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+	if err := validate.Validate(h.validator, req); err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	if err := policy.CheckPassword(ctx, h.app.Config.Get().Accounts, h.app.Breach, "password", "", req.Password); err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	id, err := h.app.Dao.AccountToken().Use(ctx, object.TokenPasswordReset, auth.HashToken(req.Token))
+	if err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	account, err := h.app.Dao.Account().FindByID(ctx, id)
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	} else if account == nil {
+		httperror.Error(w, http.StatusNotFound)
+		return
+	}
+
+	if err := h.updatePassword(ctx, account, req.Password, 0); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// updatePassword replaces password of account and revokes reset and access tokens other than keepTokenID
+func (h *handler) updatePassword(ctx context.Context, account *object.Account, password string, keepTokenID int64) error {
+	if err := account.SetPassword(password); err != nil {
+		return err
+	}
+	return h.app.Dao.Account().UpdatePassword(ctx, account.ID, account.PasswordHash, keepTokenID)
+}
+
+// issueToken stores hash of a new token of purpose and returns the token
+func (h *handler) issueToken(ctx context.Context, account *object.Account, purpose string, ttl time.Duration) (string, error) {
+	token, hash, err := auth.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+	if err := h.app.Dao.AccountToken().Create(ctx, account.ID, purpose, hash, time.Now().Add(ttl)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// sendMail sends msg, the request succeeds without the mail
+func (h *handler) sendMail(ctx context.Context, msg *mail.Message) {
+	if h.app.Mailer == nil {
+		return
+	}
+	if err := h.app.Mailer.Send(ctx, msg); err != nil {
+		logger.FromContext(ctx).Warn("failed to send mail", "subject", msg.Subject, "error", err)
+	}
+}
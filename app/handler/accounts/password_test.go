@@ -0,0 +1,271 @@
+package accounts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+	"yatter-backend-go/app/app"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/mock"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+	"yatter-backend-go/app/handler/auth"
+	"yatter-backend-go/app/mail"
+
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+type storedToken struct {
+	id        int64
+	accountID int64
+	purpose   string
+	expiresAt time.Time
+	used      bool
+}
+
+// tokenStore emulates the account_token table
+type tokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]*storedToken
+	nextID int64
+}
+
+// revoke deletes unused tokens of purpose of account other than keepID
+func (s *tokenStore) revoke(accountID int64, purpose string, keepID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, t := range s.tokens {
+		if t.accountID == accountID && t.purpose == purpose && !t.used && t.id != keepID {
+			delete(s.tokens, hash)
+		}
+	}
+}
+
+func (s *tokenStore) mock() *mock.AccountTokenMock {
+	s.tokens = map[string]*storedToken{}
+	return &mock.AccountTokenMock{
+		CreateFunc: func(ctx context.Context, accountID int64, purpose, tokenHash string, expiresAt time.Time) error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.nextID++
+			s.tokens[tokenHash] = &storedToken{id: s.nextID, accountID: accountID, purpose: purpose, expiresAt: expiresAt}
+			return nil
+		},
+		UseFunc: func(ctx context.Context, purpose, tokenHash string) (int64, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			t, ok := s.tokens[tokenHash]
+			if !ok || t.used || t.purpose != purpose || !time.Now().Before(t.expiresAt) {
+				return 0, repository.NewNotFound("token")
+			}
+			t.used = true
+			return t.accountID, nil
+		},
+		FindFunc: func(ctx context.Context, purpose, tokenHash string) (int64, int64, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			t, ok := s.tokens[tokenHash]
+			if !ok || t.used || t.purpose != purpose || !time.Now().Before(t.expiresAt) {
+				return 0, 0, repository.NewNotFound("token")
+			}
+			return t.id, t.accountID, nil
+		},
+		RevokeFunc: func(ctx context.Context, accountID int64, purpose string) error {
+			s.revoke(accountID, purpose, 0)
+			return nil
+		},
+	}
+}
+
+func newPasswordTestHandler(t *testing.T) (Handler, *object.Account, *mail.Memory, *dao.DaoMock) {
+	email := "john@example.com"
+	john := &object.Account{ID: 1, Username: "john", Email: &email, EmailVerifiedAt: &object.DateTime{Time: time.Now()}}
+	if err := john.SetPassword("secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	tokens := &tokenStore{}
+	d := dao.NewMock(&mock.AccountMock{
+		FindByIDFunc: func(ctx context.Context, id int64) (*object.Account, error) {
+			switch id {
+			case john.ID:
+				copied := *john
+				return &copied, nil
+			case 2:
+				return &object.Account{ID: 2, Username: "jane"}, nil
+			}
+			return nil, nil
+		},
+		FindByEmailFunc: func(ctx context.Context, email string) (*object.Account, error) {
+			if email == *john.Email {
+				copied := *john
+				return &copied, nil
+			}
+			return nil, nil
+		},
+		CreateAccountFunc: func(ctx context.Context, registration *object.Registration) (int64, error) {
+			return 2, nil
+		},
+		UpdatePasswordFunc: func(ctx context.Context, id int64, passwordHash string, keepTokenID int64) error {
+			john.PasswordHash = passwordHash
+			tokens.revoke(id, object.TokenPasswordReset, 0)
+			tokens.revoke(id, object.TokenAccess, keepTokenID)
+			return nil
+		},
+	}, nil, nil)
+	d.AccountTokenMock = tokens.mock()
+
+	mailer := mail.NewMemory()
+	h, _ := newHandlerAndRouter(chi.NewRouter(), &app.App{Dao: d, Mailer: mailer}, validator.New())
+	return h, john, mailer, d
+}
+
+func postJSON(t *testing.T, handle http.HandlerFunc, r *http.Request, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", &buf)
+	if r != nil {
+		req = req.WithContext(r.Context())
+	}
+	w := httptest.NewRecorder()
+	handle(w, req)
+	return w
+}
+
+func TestAccount_ChangePassword(t *testing.T) {
+	cases := map[string]struct {
+		req    ChangePasswordRequest
+		status int
+		after  string
+	}{
+		"success":                  {ChangePasswordRequest{"secret", "new secret"}, http.StatusNoContent, "new secret"},
+		"wrong current password":   {ChangePasswordRequest{"wrong", "new secret"}, http.StatusForbidden, "secret"},
+		"missing new password":     {ChangePasswordRequest{"secret", ""}, http.StatusBadRequest, "secret"},
+		"missing current password": {ChangePasswordRequest{"", "new secret"}, http.StatusBadRequest, "secret"},
+		"weak new password":        {ChangePasswordRequest{"secret", "password"}, http.StatusBadRequest, "secret"},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			h, john, _, d := newPasswordTestHandler(t)
+
+			// a reset token issued before is revoked by the change
+			resetHash := auth.HashToken("reset")
+			_ = d.AccountTokenMock.Create(context.Background(), john.ID, object.TokenPasswordReset, resetHash, time.Now().Add(time.Hour))
+
+			r := auth.SetAccount(httptest.NewRequest(http.MethodPost, "/", nil), john)
+			w := postJSON(t, h.ChangePassword, r, tt.req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.True(t, john.CheckPassword(tt.after))
+
+			_, err := d.AccountTokenMock.Use(context.Background(), object.TokenPasswordReset, resetHash)
+			assert.Equal(t, tt.status == http.StatusNoContent, err != nil)
+		})
+	}
+}
+
+func TestAccount_ChangePasswordKeepsCurrentToken(t *testing.T) {
+	_, john, _, d := newPasswordTestHandler(t)
+	_, router := newHandlerAndRouter(chi.NewRouter(), &app.App{Dao: d}, validator.New())
+
+	ctx := context.Background()
+	_ = d.AccountTokenMock.Create(ctx, john.ID, object.TokenAccess, auth.HashToken("current"), time.Now().Add(time.Hour))
+	_ = d.AccountTokenMock.Create(ctx, john.ID, object.TokenAccess, auth.HashToken("other"), time.Now().Add(time.Hour))
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(ChangePasswordRequest{"secret", "new secret"}); err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/change_password", &buf)
+	r.Header.Set("Authorization", "Bearer current")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	// only other sessions are revoked
+	_, _, err := d.AccountTokenMock.Find(ctx, object.TokenAccess, auth.HashToken("current"))
+	assert.NoError(t, err)
+	_, _, err = d.AccountTokenMock.Find(ctx, object.TokenAccess, auth.HashToken("other"))
+	assert.Error(t, err)
+}
+
+var tokenPattern = regexp.MustCompile(`token: ([0-9a-f]+)`)
+
+func TestAccount_PasswordReset(t *testing.T) {
+	h, john, mailer, _ := newPasswordTestHandler(t)
+
+	// unknown addresses are accepted without mail
+	w := postJSON(t, h.RequestPasswordReset, nil, PasswordResetRequest{Email: "jane@example.com"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Len(t, mailer.Messages(), 0)
+
+	w = postJSON(t, h.RequestPasswordReset, nil, PasswordResetRequest{Email: "not an address"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postJSON(t, h.RequestPasswordReset, nil, PasswordResetRequest{Email: "john@example.com"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	if !assert.Len(t, mailer.Messages(), 1) {
+		return
+	}
+	msg := mailer.Messages()[0]
+	assert.Equal(t, "john@example.com", msg.To)
+	m := tokenPattern.FindStringSubmatch(msg.Body)
+	if !assert.NotNil(t, m) {
+		return
+	}
+	token := m[1]
+
+	w = postJSON(t, h.ResetPassword, nil, ResetPasswordRequest{Token: "invalid", Password: "new secret"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = postJSON(t, h.ResetPassword, nil, ResetPasswordRequest{Token: token, Password: "new secret"})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.True(t, john.CheckPassword("new secret"))
+
+	// tokens are single-use
+	w = postJSON(t, h.ResetPassword, nil, ResetPasswordRequest{Token: token, Password: "another secret"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.True(t, john.CheckPassword("new secret"))
+}
+
+func TestAccount_VerifyEmail(t *testing.T) {
+	h, _, mailer, d := newPasswordTestHandler(t)
+
+	var verified int64
+	d.AccountMock.VerifyEmailFunc = func(ctx context.Context, id int64) error {
+		verified = id
+		return nil
+	}
+
+	w := postJSON(t, h.Create, nil, CreateRequest{Username: "jane", Password: "long secret", Email: "jane@example.com"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	if !assert.Len(t, mailer.Messages(), 1) {
+		return
+	}
+	m := tokenPattern.FindStringSubmatch(mailer.Messages()[0].Body)
+	if !assert.NotNil(t, m) {
+		return
+	}
+
+	// a password reset token is not accepted
+	resetHash := auth.HashToken("reset")
+	_ = d.AccountTokenMock.Create(context.Background(), 2, object.TokenPasswordReset, resetHash, time.Now().Add(time.Hour))
+	w = postJSON(t, h.VerifyEmail, nil, VerifyEmailRequest{Token: "reset"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = postJSON(t, h.VerifyEmail, nil, VerifyEmailRequest{Token: m[1]})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, int64(2), verified)
+}
@@ -0,0 +1,69 @@
+package accounts
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"yatter-backend-go/app/app"
+	"yatter-backend-go/app/config"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/mock"
+	"yatter-backend-go/app/domain/object"
+
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccount_CreateRegistrationMode(t *testing.T) {
+	cases := map[string]struct {
+		mode    string
+		body    string
+		status  int
+		pending bool
+	}{
+		"open":                        {config.RegistrationOpen, `{"username":"john","password":"long secret"}`, http.StatusCreated, false},
+		"approval":                    {config.RegistrationApproval, `{"username":"john","password":"long secret","reason":"I like birds"}`, http.StatusAccepted, true},
+		"approval without reason":     {config.RegistrationApproval, `{"username":"john","password":"long secret","reason":"  "}`, http.StatusBadRequest, false},
+		"approval with invite":        {config.RegistrationApproval, `{"username":"john","password":"long secret","invite_code":"abc"}`, http.StatusCreated, false},
+		"invite":                      {config.RegistrationInvite, `{"username":"john","password":"long secret","invite_code":"abc"}`, http.StatusCreated, false},
+		"invite without code":         {config.RegistrationInvite, `{"username":"john","password":"long secret"}`, http.StatusBadRequest, false},
+		"closed":                      {config.RegistrationClosed, `{"username":"john","password":"long secret","invite_code":"abc"}`, http.StatusForbidden, false},
+		"reserved username":           {config.RegistrationOpen, `{"username":"admin","password":"long secret"}`, http.StatusBadRequest, false},
+		"username with slash":         {config.RegistrationOpen, `{"username":"john/doe","password":"long secret"}`, http.StatusBadRequest, false},
+		"weak password":               {config.RegistrationOpen, `{"username":"john","password":"john1234"}`, http.StatusBadRequest, false},
+		"approval with a long reason": {config.RegistrationApproval, `{"username":"john","password":"long secret","reason":"` + strings.Repeat("a", 501) + `"}`, http.StatusBadRequest, false},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			var registered *object.Registration
+			d := dao.NewMock(&mock.AccountMock{
+				CreateAccountFunc: func(ctx context.Context, registration *object.Registration) (int64, error) {
+					registered = registration
+					return 1, nil
+				},
+				FindByIDFunc: func(ctx context.Context, id int64) (*object.Account, error) {
+					return &object.Account{ID: id, Username: "john"}, nil
+				},
+			}, nil, nil)
+			cfg := config.Default()
+			cfg.Registration.Mode = tt.mode
+			a := &app.App{Dao: d, Config: config.NewStore("", cfg)}
+			h, _ := newHandlerAndRouter(chi.NewRouter(), a, validator.New())
+
+			w := httptest.NewRecorder()
+			h.Create(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status >= http.StatusBadRequest {
+				assert.Nil(t, registered)
+				return
+			}
+			assert.Equal(t, tt.pending, registered.Pending)
+		})
+	}
+}
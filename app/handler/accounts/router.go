@@ -2,9 +2,11 @@ package accounts
 
 import (
 	"net/http"
+	"time"
 
 	"yatter-backend-go/app/app"
 	"yatter-backend-go/app/handler/auth"
+	"yatter-backend-go/app/ratelimit"
 
 	"github.com/go-chi/chi"
 	"github.com/go-playground/validator/v10"
@@ -34,11 +36,32 @@ func newHandlerAndRouter(r *chi.Mux, app *app.App, validator *validator.Validate
 		r.Use(auth.BasicAuth(h.app))
 		r.Post("/{username}/follow", h.Follow)
 		r.Post("/{username}/unfollow", h.Unfollow)
+		r.Post("/2fa/setup", h.SetupTwoFactor)
+		r.Post("/2fa/confirm", h.ConfirmTwoFactor)
+		r.Post("/change_password", h.ChangePassword)
+		r.Post("/delete", h.Delete)
+		r.With(app.RateLimit.Limit(ratelimit.Policy{
+			Name: "accounts.export", Limit: 1, Window: time.Hour, Key: auth.ByAccount,
+		})).Post("/export", h.Export)
+		r.Get("/export/{id}", h.GetExport)
+		r.Get("/export/{id}/download", h.DownloadExport)
 	})
+	// bearer tokens are issued and two-factor authentication is disabled only with the code
+	r.With(auth.PasswordAuth(h.app)).Post("/token", h.IssueToken)
+	r.With(auth.PasswordAuth(h.app)).Post("/2fa/disable", h.DisableTwoFactor)
 	r.With(auth.BasicAuth(h.app)).Get("/relationships", h.Relationships)
 	r.With(auth.BasicAuth(h.app)).Post("/update_credentials", h.UpdateCredentials)
+	r.With(auth.BasicAuth(h.app)).Get("/verify_credentials", h.VerifyCredentials)
+	r.With(auth.BasicAuth(h.app)).Get("/login_activity", h.LoginActivity)
 
-	r.Post("/", h.Create)
+	r.With(app.RateLimit.Limit(ratelimit.Policy{
+		Name: "accounts.create", Limit: 5, Window: 30 * time.Minute, Key: ratelimit.ByIP,
+	})).Post("/", h.Create)
+	r.Post("/verify_email", h.VerifyEmail)
+	r.With(app.RateLimit.Limit(ratelimit.Policy{
+		Name: "accounts.password_reset", Limit: 5, Window: 30 * time.Minute, Key: ratelimit.ByIP,
+	})).Post("/password_reset", h.RequestPasswordReset)
+	r.Post("/password_reset/confirm", h.ResetPassword)
 	r.Get("/{username}", h.Get)
 	r.Get("/{username}/following", h.Following)
 	r.Get("/{username}/followers", h.Followers)
@@ -0,0 +1,51 @@
+package accounts
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/handler/auth"
+	"yatter-backend-go/app/handler/httperror"
+)
+
+// Lifetime of the access token issued by `POST /v1/accounts/token`
+const accessTokenTTL = 30 * 24 * time.Hour
+
+// TokenResponse is response of `POST /v1/accounts/token`
+type TokenResponse struct {
+	// Token to send as `Authorization: Bearer`
+	AccessToken string `json:"access_token"`
+
+	TokenType string `json:"token_type"`
+
+	// Lifetime of the token in seconds
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+// IssueToken handles request for `POST /v1/accounts/token`
+// PasswordAuth has verified the password and the code of OTPHeader once,
+// the token is accepted by BasicAuth until it expires or the password is changed.
+func (h *handler) IssueToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	account := auth.AccountOf(r)
+
+	token, err := h.issueToken(ctx, account, object.TokenAccess, accessTokenTTL)
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+
+	res := &TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(accessTokenTTL / time.Second),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,103 @@
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"yatter-backend-go/app/app"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/mock"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/handler/auth"
+	"yatter-backend-go/app/ratelimit"
+	"yatter-backend-go/app/totp"
+
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueTokenWithTwoFactor(t *testing.T) {
+	john := &object.Account{ID: 1, Username: "john"}
+	if err := john.SetPassword("long secret"); err != nil {
+		t.Fatal(err)
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tf := &object.TwoFactor{AccountID: john.ID, Secret: secret, Enabled: true}
+
+	d := dao.NewMock(&mock.AccountMock{
+		FindByUsernameFunc: func(ctx context.Context, username string) (*object.Account, error) {
+			if username == john.Username {
+				return john, nil
+			}
+			return nil, nil
+		},
+		FindByIDFunc: func(ctx context.Context, id int64) (*object.Account, error) {
+			if id == john.ID {
+				return john, nil
+			}
+			return nil, nil
+		},
+	}, nil, nil)
+	d.AccountTokenMock = (&tokenStore{}).mock()
+	d.LoginActivityMock = &mock.LoginActivityMock{
+		CreateFunc: func(ctx context.Context, activity *object.LoginActivity) error { return nil },
+	}
+	d.TwoFactorMock = &mock.TwoFactorMock{
+		FindByAccountIDFunc: func(ctx context.Context, accountID int64) (*object.TwoFactor, error) {
+			copied := *tf
+			return &copied, nil
+		},
+		UseCounterFunc: func(ctx context.Context, accountID, counter int64) (bool, error) {
+			if counter <= tf.LastCounter {
+				return false, nil
+			}
+			tf.LastCounter = counter
+			return true, nil
+		},
+	}
+	_, router := newHandlerAndRouter(chi.NewRouter(), &app.App{Dao: d, Lockout: ratelimit.NewLockout()}, validator.New())
+
+	do := func(method, path string, set func(r *http.Request)) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, nil)
+		set(r)
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	code, err := totp.Code(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := do(http.MethodPost, "/token", func(r *http.Request) {
+		r.SetBasicAuth("john", "long secret")
+		r.Header.Set(auth.OTPHeader, code)
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var res TokenResponse
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&res))
+	assert.Equal(t, "Bearer", res.TokenType)
+	assert.NotEmpty(t, res.AccessToken)
+
+	// the code is spent once, requests in a row go on with the token beyond the lockout budget
+	bearer := func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+res.AccessToken) }
+	for i := 0; i < ratelimit.NewLockout().Free+2; i++ {
+		w = do(http.MethodGet, "/verify_credentials", bearer)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	// a token doesn't issue another one, nor disable two-factor authentication
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/token", bearer).Code)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/2fa/disable", bearer).Code)
+
+	w = do(http.MethodGet, "/verify_credentials", func(r *http.Request) { r.Header.Set("Authorization", "Bearer unknown") })
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "invalid_token")
+}
@@ -0,0 +1,138 @@
+package accounts
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+	"yatter-backend-go/app/handler/auth"
+	"yatter-backend-go/app/handler/httperror"
+	"yatter-backend-go/app/handler/validate"
+	"yatter-backend-go/app/totp"
+)
+
+// Issuer shown in authenticator apps
+const otpIssuer = "yatter"
+
+// TwoFactorSetupResponse is response of `POST /v1/accounts/2fa/setup`
+type TwoFactorSetupResponse struct {
+	// Base32 encoded secret for manual entry
+	Secret string `json:"secret"`
+
+	// otpauth URI to be shown as QR code
+	URI string `json:"uri"`
+}
+
+// SetupTwoFactor handles request for `POST /v1/accounts/2fa/setup`
+// It is not enabled until confirmed with a code, calling again replaces the secret.
+func (h *handler) SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	account := auth.AccountOf(r)
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := h.app.Dao.TwoFactor().Setup(ctx, account.ID, secret); err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	res := &TwoFactorSetupResponse{
+		Secret: secret,
+		URI:    totp.URI(otpIssuer, account.Username, secret),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// Handle request for `POST /v1/accounts/2fa/confirm`
+// Request body
+type ConfirmTwoFactorRequest struct {
+	Code string `validate:"required"`
+}
+
+// ConfirmTwoFactorResponse is response of `POST /v1/accounts/2fa/confirm`
+type ConfirmTwoFactorResponse struct {
+	// Single-use codes to log in without the authenticator, shown only once
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// ConfirmTwoFactor handles request for `POST /v1/accounts/2fa/confirm`
+func (h *handler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	account := auth.AccountOf(r)
+
+	var req ConfirmTwoFactorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+	if err := validate.Validate(h.validator, req); err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	repo := h.app.Dao.TwoFactor()
+	tf, err := repo.FindByAccountID(ctx, account.ID)
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	} else if tf == nil {
+		httperror.Respond(w, r, repository.NewNotFound("two_factor"))
+		return
+	} else if tf.Enabled {
+		httperror.Respond(w, r, repository.NewConflict("two_factor", "", "two-factor authentication is already enabled"))
+		return
+	}
+
+	counter, ok := totp.Validate(tf.Secret, req.Code, time.Now())
+	if !ok {
+		httperror.Respond(w, r, repository.NewValidation("code", "ERR_INVALID", "is invalid"))
+		return
+	}
+
+	codes, hashes, err := auth.GenerateRecoveryCodes()
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+	if err := repo.Enable(ctx, account.ID, counter, hashes); err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+	// tokens issued without the code are not trusted anymore
+	if err := h.app.Dao.AccountToken().Revoke(ctx, account.ID, object.TokenAccess); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&ConfirmTwoFactorResponse{RecoveryCodes: codes}); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// DisableTwoFactor handles request for `POST /v1/accounts/2fa/disable`
+// PasswordAuth has already verified the code of OTPHeader.
+func (h *handler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	account := auth.AccountOf(r)
+
+	if err := h.app.Dao.TwoFactor().Disable(ctx, account.ID); err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
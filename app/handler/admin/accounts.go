@@ -0,0 +1,282 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+	"yatter-backend-go/app/handler/auth"
+	"yatter-backend-go/app/handler/httperror"
+	"yatter-backend-go/app/handler/request"
+
+	"github.com/go-chi/chi"
+)
+
+// Number of login activities in account details
+const loginActivityLimit = 20
+
+// Account of the admin API, which includes fields hidden from the public API
+type Account struct {
+	ID            int64            `json:"id"`
+	Username      string           `json:"username"`
+	Email         *string          `json:"email,omitempty"`
+	EmailVerified bool             `json:"email_verified"`
+	Role          string           `json:"role"`
+	CreateAt      object.DateTime  `json:"create_at"`
+	SuspendedAt   *object.DateTime `json:"suspended_at,omitempty"`
+	SilencedAt    *object.DateTime `json:"silenced_at,omitempty"`
+	SensitizedAt  *object.DateTime `json:"sensitized_at,omitempty"`
+	DeletedAt     *object.DateTime `json:"deleted_at,omitempty"`
+
+	// Whether the account waits for approval
+	Pending bool `json:"pending"`
+
+	// Why the user wants to join
+	Reason *string `json:"reason,omitempty"`
+
+	// The invite used to register
+	InviteID *int64 `json:"invite_id,omitempty"`
+
+	// The public representation of the account
+	Account *object.Account `json:"account"`
+
+	// Recent login activities, only in account details
+	LoginActivities []object.LoginActivity `json:"login_activities,omitempty"`
+}
+
+func newAccount(a *object.Account) *Account {
+	return &Account{
+		ID:            a.ID,
+		Username:      a.Username,
+		Email:         a.Email,
+		EmailVerified: a.EmailVerifiedAt != nil,
+		Role:          a.Role,
+		CreateAt:      a.CreateAt,
+		SuspendedAt:   a.SuspendedAt,
+		SilencedAt:    a.SilencedAt,
+		SensitizedAt:  a.SensitizedAt,
+		DeletedAt:     a.DeletedAt,
+		Pending:       a.Pending,
+		Reason:        a.RegistrationReason,
+		InviteID:      a.InviteID,
+		Account:       a,
+	}
+}
+
+// Moderation actions of `POST /v1/admin/accounts/{id}/{action}`
+var accountActions = map[string]bool{
+	object.ActionSuspend:   true,
+	object.ActionUnsuspend: true,
+	object.ActionSilence:   true,
+	object.ActionUnsilence: true,
+	object.ActionSensitive: true,
+}
+
+// ListAccounts handles request for `GET /v1/admin/accounts`
+// Query parameters username (partial match), email, role and status filter accounts.
+func (h *handler) ListAccounts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	const (
+		maxID   = "max_id"
+		sinceID = "since_id"
+		limit   = "limit"
+	)
+
+	options := []request.Option{
+		{Name: maxID, DefaultValue: 0, MinValue: 1, MaxValue: math.MaxInt64},
+		{Name: sinceID, DefaultValue: 0, MinValue: 1, MaxValue: math.MaxInt64},
+		{Name: limit, DefaultValue: 40, MinValue: 0, MaxValue: 80},
+	}
+	params, err := request.GetOptionParams(r, options)
+	if err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := object.AccountFilter{
+		Username: q.Get("username"),
+		Email:    q.Get("email"),
+		Role:     q.Get("role"),
+		Status:   q.Get("status"),
+	}
+	if filter.Role != "" && !object.ValidRole(filter.Role) {
+		httperror.Respond(w, r, repository.NewValidation("role", "ERR_INVALID", "is not included in the list"))
+		return
+	}
+
+	accounts, err := h.app.Dao.Admin().ListAccounts(ctx, filter, params[maxID], params[sinceID], params[limit])
+	if err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	res := make([]*Account, len(accounts))
+	for i := range accounts {
+		res[i] = newAccount(&accounts[i])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// GetAccount handles request for `GET /v1/admin/accounts/{id}`
+func (h *handler) GetAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	account, ok := h.findAccount(w, r)
+	if !ok {
+		return
+	}
+
+	activities, err := h.app.Dao.LoginActivity().ListByAccountID(ctx, account.ID, 0, 0, loginActivityLimit)
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+
+	res := newAccount(account)
+	res.LoginActivities = activities
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// ModerateAccount handles request for `POST /v1/admin/accounts/{id}/{action}`
+// action is one of suspend, unsuspend, silence, unsilence and sensitive.
+func (h *handler) ModerateAccount(w http.ResponseWriter, r *http.Request) {
+	action := chi.URLParam(r, "action")
+	if !accountActions[action] {
+		httperror.Error(w, http.StatusNotFound)
+		return
+	}
+
+	h.moderateAccount(w, r, action)
+}
+
+// ApproveAccount handles request for `POST /v1/admin/accounts/{id}/approve`
+func (h *handler) ApproveAccount(w http.ResponseWriter, r *http.Request) {
+	h.moderateAccount(w, r, object.ActionApprove)
+}
+
+// RejectAccount handles request for `POST /v1/admin/accounts/{id}/reject`
+// The pending account is deleted.
+func (h *handler) RejectAccount(w http.ResponseWriter, r *http.Request) {
+	h.moderateAccount(w, r, object.ActionReject)
+}
+
+// moderateAccount applies action to account of path parameter and writes it
+func (h *handler) moderateAccount(w http.ResponseWriter, r *http.Request, action string) {
+	account, ok := h.findTarget(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.app.Dao.Admin().ModerateAccount(r.Context(), account.ID, auth.AccountOf(r).ID, action); err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	h.respondAccount(w, r, account.ID)
+}
+
+// Handle request for `POST /v1/admin/accounts/{id}/role`
+// Request body
+type ChangeRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// ChangeRole handles request for `POST /v1/admin/accounts/{id}/role`
+func (h *handler) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req ChangeRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+	if !object.ValidRole(req.Role) {
+		httperror.Respond(w, r, repository.NewValidation("role", "ERR_INVALID", "is not included in the list"))
+		return
+	}
+
+	account, ok := h.findTarget(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.app.Dao.Admin().ChangeRole(ctx, account.ID, auth.AccountOf(r).ID, req.Role); err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	h.respondAccount(w, r, account.ID)
+}
+
+// findAccount reads account of path parameter, deleted accounts are included
+func (h *handler) findAccount(w http.ResponseWriter, r *http.Request) (*object.Account, bool) {
+	id, err := request.IDOf(r)
+	if err != nil {
+		httperror.BadRequest(w, err)
+		return nil, false
+	}
+
+	account, err := h.app.Dao.Admin().FindAccount(r.Context(), id)
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return nil, false
+	} else if account == nil {
+		httperror.Respond(w, r, repository.NewNotFound("account"))
+		return nil, false
+	}
+
+	return account, true
+}
+
+// findTarget reads account of path parameter which the authorized account can moderate
+// Staff accounts are moderated only by admins, and nobody moderates themselves.
+func (h *handler) findTarget(w http.ResponseWriter, r *http.Request) (*object.Account, bool) {
+	account, ok := h.findAccount(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	actor := auth.AccountOf(r)
+	if account.ID == actor.ID {
+		httperror.Respond(w, r, repository.NewForbidden("cannot moderate yourself"))
+		return nil, false
+	}
+	if account.HasRole(object.RoleModerator) && !actor.HasRole(object.RoleAdmin) {
+		httperror.Respond(w, r, repository.NewForbidden("%s is moderated only by admins", account.Role))
+		return nil, false
+	}
+
+	return account, true
+}
+
+// respondAccount writes the account after an action
+func (h *handler) respondAccount(w http.ResponseWriter, r *http.Request, id int64) {
+	account, err := h.app.Dao.Admin().FindAccount(r.Context(), id)
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	} else if account == nil {
+		httperror.InternalServerError(w, r, errors.New("account disappeared"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(newAccount(account)); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+	"yatter-backend-go/app/handler/httperror"
+	"yatter-backend-go/app/handler/request"
+)
+
+// ListActionLogs handles request for `GET /v1/admin/action_logs`
+// Query parameters account_id, action, target_type and target_id filter logs.
+// target_id is applied with target_type.
+func (h *handler) ListActionLogs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	const (
+		maxID   = "max_id"
+		sinceID = "since_id"
+		limit   = "limit"
+	)
+
+	options := []request.Option{
+		{Name: maxID, DefaultValue: 0, MinValue: 1, MaxValue: math.MaxInt64},
+		{Name: sinceID, DefaultValue: 0, MinValue: 1, MaxValue: math.MaxInt64},
+		{Name: limit, DefaultValue: 40, MinValue: 0, MaxValue: 80},
+	}
+	params, err := request.GetOptionParams(r, options)
+	if err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := object.ActionLogFilter{
+		Action:     q.Get("action"),
+		TargetType: q.Get("target_type"),
+	}
+	switch filter.TargetType {
+	case "", object.TargetAccount, object.TargetStatus, object.TargetReport:
+	default:
+		httperror.Respond(w, r, repository.NewValidation("target_type", "ERR_INVALID", "is not included in the list"))
+		return
+	}
+	if filter.AccountID, err = idParam(q.Get("account_id")); err != nil {
+		httperror.Respond(w, r, repository.NewValidation("account_id", "ERR_INVALID", "is invalid"))
+		return
+	}
+	if filter.TargetID, err = idParam(q.Get("target_id")); err != nil {
+		httperror.Respond(w, r, repository.NewValidation("target_id", "ERR_INVALID", "is invalid"))
+		return
+	} else if filter.TargetID != 0 && filter.TargetType == "" {
+		httperror.Respond(w, r, repository.NewValidation("target_type", "ERR_BLANK", "can't be blank"))
+		return
+	}
+
+	logs, err := h.app.Dao.Admin().ListActionLogs(ctx, filter, params[maxID], params[sinceID], params[limit])
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(logs); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/object"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdmin_ListActionLogs(t *testing.T) {
+	cases := map[string]struct {
+		query  string
+		status int
+		filter object.ActionLogFilter
+	}{
+		"all":                 {"", http.StatusOK, object.ActionLogFilter{}},
+		"by moderator":        {"?account_id=1&action=suspend", http.StatusOK, object.ActionLogFilter{AccountID: 1, Action: object.ActionSuspend}},
+		"by target":           {"?target_type=status&target_id=5", http.StatusOK, object.ActionLogFilter{TargetType: object.TargetStatus, TargetID: 5}},
+		"target without type": {"?target_id=5", http.StatusBadRequest, object.ActionLogFilter{}},
+		"unknown target type": {"?target_type=media", http.StatusBadRequest, object.ActionLogFilter{}},
+		"invalid account":     {"?account_id=x", http.StatusBadRequest, object.ActionLogFilter{}},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			h, _ := setupAdmin(nil)
+			var got object.ActionLogFilter
+			h.app.Dao.(*dao.DaoMock).AdminMock.ListActionLogsFunc = func(ctx context.Context, filter object.ActionLogFilter, maxID, sinceID, limit int64) ([]object.ActionLog, error) {
+				got = filter
+				return []object.ActionLog{{ID: 1, Action: object.ActionDeleteStatus, Before: object.RawJSON(`{"id":5}`)}}, nil
+			}
+
+			r := newRequest(http.MethodGet, "", &object.Account{ID: 1, Role: object.RoleModerator}, nil)
+			r.URL.RawQuery = strings.TrimPrefix(tt.query, "?")
+			w := httptest.NewRecorder()
+			h.ListActionLogs(w, r)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.filter, got)
+			if tt.status == http.StatusOK {
+				var logs []map[string]interface{}
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&logs))
+				assert.Equal(t, map[string]interface{}{"id": float64(5)}, logs[0]["before"])
+				assert.Nil(t, logs[0]["after"])
+			}
+		})
+	}
+}
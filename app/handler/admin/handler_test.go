@@ -0,0 +1,162 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"yatter-backend-go/app/app"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/mock"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/handler/auth"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRequest(method, body string, actor *object.Account, params map[string]string) *http.Request {
+	r := httptest.NewRequest(method, "/", strings.NewReader(body))
+	rctx := chi.NewRouteContext()
+	for k, v := range params {
+		rctx.URLParams.Add(k, v)
+	}
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+	return auth.SetAccount(r, actor)
+}
+
+func setupAdmin(accounts map[int64]*object.Account) (*handler, *[]string) {
+	var actions []string
+	d := dao.NewMock(nil, nil, nil)
+	d.AdminMock = &mock.AdminMock{
+		FindAccountFunc: func(ctx context.Context, id int64) (*object.Account, error) {
+			return accounts[id], nil
+		},
+		ModerateAccountFunc: func(ctx context.Context, id, actorID int64, action string) error {
+			actions = append(actions, action)
+			return nil
+		},
+		ChangeRoleFunc: func(ctx context.Context, id, actorID int64, role string) error {
+			accounts[id].Role = role
+			return nil
+		},
+	}
+	d.LoginActivityMock = &mock.LoginActivityMock{
+		ListByAccountIDFunc: func(ctx context.Context, accountID, maxID, sinceID, limit int64) ([]object.LoginActivity, error) {
+			return []object.LoginActivity{{ID: 1, AccountID: accountID, Success: true}}, nil
+		},
+	}
+	return &handler{app: &app.App{Dao: d}}, &actions
+}
+
+func TestAdmin_ModerateAccount(t *testing.T) {
+	moderator := &object.Account{ID: 1, Role: object.RoleModerator}
+	admin := &object.Account{ID: 2, Role: object.RoleAdmin}
+
+	cases := map[string]struct {
+		actor  *object.Account
+		id     string
+		action string
+		status int
+	}{
+		"suspend user":       {moderator, "3", object.ActionSuspend, http.StatusOK},
+		"force sensitive":    {moderator, "3", object.ActionSensitive, http.StatusOK},
+		"unknown action":     {moderator, "3", "ban", http.StatusNotFound},
+		"unknown account":    {moderator, "9", object.ActionSuspend, http.StatusNotFound},
+		"yourself":           {moderator, "1", object.ActionSilence, http.StatusForbidden},
+		"admin by moderator": {moderator, "2", object.ActionSuspend, http.StatusForbidden},
+		"moderator by admin": {admin, "1", object.ActionSuspend, http.StatusOK},
+		"invalid id":         {moderator, "x", object.ActionSuspend, http.StatusBadRequest},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			h, actions := setupAdmin(map[int64]*object.Account{
+				1: moderator,
+				2: admin,
+				3: {ID: 3, Username: "john", Role: object.RoleUser},
+			})
+
+			w := httptest.NewRecorder()
+			h.ModerateAccount(w, newRequest(http.MethodPost, "", tt.actor, map[string]string{"id": tt.id, "action": tt.action}))
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, []string{tt.action}, *actions)
+			} else {
+				assert.Empty(t, *actions)
+			}
+		})
+	}
+}
+
+func TestAdmin_GetAccount(t *testing.T) {
+	email := "john@example.com"
+	h, _ := setupAdmin(map[int64]*object.Account{
+		3: {ID: 3, Username: "john", Email: &email, Role: object.RoleUser},
+	})
+
+	w := httptest.NewRecorder()
+	h.GetAccount(w, newRequest(http.MethodGet, "", &object.Account{ID: 1, Role: object.RoleModerator}, map[string]string{"id": "3"}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var got Account
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Equal(t, email, *got.Email)
+	assert.Equal(t, object.RoleUser, got.Role)
+	assert.Len(t, got.LoginActivities, 1)
+}
+
+func TestAdmin_ChangeRole(t *testing.T) {
+	admin := &object.Account{ID: 2, Role: object.RoleAdmin}
+
+	cases := map[string]struct {
+		id     string
+		body   string
+		status int
+	}{
+		"promote":      {"3", `{"role":"moderator"}`, http.StatusOK},
+		"unknown role": {"3", `{"role":"owner"}`, http.StatusBadRequest},
+		"yourself":     {"2", `{"role":"user"}`, http.StatusForbidden},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			accounts := map[int64]*object.Account{
+				2: admin,
+				3: {ID: 3, Role: object.RoleUser},
+			}
+			h, _ := setupAdmin(accounts)
+
+			w := httptest.NewRecorder()
+			h.ChangeRole(w, newRequest(http.MethodPost, tt.body, admin, map[string]string{"id": tt.id}))
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, object.RoleModerator, accounts[3].Role)
+			}
+		})
+	}
+}
+
+func TestAdmin_ApproveAccount(t *testing.T) {
+	admin := &object.Account{ID: 2, Role: object.RoleAdmin}
+	h, actions := setupAdmin(map[int64]*object.Account{
+		2: admin,
+		3: {ID: 3, Username: "john", Role: object.RoleUser, Pending: true},
+	})
+
+	w := httptest.NewRecorder()
+	h.ApproveAccount(w, newRequest(http.MethodPost, "", admin, map[string]string{"id": "3"}))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	h.RejectAccount(w, newRequest(http.MethodPost, "", admin, map[string]string{"id": "3"}))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, []string{object.ActionApprove, object.ActionReject}, *actions)
+}
@@ -0,0 +1,216 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+	"yatter-backend-go/app/handler/auth"
+	"yatter-backend-go/app/handler/httperror"
+	"yatter-backend-go/app/handler/request"
+)
+
+// ListReports handles request for `GET /v1/admin/reports`
+// Query parameters state, assigned_account_id and target_account_id filter reports.
+func (h *handler) ListReports(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	const (
+		maxID   = "max_id"
+		sinceID = "since_id"
+		limit   = "limit"
+	)
+
+	options := []request.Option{
+		{Name: maxID, DefaultValue: 0, MinValue: 1, MaxValue: math.MaxInt64},
+		{Name: sinceID, DefaultValue: 0, MinValue: 1, MaxValue: math.MaxInt64},
+		{Name: limit, DefaultValue: 40, MinValue: 0, MaxValue: 80},
+	}
+	params, err := request.GetOptionParams(r, options)
+	if err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := object.ReportFilter{State: q.Get("state")}
+	switch filter.State {
+	case "", object.ReportOpen, object.ReportResolved:
+	default:
+		httperror.Respond(w, r, repository.NewValidation("state", "ERR_INVALID", "is not included in the list"))
+		return
+	}
+	if filter.AssignedAccountID, err = idParam(q.Get("assigned_account_id")); err != nil {
+		httperror.Respond(w, r, repository.NewValidation("assigned_account_id", "ERR_INVALID", "is invalid"))
+		return
+	}
+	if filter.TargetAccountID, err = idParam(q.Get("target_account_id")); err != nil {
+		httperror.Respond(w, r, repository.NewValidation("target_account_id", "ERR_INVALID", "is invalid"))
+		return
+	}
+
+	reports, err := h.app.Dao.Report().List(ctx, filter, params[maxID], params[sinceID], params[limit])
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(reports); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// GetReport handles request for `GET /v1/admin/reports/{id}`
+func (h *handler) GetReport(w http.ResponseWriter, r *http.Request) {
+	id, err := request.IDOf(r)
+	if err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+
+	h.respondReport(w, r, id)
+}
+
+// Handle request for `POST /v1/admin/reports/{id}/assign`
+// Request body, the authorized account is assigned without account_id
+type AssignReportRequest struct {
+	AccountID *int64 `json:"account_id"`
+}
+
+// AssignReport handles request for `POST /v1/admin/reports/{id}/assign`
+func (h *handler) AssignReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := request.IDOf(r)
+	if err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+
+	var req AssignReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		httperror.BadRequest(w, err)
+		return
+	}
+
+	actor := auth.AccountOf(r)
+	assigneeID := actor.ID
+	if req.AccountID != nil {
+		assigneeID = *req.AccountID
+	}
+	if assigneeID != actor.ID {
+		assignee, err := h.app.Dao.Admin().FindAccount(ctx, assigneeID)
+		if err != nil {
+			httperror.InternalServerError(w, r, err)
+			return
+		}
+		if assignee == nil || assignee.DeletedAt != nil || !assignee.HasRole(object.RoleModerator) {
+			httperror.Respond(w, r, repository.NewValidation("account_id", "ERR_INVALID", "is not a moderator"))
+			return
+		}
+	}
+
+	if err := h.app.Dao.Report().Assign(ctx, id, actor.ID, &assigneeID); err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	h.respondReport(w, r, id)
+}
+
+// UnassignReport handles request for `POST /v1/admin/reports/{id}/unassign`
+func (h *handler) UnassignReport(w http.ResponseWriter, r *http.Request) {
+	h.actOnReport(w, r, func(id, actorID int64) error {
+		return h.app.Dao.Report().Assign(r.Context(), id, actorID, nil)
+	})
+}
+
+// ResolveReport handles request for `POST /v1/admin/reports/{id}/resolve`
+func (h *handler) ResolveReport(w http.ResponseWriter, r *http.Request) {
+	h.actOnReport(w, r, func(id, actorID int64) error {
+		return h.app.Dao.Report().Resolve(r.Context(), id, actorID)
+	})
+}
+
+// ReopenReport handles request for `POST /v1/admin/reports/{id}/reopen`
+func (h *handler) ReopenReport(w http.ResponseWriter, r *http.Request) {
+	h.actOnReport(w, r, func(id, actorID int64) error {
+		return h.app.Dao.Report().Reopen(r.Context(), id, actorID)
+	})
+}
+
+// Handle request for `POST /v1/admin/reports/{id}/notes`
+// Request body
+type AddReportNoteRequest struct {
+	Content string `json:"content"`
+}
+
+// AddReportNote handles request for `POST /v1/admin/reports/{id}/notes`
+func (h *handler) AddReportNote(w http.ResponseWriter, r *http.Request) {
+	var req AddReportNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		httperror.Respond(w, r, repository.NewValidation("content", "ERR_BLANK", "can't be blank"))
+		return
+	}
+
+	h.actOnReport(w, r, func(id, actorID int64) error {
+		return h.app.Dao.Report().AddNote(r.Context(), id, actorID, req.Content)
+	})
+}
+
+// actOnReport runs action on report of path parameter by the authorized account
+func (h *handler) actOnReport(w http.ResponseWriter, r *http.Request, action func(id, actorID int64) error) {
+	id, err := request.IDOf(r)
+	if err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+
+	if err := action(id, auth.AccountOf(r).ID); err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	h.respondReport(w, r, id)
+}
+
+// respondReport writes the report with its details
+func (h *handler) respondReport(w http.ResponseWriter, r *http.Request, id int64) {
+	report, err := h.app.Dao.Report().FindByID(r.Context(), id)
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	} else if report == nil {
+		httperror.Respond(w, r, repository.NewNotFound("report"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// idParam parses optional ID of query parameter, 0 if it is empty
+func idParam(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id < 1 {
+		return 0, errors.New("invalid id")
+	}
+	return id, nil
+}
@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/mock"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdmin_AssignReport(t *testing.T) {
+	moderator := &object.Account{ID: 1, Role: object.RoleModerator}
+
+	cases := map[string]struct {
+		id       string
+		body     string
+		status   int
+		assignee int64
+	}{
+		"yourself":       {"1", "", http.StatusOK, 1},
+		"moderator":      {"1", `{"account_id":2}`, http.StatusOK, 2},
+		"user":           {"1", `{"account_id":3}`, http.StatusBadRequest, 0},
+		"unknown":        {"1", `{"account_id":9}`, http.StatusBadRequest, 0},
+		"unknown report": {"5", `{"account_id":2}`, http.StatusNotFound, 0},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			h, _ := setupAdmin(map[int64]*object.Account{
+				1: moderator,
+				2: {ID: 2, Role: object.RoleAdmin},
+				3: {ID: 3, Role: object.RoleUser},
+			})
+			var assignee int64
+			h.app.Dao.(*dao.DaoMock).ReportMock = &mock.ReportMock{
+				AssignFunc: func(ctx context.Context, id, actorID int64, assigneeID *int64) error {
+					if id != 1 {
+						return repository.NewNotFound("report")
+					}
+					assert.Equal(t, moderator.ID, actorID)
+					assignee = *assigneeID
+					return nil
+				},
+				FindByIDFunc: func(ctx context.Context, id int64) (*object.Report, error) {
+					return &object.Report{ID: id, AssignedAccountID: &assignee}, nil
+				},
+			}
+
+			w := httptest.NewRecorder()
+			h.AssignReport(w, newRequest(http.MethodPost, tt.body, moderator, map[string]string{"id": tt.id}))
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.assignee, assignee)
+		})
+	}
+}
+
+func TestAdmin_ResolveReport(t *testing.T) {
+	h, _ := setupAdmin(nil)
+	state := object.ReportOpen
+	h.app.Dao.(*dao.DaoMock).ReportMock = &mock.ReportMock{
+		ResolveFunc: func(ctx context.Context, id, actorID int64) error {
+			if state == object.ReportResolved {
+				return repository.NewConflict("report", "state", "report is already resolved")
+			}
+			state = object.ReportResolved
+			return nil
+		},
+		FindByIDFunc: func(ctx context.Context, id int64) (*object.Report, error) {
+			return &object.Report{ID: id, State: state}, nil
+		},
+	}
+	moderator := &object.Account{ID: 1, Role: object.RoleModerator}
+
+	w := httptest.NewRecorder()
+	h.ResolveReport(w, newRequest(http.MethodPost, "", moderator, map[string]string{"id": "1"}))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	h.ResolveReport(w, newRequest(http.MethodPost, "", moderator, map[string]string{"id": "1"}))
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
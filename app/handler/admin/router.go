@@ -0,0 +1,46 @@
+package admin
+
+import (
+	"net/http"
+	"yatter-backend-go/app/app"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/handler/auth"
+
+	"github.com/go-chi/chi"
+)
+
+type handler struct {
+	app *app.App
+}
+
+// Create Handler for `/v1/admin/`
+// Moderators and admins are allowed, roles and registrations are handled only by admins.
+// The first admin is promoted in the database, e.g. `UPDATE account SET role = 'admin'`.
+func NewRouter(app *app.App) http.Handler {
+	r := chi.NewRouter()
+
+	h := &handler{app: app}
+
+	r.Use(auth.BasicAuth(h.app))
+	r.Use(auth.RequireRole(object.RoleModerator))
+
+	r.Get("/accounts", h.ListAccounts)
+	r.Get("/accounts/{id}", h.GetAccount)
+	r.With(auth.RequireRole(object.RoleAdmin)).Post("/accounts/{id}/role", h.ChangeRole)
+	r.With(auth.RequireRole(object.RoleAdmin)).Post("/accounts/{id}/approve", h.ApproveAccount)
+	r.With(auth.RequireRole(object.RoleAdmin)).Post("/accounts/{id}/reject", h.RejectAccount)
+	r.Post("/accounts/{id}/{action}", h.ModerateAccount)
+	r.Delete("/statuses/{id}", h.DeleteStatus)
+
+	r.Get("/reports", h.ListReports)
+	r.Get("/reports/{id}", h.GetReport)
+	r.Post("/reports/{id}/assign", h.AssignReport)
+	r.Post("/reports/{id}/unassign", h.UnassignReport)
+	r.Post("/reports/{id}/resolve", h.ResolveReport)
+	r.Post("/reports/{id}/reopen", h.ReopenReport)
+	r.Post("/reports/{id}/notes", h.AddReportNote)
+
+	r.Get("/action_logs", h.ListActionLogs)
+
+	return r
+}
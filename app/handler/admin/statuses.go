@@ -0,0 +1,24 @@
+package admin
+
+import (
+	"net/http"
+	"yatter-backend-go/app/handler/auth"
+	"yatter-backend-go/app/handler/httperror"
+	"yatter-backend-go/app/handler/request"
+)
+
+// DeleteStatus handles request for `DELETE /v1/admin/statuses/{id}`
+func (h *handler) DeleteStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := request.IDOf(r)
+	if err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+
+	if err := h.app.Dao.Admin().DeleteStatus(r.Context(), id, auth.AccountOf(r).ID); err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,16 +2,36 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"yatter-backend-go/app/app"
 	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
 	"yatter-backend-go/app/handler/httperror"
+	"yatter-backend-go/app/logger"
+	"yatter-backend-go/app/ratelimit"
 )
 
 var contextKey = new(struct{})
 
+// tokenContextKey is the key of the bearer token ID, its type differs from contextKey
+type tokenContextKey struct{}
+
+var (
+	// errSuspended is returned to suspended accounts
+	errSuspended = errors.New("account is suspended")
+
+	// errPending is returned to accounts waiting for approval
+	errPending = errors.New("account is pending approval")
+)
+
+// Budget of failed BasicAuth attempts of each client
+var basicAuthPolicy = ratelimit.Policy{Name: "auth.basic", Limit: 300, Window: 5 * time.Minute, Key: ratelimit.ByIP}
+
 // Auth by header
 func Middleware(app *app.App) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -34,24 +54,51 @@ func Middleware(app *app.App) func(http.Handler) http.Handler {
 
 			username := pair[1]
 			if account, err := app.Dao.Account().FindByUsername(ctx, username); err != nil {
-				httperror.InternalServerError(w, err)
+				httperror.InternalServerError(w, r, err)
 				return
 			} else if account == nil {
 				httperror.Error(w, http.StatusUnauthorized)
 				return
+			} else if account.SuspendedAt != nil {
+				httperror.Status(w, http.StatusForbidden, errSuspended)
+				return
+			} else if account.Pending {
+				httperror.Status(w, http.StatusForbidden, errPending)
+				return
 			} else {
+				logger.SetAccountID(ctx, account.ID)
 				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey, account)))
 			}
 		})
 	}
 }
 
-// BasicAuth for basic authentication
+// BasicAuth for basic authentication, failed attempts are throttled by client address
+// Failures lock the username and the client address with exponential backoff,
+// and attempts to existing accounts are recorded to login activities.
+// Accounts with two-factor authentication require the code in OTPHeader.
+// Suspended and pending accounts are rejected with 403 after the credentials are checked.
+// A bearer token issued by `POST /v1/accounts/token` is accepted instead of the credentials,
+// so that clients with two-factor authentication don't spend a code on each request.
 func BasicAuth(app *app.App) func(http.Handler) http.Handler {
+	return authenticate(app, true)
+}
+
+// PasswordAuth is BasicAuth which doesn't accept bearer tokens, to issue them
+func PasswordAuth(app *app.App) func(http.Handler) http.Handler {
+	return authenticate(app, false)
+}
+
+func authenticate(app *app.App, allowBearer bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
 
+			if token, ok := bearerToken(r); ok && allowBearer {
+				bearerAuth(w, r, app, token, next)
+				return
+			}
+
 			username, password, ok := r.BasicAuth()
 			if !ok {
 				w.Header().Set("WWW-Authenticate", "Basic")
@@ -59,19 +106,172 @@ func BasicAuth(app *app.App) func(http.Handler) http.Handler {
 				return
 			}
 
-			if account, err := app.Dao.Account().FindByUsername(ctx, username); err != nil {
-				httperror.InternalServerError(w, err)
+			// only failed checks consume the budget, so clients signed in are not throttled
+			if wait, exceeded := app.RateLimit.Exceeded(r, basicAuthPolicy); exceeded {
+				ratelimit.SetRetryAfter(w, wait)
+				httperror.Error(w, http.StatusTooManyRequests)
 				return
-			} else if account == nil || !account.CheckPassword(password) {
+			}
+
+			// locked attempts are rejected before bcrypt
+			userKey := "user:" + strings.ToLower(username)
+			attempt, wait := app.Lockout.Begin(userKey, ratelimit.ByIP(r))
+			if wait > 0 {
+				logger.FromContext(ctx).Info("login locked", "username", username)
+				ratelimit.SetRetryAfter(w, wait)
+				httperror.Error(w, http.StatusTooManyRequests)
+				return
+			}
+
+			account, err := app.Dao.Account().FindByUsername(ctx, username)
+			if err != nil {
+				attempt.Cancel()
+				httperror.InternalServerError(w, r, err)
+				return
+			}
+			if account == nil || !account.CheckPassword(password) {
+				attempt.Fail()
+				app.RateLimit.Charge(r, basicAuthPolicy)
+				if account != nil {
+					reason := object.LoginInvalidPassword
+					recordLogin(app, r, account, &reason)
+				}
 				httperror.Error(w, http.StatusUnauthorized)
 				return
-			} else {
-				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey, account)))
 			}
+
+			if ok, err := checkTwoFactor(w, r, app, account); err != nil {
+				attempt.Cancel()
+				httperror.InternalServerError(w, r, err)
+				return
+			} else if !ok {
+				if r.Header.Get(OTPHeader) == "" {
+					// asking for the code is not a failure
+					attempt.Cancel()
+				} else {
+					attempt.Fail()
+					app.RateLimit.Charge(r, basicAuthPolicy)
+					reason := object.LoginInvalidOTP
+					recordLogin(app, r, account, &reason)
+				}
+				httperror.Error(w, http.StatusUnauthorized)
+				return
+			}
+
+			if account.SuspendedAt != nil || account.Pending {
+				// the credentials are right, so it is not a failure
+				attempt.Cancel()
+				reason, err := object.LoginSuspended, errSuspended
+				if account.SuspendedAt == nil {
+					reason, err = object.LoginPending, errPending
+				}
+				recordLogin(app, r, account, &reason)
+				httperror.Status(w, http.StatusForbidden, err)
+				return
+			}
+
+			attempt.Succeed(userKey)
+			recordLogin(app, r, account, nil)
+
+			logger.SetAccountID(ctx, account.ID)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey, account)))
 		})
 	}
 }
 
+// bearerToken reads the token of `Authorization: Bearer`
+func bearerToken(r *http.Request) (string, bool) {
+	pair := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+	if len(pair) < 2 || !strings.EqualFold(pair[0], "bearer") || pair[1] == "" {
+		return "", false
+	}
+	return pair[1], true
+}
+
+// bearerAuth authorizes the account of token, the code of two-factor authentication was checked on issuance
+func bearerAuth(w http.ResponseWriter, r *http.Request, app *app.App, token string, next http.Handler) {
+	ctx := r.Context()
+
+	tokenID, accountID, err := app.Dao.AccountToken().Find(ctx, object.TokenAccess, HashToken(token))
+	var notFound *repository.NotFoundError
+	if errors.As(err, &notFound) {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		httperror.Error(w, http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+
+	account, err := app.Dao.Account().FindByID(ctx, accountID)
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	} else if account == nil {
+		httperror.Error(w, http.StatusUnauthorized)
+		return
+	} else if account.SuspendedAt != nil {
+		httperror.Status(w, http.StatusForbidden, errSuspended)
+		return
+	} else if account.Pending {
+		httperror.Status(w, http.StatusForbidden, errPending)
+		return
+	}
+
+	logger.SetAccountID(ctx, account.ID)
+	ctx = context.WithValue(ctx, tokenContextKey{}, tokenID)
+	next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, contextKey, account)))
+}
+
+// checkTwoFactor verifies OTPHeader if account enabled two-factor authentication
+func checkTwoFactor(w http.ResponseWriter, r *http.Request, app *app.App, account *object.Account) (bool, error) {
+	repo := app.Dao.TwoFactor()
+	tf, err := repo.FindByAccountID(r.Context(), account.ID)
+	if err != nil {
+		return false, err
+	} else if tf == nil || !tf.Enabled {
+		return true, nil
+	}
+
+	code := r.Header.Get(OTPHeader)
+	if code == "" {
+		w.Header().Set(OTPHeader, "required")
+		return false, nil
+	}
+	return VerifyOTP(r.Context(), repo, tf, code, time.Now())
+}
+
+// Max length of user agent stored in login activities
+const maxUserAgent = 255
+
+// recordLogin records the attempt, failureReason is nil on success
+func recordLogin(app *app.App, r *http.Request, account *object.Account, failureReason *string) {
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxUserAgent {
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgent], "")
+	}
+
+	activity := &object.LoginActivity{
+		AccountID:     account.ID,
+		Success:       failureReason == nil,
+		FailureReason: failureReason,
+		IP:            ratelimit.ClientIP(r),
+		UserAgent:     userAgent,
+	}
+	// authentication goes on without the record
+	if err := app.Dao.LoginActivity().Create(r.Context(), activity); err != nil {
+		logger.FromContext(r.Context()).Warn("failed to record login activity", "error", err)
+	}
+}
+
+// ByAccount keys requests by the authorized account, others are keyed by IP
+func ByAccount(r *http.Request) string {
+	if account := AccountOf(r); account != nil {
+		return "account:" + strconv.FormatInt(account.ID, 10)
+	}
+	return ratelimit.ByIP(r)
+}
+
 // Read Account data from authorized request
 func AccountOf(r *http.Request) *object.Account {
 	if cv := r.Context().Value(contextKey); cv == nil {
@@ -85,6 +285,12 @@ func AccountOf(r *http.Request) *object.Account {
 	}
 }
 
+// TokenIDOf returns ID of the bearer token which authorized request, 0 if it is authorized with the password
+func TokenIDOf(r *http.Request) int64 {
+	id, _ := r.Context().Value(tokenContextKey{}).(int64)
+	return id
+}
+
 // Set Account data to authorized request
 func SetAccount(r *http.Request, account *object.Account) *http.Request {
 	return r.WithContext(context.WithValue(r.Context(), contextKey, account))
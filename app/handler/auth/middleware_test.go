@@ -0,0 +1,357 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"yatter-backend-go/app/app"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/mock"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/ratelimit"
+	"yatter-backend-go/app/totp"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type loginRecorder struct {
+	mu         sync.Mutex
+	activities []object.LoginActivity
+}
+
+func (l *loginRecorder) create(ctx context.Context, activity *object.LoginActivity) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.activities = append(l.activities, *activity)
+	return nil
+}
+
+func setupBasicAuth(t *testing.T) (http.Handler, *int32, *loginRecorder) {
+	h, lookups, recorder, _ := setupBasicAuthWithDao(t)
+	return h, lookups, recorder
+}
+
+func setupBasicAuthWithDao(t *testing.T) (http.Handler, *int32, *loginRecorder, *dao.DaoMock) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	john := &object.Account{ID: 1, Username: "john", PasswordHash: string(hash)}
+
+	var lookups int32
+	recorder := &loginRecorder{}
+	d := dao.NewMock(&mock.AccountMock{
+		FindByUsernameFunc: func(ctx context.Context, username string) (*object.Account, error) {
+			atomic.AddInt32(&lookups, 1)
+			if username == john.Username {
+				return john, nil
+			}
+			return nil, nil
+		},
+	}, nil, nil)
+	d.LoginActivityMock = &mock.LoginActivityMock{CreateFunc: recorder.create}
+	d.TwoFactorMock = &mock.TwoFactorMock{
+		FindByAccountIDFunc: func(ctx context.Context, accountID int64) (*object.TwoFactor, error) {
+			return nil, nil
+		},
+	}
+
+	a := &app.App{Dao: d, Lockout: ratelimit.NewLockout()}
+	h := BasicAuth(a)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, john.ID, AccountOf(r).ID)
+	}))
+	return h, &lookups, recorder, d
+}
+
+func basicAuth(h http.Handler, username, password string) *httptest.ResponseRecorder {
+	return basicAuthOTP(h, username, password, "")
+}
+
+func basicAuthOTP(h http.Handler, username, password, otp string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Header.Set("User-Agent", "test")
+	r.SetBasicAuth(username, password)
+	if otp != "" {
+		r.Header.Set(OTPHeader, otp)
+	}
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestBasicAuth(t *testing.T) {
+	h, _, recorder := setupBasicAuth(t)
+
+	tests := map[string]struct {
+		username string
+		password string
+		status   int
+	}{
+		"success":        {"john", "secret", http.StatusOK},
+		"wrong password": {"john", "wrong", http.StatusUnauthorized},
+		"unknown user":   {"jane", "secret", http.StatusUnauthorized},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			w := basicAuth(h, tt.username, tt.password)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+
+	// unknown accounts have nothing to record
+	assert.Len(t, recorder.activities, 2)
+	for _, activity := range recorder.activities {
+		assert.Equal(t, int64(1), activity.AccountID)
+		assert.Equal(t, "192.0.2.1", activity.IP)
+		assert.Equal(t, "test", activity.UserAgent)
+		if activity.Success {
+			assert.Nil(t, activity.FailureReason)
+		} else {
+			assert.Equal(t, object.LoginInvalidPassword, *activity.FailureReason)
+		}
+	}
+}
+
+func TestBasicAuthConcurrentFailures(t *testing.T) {
+	h, lookups, recorder := setupBasicAuth(t)
+
+	const n = 50
+	var (
+		wg    sync.WaitGroup
+		start = make(chan struct{})
+		codes = make(chan int, n)
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			w := basicAuth(h, "john", "wrong")
+			if w.Code == http.StatusTooManyRequests {
+				assert.NotEmpty(t, w.Header().Get("Retry-After"))
+			}
+			codes <- w.Code
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(codes)
+
+	count := map[int]int{}
+	for code := range codes {
+		count[code]++
+	}
+
+	// passwords are checked only within the budget, others are rejected before bcrypt
+	free := ratelimit.NewLockout().Free
+	assert.LessOrEqual(t, count[http.StatusUnauthorized], free)
+	assert.Equal(t, n, count[http.StatusUnauthorized]+count[http.StatusTooManyRequests])
+	assert.Equal(t, int32(count[http.StatusUnauthorized]), atomic.LoadInt32(lookups))
+	assert.Len(t, recorder.activities, count[http.StatusUnauthorized])
+
+	// the right password is rejected as well while locked
+	w := basicAuth(h, "john", "secret")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+// twoFactorStore emulates conditional updates of the two_factor and recovery_code tables
+type twoFactorStore struct {
+	mu    sync.Mutex
+	tf    object.TwoFactor
+	codes map[string]bool
+}
+
+func (s *twoFactorStore) mock() *mock.TwoFactorMock {
+	return &mock.TwoFactorMock{
+		FindByAccountIDFunc: func(ctx context.Context, accountID int64) (*object.TwoFactor, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			tf := s.tf
+			return &tf, nil
+		},
+		UseCounterFunc: func(ctx context.Context, accountID, counter int64) (bool, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if counter <= s.tf.LastCounter {
+				return false, nil
+			}
+			s.tf.LastCounter = counter
+			return true, nil
+		},
+		UseRecoveryCodeFunc: func(ctx context.Context, accountID int64, codeHash string) (bool, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			unused, ok := s.codes[codeHash]
+			s.codes[codeHash] = false
+			return ok && unused, nil
+		},
+	}
+}
+
+func TestBasicAuthTwoFactor(t *testing.T) {
+	h, _, recorder, d := setupBasicAuthWithDao(t)
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes, hashes, err := GenerateRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &twoFactorStore{
+		tf:    object.TwoFactor{AccountID: 1, Secret: secret, Enabled: true},
+		codes: map[string]bool{},
+	}
+	for _, hash := range hashes {
+		store.codes[hash] = true
+	}
+	d.TwoFactorMock = store.mock()
+
+	// the code is asked for after the password
+	w := basicAuth(h, "john", "secret")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "required", w.Header().Get(OTPHeader))
+
+	w = basicAuthOTP(h, "john", "wrong", "000000")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Header().Get(OTPHeader))
+
+	code, err := totp.Code(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// concurrent uses of the same code accept only one
+	const n = 3
+	var (
+		wg sync.WaitGroup
+		ok int32
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if basicAuthOTP(h, "john", "secret", code).Code == http.StatusOK {
+				atomic.AddInt32(&ok, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), ok)
+
+	// recovery codes are single-use, formatting is ignored
+	w = basicAuthOTP(h, "john", "secret", strings.ToUpper(codes[0]))
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = basicAuthOTP(h, "john", "secret", codes[0])
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	var reasons []string
+	for _, activity := range recorder.activities {
+		if activity.FailureReason != nil {
+			reasons = append(reasons, *activity.FailureReason)
+		}
+	}
+	assert.Equal(t, []string{
+		object.LoginInvalidPassword,
+		object.LoginInvalidOTP,
+		object.LoginInvalidOTP,
+		object.LoginInvalidOTP,
+	}, reasons)
+}
+
+func TestBasicAuthSuspended(t *testing.T) {
+	h, _, recorder, d := setupBasicAuthWithDao(t)
+	john, err := d.AccountMock.FindByUsername(context.Background(), "john")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := object.DateTime{Time: time.Now()}
+	john.SuspendedAt = &now
+
+	assert.Equal(t, http.StatusForbidden, basicAuth(h, "john", "secret").Code)
+	// the password is still checked before the suspension is revealed
+	assert.Equal(t, http.StatusUnauthorized, basicAuth(h, "john", "wrong").Code)
+
+	assert.Len(t, recorder.activities, 2)
+	assert.Equal(t, object.LoginSuspended, *recorder.activities[0].FailureReason)
+}
+
+func TestRequireRole(t *testing.T) {
+	h := RequireRole(object.RoleModerator)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := map[string]struct {
+		account *object.Account
+		status  int
+	}{
+		"anonymous": {nil, http.StatusUnauthorized},
+		"user":      {&object.Account{Role: object.RoleUser}, http.StatusForbidden},
+		"moderator": {&object.Account{Role: object.RoleModerator}, http.StatusOK},
+		"admin":     {&object.Account{Role: object.RoleAdmin}, http.StatusOK},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.account != nil {
+				r = SetAccount(r, tt.account)
+			}
+			h.ServeHTTP(w, r)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestBasicAuthPending(t *testing.T) {
+	h, _, recorder, d := setupBasicAuthWithDao(t)
+	john, err := d.AccountMock.FindByUsername(context.Background(), "john")
+	if err != nil {
+		t.Fatal(err)
+	}
+	john.Pending = true
+
+	w := basicAuth(h, "john", "secret")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "pending approval")
+
+	assert.Len(t, recorder.activities, 1)
+	assert.Equal(t, object.LoginPending, *recorder.activities[0].FailureReason)
+}
+
+func TestBasicAuthChargesOnlyFailures(t *testing.T) {
+	h, _, _, d := setupBasicAuthWithDao(t)
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(), nil)
+	a := &app.App{Dao: d, Lockout: ratelimit.NewLockout(), RateLimit: limiter}
+	h = BasicAuth(a)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// more successful requests than the budget of failures
+	for i := 0; i < basicAuthPolicy.Limit+1; i++ {
+		if w := basicAuth(h, "john", "secret"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: got %d", i, w.Code)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	_, exceeded := limiter.Exceeded(r, basicAuthPolicy)
+	assert.False(t, exceeded)
+
+	assert.Equal(t, http.StatusUnauthorized, basicAuth(h, "john", "wrong").Code)
+	for i := 1; i < basicAuthPolicy.Limit; i++ {
+		limiter.Charge(r, basicAuthPolicy)
+	}
+	w := basicAuth(h, "john", "secret")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"yatter-backend-go/app/handler/httperror"
+)
+
+// RequireRole rejects accounts without role or a higher one, it must follow BasicAuth
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			account := AccountOf(r)
+			if account == nil {
+				httperror.Error(w, http.StatusUnauthorized)
+				return
+			}
+			if !account.HasRole(role) {
+				httperror.Status(w, http.StatusForbidden, fmt.Errorf("%s role is required", role))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"strings"
+	"time"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+	"yatter-backend-go/app/totp"
+)
+
+// OTPHeader carries the one-time password or a recovery code on BasicAuth
+const OTPHeader = "X-OTP"
+
+// Number of recovery codes issued on enrollment
+const RecoveryCodeCount = 10
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateRecoveryCodes returns single-use codes to show once and their hashes to store
+func GenerateRecoveryCodes() (codes, hashes []string, err error) {
+	codes = make([]string, RecoveryCodeCount)
+	hashes = make([]string, RecoveryCodeCount)
+	for i := range codes {
+		// 80 bits, formatted as "xxxx-xxxx-xxxx-xxxx"
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		s := strings.ToLower(recoveryEncoding.EncodeToString(b))
+		codes[i] = s[0:4] + "-" + s[4:8] + "-" + s[8:12] + "-" + s[12:16]
+		hashes[i] = HashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode returns hash of code ignoring case, spaces and hyphens
+// Codes have enough entropy for a fast hash, unlike passwords.
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyOTP checks a TOTP code or a recovery code of tf
+// Each TOTP code and recovery code is accepted only once.
+func VerifyOTP(ctx context.Context, repo repository.TwoFactor, tf *object.TwoFactor, code string, now time.Time) (bool, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		counter, ok := totp.Validate(tf.Secret, code, now)
+		if !ok || counter <= tf.LastCounter {
+			return false, nil
+		}
+		return repo.UseCounter(ctx, tf.AccountID, counter)
+	}
+
+	return repo.UseRecoveryCode(ctx, tf.AccountID, HashRecoveryCode(code))
+}
@@ -11,6 +11,8 @@ import (
 	"testing"
 
 	"yatter-backend-go/app/app"
+	"yatter-backend-go/app/config"
+	"yatter-backend-go/app/logger"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
@@ -62,7 +64,11 @@ func TestAccountRegistration(t *testing.T) {
 }
 
 func setup(t *testing.T) *C {
-	app, err := app.NewApp()
+	cfg, err := config.Load("")
+	if err != nil {
+		panic(err)
+	}
+	app, err := app.NewApp(config.NewStore("", cfg), logger.Nop())
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,154 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+	"yatter-backend-go/app/dao"
+)
+
+const (
+	statusOK   = "ok"
+	statusFail = "fail"
+
+	// Max duration of each check
+	checkTimeout = 2 * time.Second
+
+	// Duration to reuse the last result
+	cacheTTL = 2 * time.Second
+)
+
+// Check returns error if the dependency is unavailable
+type Check struct {
+	Name string
+	Func func(ctx context.Context) error
+}
+
+// Result of a check
+type Result struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report of all checks
+type Report struct {
+	Status    string            `json:"status"`
+	Checks    map[string]Result `json:"checks"`
+	CheckedAt time.Time         `json:"checked_at"`
+}
+
+// OK reports whether all checks passed
+func (r *Report) OK() bool {
+	return r.Status == statusOK
+}
+
+// Checker runs checks concurrently and caches the report
+type Checker struct {
+	checks []Check
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex
+	last *Report
+}
+
+// Create Checker
+func NewChecker(checks ...Check) *Checker {
+	return &Checker{checks: checks, ttl: cacheTTL, now: time.Now}
+}
+
+// Run checks or returns the cached report
+// Concurrent callers wait for the running checks instead of starting their own.
+func (c *Checker) Run(ctx context.Context) *Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.last != nil && c.now().Sub(c.last.CheckedAt) < c.ttl {
+		return c.last
+	}
+
+	report := &Report{Status: statusOK, Checks: make(map[string]Result, len(c.checks)), CheckedAt: c.now()}
+	results := make([]Result, len(c.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for i, check := range c.checks {
+		report.Checks[check.Name] = results[i]
+		if results[i].Status != statusOK {
+			report.Status = statusFail
+		}
+	}
+
+	c.last = report
+	return report
+}
+
+func run(ctx context.Context, check Check) (result Result) {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	defer func() {
+		if rec := recover(); rec != nil {
+			result = Result{Status: statusFail, Error: fmt.Sprintf("panic: %v", rec)}
+		}
+		result.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+	}()
+
+	if err := check.Func(ctx); err != nil {
+		return Result{Status: statusFail, Error: err.Error()}
+	}
+	return Result{Status: statusOK}
+}
+
+// Database checks connection to DB
+func Database(d dao.Dao) Check {
+	return Check{Name: "database", Func: d.Ping}
+}
+
+// Schema checks the applied schema is what the application expects
+func Schema(d dao.Dao) Check {
+	return Check{Name: "schema", Func: func(ctx context.Context) error {
+		version, err := d.SchemaVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if version != dao.SchemaVersion {
+			return fmt.Errorf("schema version is %d, want %d", version, dao.SchemaVersion)
+		}
+		return nil
+	}}
+}
+
+// Storage checks a file can be written into the directory returned by dir
+func Storage(dir func() string) Check {
+	return Check{Name: "storage", Func: func(ctx context.Context) error {
+		d := dir()
+		if err := os.MkdirAll(d, os.ModePerm); err != nil {
+			return err
+		}
+		f, err := ioutil.TempFile(d, ".health-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+
+		if _, err := f.Write([]byte("ok")); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}}
+}
@@ -0,0 +1,92 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecker_Run(t *testing.T) {
+	cases := map[string]struct {
+		checks     []Check
+		wantStatus string
+		wantErrors map[string]string
+	}{
+		"all ok": {
+			checks: []Check{
+				{Name: "a", Func: func(ctx context.Context) error { return nil }},
+				{Name: "b", Func: func(ctx context.Context) error { return nil }},
+			},
+			wantStatus: statusOK,
+			wantErrors: map[string]string{"a": "", "b": ""},
+		},
+		"one fails": {
+			checks: []Check{
+				{Name: "a", Func: func(ctx context.Context) error { return nil }},
+				{Name: "b", Func: func(ctx context.Context) error { return errors.New("down") }},
+			},
+			wantStatus: statusFail,
+			wantErrors: map[string]string{"a": "", "b": "down"},
+		},
+		"panic": {
+			checks: []Check{
+				{Name: "a", Func: func(ctx context.Context) error { panic("boom") }},
+			},
+			wantStatus: statusFail,
+			wantErrors: map[string]string{"a": "panic: boom"},
+		},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			report := NewChecker(tt.checks...).Run(context.Background())
+
+			assert.Equal(t, tt.wantStatus, report.Status)
+			for name, want := range tt.wantErrors {
+				assert.Equal(t, want, report.Checks[name].Error, name)
+			}
+		})
+	}
+}
+
+func TestChecker_Cache(t *testing.T) {
+	var calls int32
+	c := NewChecker(Check{Name: "slow", Func: func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}})
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Run(context.Background())
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "concurrent callers share the result")
+
+	now = now.Add(cacheTTL)
+	c.Run(context.Background())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "expired result is checked again")
+}
+
+func TestStorage(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, Storage(func() string { return dir }).Func(context.Background()))
+
+	file := dir + "/file"
+	assert.NoError(t, ioutil.WriteFile(file, nil, 0o600))
+	assert.Error(t, Storage(func() string { return file + "/sub" }).Func(context.Background()))
+}
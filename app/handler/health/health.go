@@ -1,16 +1,92 @@
 package health
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
+	"time"
+	"yatter-backend-go/app/app"
+	"yatter-backend-go/app/handler/httperror"
+
+	"github.com/go-chi/chi"
 )
 
-// Handle health check request
-func NewRouter() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/plain")
-		_, err := w.Write([]byte("OK"))
-		if err != nil {
+// Implementation of handler
+type handler struct {
+	app     *app.App
+	checker *Checker
+}
+
+// Create Handler for `/v1/health`
+func NewRouter(app *app.App) http.Handler {
+	r := chi.NewRouter()
+
+	h := &handler{
+		app: app,
+		checker: NewChecker(
+			Database(app.Dao),
+			Schema(app.Dao),
+			Storage(func() string { return app.Config.Get().Media.FilesDir }),
+		),
+	}
+
+	r.Get("/", h.Health)
+	r.Get("/live", h.Live)
+	r.Get("/ready", h.Ready)
+
+	return r
+}
+
+// Handle request for `GET /v1/health`, which is kept for existing health checks
+// It fails while the server is draining connections.
+func (h *handler) Health(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	if !h.app.Lifecycle.Ready() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		if _, err := w.Write([]byte("draining")); err != nil {
 			panic(err)
 		}
+		return
+	}
+	_, err := w.Write([]byte("OK"))
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Handle request for `GET /v1/health/live`
+// It only tells the process is serving, dependencies are not checked.
+func (h *handler) Live(w http.ResponseWriter, r *http.Request) {
+	h.write(w, r, http.StatusOK, &Report{Status: statusOK, Checks: map[string]Result{}, CheckedAt: time.Now()})
+}
+
+// Handle request for `GET /v1/health/ready`
+func (h *handler) Ready(w http.ResponseWriter, r *http.Request) {
+	if !h.app.Lifecycle.Ready() {
+		h.write(w, r, http.StatusServiceUnavailable, &Report{
+			Status:    statusFail,
+			Checks:    map[string]Result{"server": {Status: statusFail, Error: "draining"}},
+			CheckedAt: time.Now(),
+		})
+		return
+	}
+
+	// the report is shared by callers, so canceling a request must not fail it
+	report := h.checker.Run(context.Background())
+
+	code := http.StatusOK
+	if !report.OK() {
+		code = http.StatusServiceUnavailable
+	}
+	h.write(w, r, code, report)
+}
+
+func (h *handler) write(w http.ResponseWriter, r *http.Request, code int, report *Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
 	}
 }
@@ -1,23 +1,106 @@
 package httperror
 
 import (
-	"log"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"yatter-backend-go/app/domain/repository"
+	"yatter-backend-go/app/logger"
 )
 
+// Response is the envelope of errors
+type Response struct {
+	// Machine readable error, e.g. "not_found"
+	Error string `json:"error"`
+
+	// Human readable description
+	Description string `json:"error_description,omitempty"`
+
+	// Problems of each field of the request
+	Details map[string][]Detail `json:"details,omitempty"`
+}
+
+// Detail is a problem of a field
+type Detail struct {
+	Error       string `json:"error"`
+	Description string `json:"description"`
+}
+
 // Response with given status code
 func Error(w http.ResponseWriter, code int) {
-	http.Error(w, http.StatusText(code), code)
+	write(w, code, &Response{Error: codeOf(code), Description: http.StatusText(code)})
+}
+
+// Response with given status code and error
+func Status(w http.ResponseWriter, code int, err error) {
+	write(w, code, &Response{Error: codeOf(code), Description: err.Error()})
 }
 
 // Response with Bad Request (400)
 func BadRequest(w http.ResponseWriter, err error) {
-	http.Error(w, err.Error(), http.StatusBadRequest)
+	Status(w, http.StatusBadRequest, err)
+}
+
+// Response with Unprocessable Entity (422)
+func UnprocessableEntity(w http.ResponseWriter, err error) {
+	Status(w, http.StatusUnprocessableEntity, err)
 }
 
 // Response with Internal Server Error (500)
-func InternalServerError(w http.ResponseWriter, err error) {
-	log.Printf("[InternalServerError] %+v", err)
+func InternalServerError(w http.ResponseWriter, r *http.Request, err error) {
+	logger.FromContext(r.Context()).Error("internal server error", "error", fmt.Sprintf("%+v", err))
 
 	Error(w, http.StatusInternalServerError)
 }
+
+// Respond converts domain errors to the response, unknown errors are Internal Server Error
+func Respond(w http.ResponseWriter, r *http.Request, err error) {
+	var (
+		notFound   *repository.NotFoundError
+		conflict   *repository.ConflictError
+		forbidden  *repository.ForbiddenError
+		validation *repository.ValidationError
+	)
+
+	switch {
+	case errors.As(err, &notFound):
+		Status(w, http.StatusNotFound, notFound)
+	case errors.As(err, &conflict):
+		res := &Response{Error: codeOf(http.StatusConflict), Description: conflict.Message}
+		if conflict.Field != "" {
+			res.Details = map[string][]Detail{conflict.Field: {{Error: "ERR_TAKEN", Description: conflict.Message}}}
+		}
+		write(w, http.StatusConflict, res)
+	case errors.As(err, &forbidden):
+		Status(w, http.StatusForbidden, forbidden)
+	case errors.As(err, &validation):
+		res := &Response{Error: "validation_failed", Description: validation.Error(), Details: map[string][]Detail{}}
+		for _, f := range validation.Fields {
+			res.Details[f.Field] = append(res.Details[f.Field], Detail{Error: f.Code, Description: f.Message})
+		}
+		write(w, http.StatusBadRequest, res)
+	case errors.Is(err, repository.ErrAttachmentProcessing):
+		UnprocessableEntity(w, err)
+	default:
+		InternalServerError(w, r, err)
+	}
+}
+
+// codeOf returns snake case of the status text, e.g. "not_found"
+func codeOf(code int) string {
+	text := http.StatusText(code)
+	if text == "" {
+		return "error"
+	}
+	return strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(text, "-", "_"), " ", "_"))
+}
+
+func write(w http.ResponseWriter, code int, res *Response) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	// the status is already written, nothing can be done on failure
+	_ = json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,93 @@
+package httperror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"yatter-backend-go/app/domain/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRespond(t *testing.T) {
+	validation := &repository.ValidationError{}
+	validation.Add("username", "ERR_BLANK", "can't be blank")
+	validation.Add("username", "ERR_LENGTH", "is too short")
+
+	tests := map[string]struct {
+		err     error
+		status  int
+		code    string
+		details map[string][]Detail
+	}{
+		"not found": {
+			err:    repository.NewNotFound("status"),
+			status: http.StatusNotFound,
+			code:   "not_found",
+		},
+		"wrapped not found": {
+			err:    fmt.Errorf("delete: %w", repository.NewNotFound("status")),
+			status: http.StatusNotFound,
+			code:   "not_found",
+		},
+		"conflict": {
+			err:    repository.NewConflict("account", "username", "username is already taken"),
+			status: http.StatusConflict,
+			code:   "conflict",
+			details: map[string][]Detail{
+				"username": {{Error: "ERR_TAKEN", Description: "username is already taken"}},
+			},
+		},
+		"forbidden": {
+			err:    repository.NewForbidden("not allowed"),
+			status: http.StatusForbidden,
+			code:   "forbidden",
+		},
+		"validation": {
+			err:    validation,
+			status: http.StatusBadRequest,
+			code:   "validation_failed",
+			details: map[string][]Detail{
+				"username": {
+					{Error: "ERR_BLANK", Description: "can't be blank"},
+					{Error: "ERR_LENGTH", Description: "is too short"},
+				},
+			},
+		},
+		"attachment processing": {
+			err:    repository.ErrAttachmentProcessing,
+			status: http.StatusUnprocessableEntity,
+			code:   "unprocessable_entity",
+		},
+		"unknown": {
+			err:    errors.New("connection refused"),
+			status: http.StatusInternalServerError,
+			code:   "internal_server_error",
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+			Respond(w, r, tt.err)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+
+			var got Response
+			assert.Nil(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.Equal(t, tt.code, got.Error)
+			assert.Equal(t, tt.details, got.Details)
+			if tt.status == http.StatusInternalServerError {
+				// internal errors are not exposed
+				assert.NotContains(t, got.Description, "connection refused")
+			}
+		})
+	}
+}
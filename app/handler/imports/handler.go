@@ -0,0 +1,140 @@
+package imports
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+	"yatter-backend-go/app/handler/auth"
+	"yatter-backend-go/app/handler/httperror"
+	"yatter-backend-go/app/handler/request"
+)
+
+const (
+	// Max size of an uploaded CSV file
+	maxImportSize = 2 << 20
+
+	// Max number of rows in an import
+	maxImportRows = 20000
+
+	// Header of the first column in the Mastodon export format
+	accountAddressHeader = "Account address"
+)
+
+// Create handles request for `POST /v1/imports`
+// Form fields
+//   - type: one of "following", "blocking", "muting"
+//   - data: CSV file in the Mastodon export format, account addresses in the first column
+//
+// Addresses of this server are applied without the domain, other remote accounts are reported as failures.
+func (h *handler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize+(64<<10))
+	if err := r.ParseMultipartForm(maxImportSize); err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	importType := r.FormValue("type")
+	switch importType {
+	case object.ImportFollowing, object.ImportBlocking, object.ImportMuting:
+	default:
+		httperror.Respond(w, r, repository.NewValidation("type", "ERR_INVALID", "is not included in the list"))
+		return
+	}
+
+	file, _, err := r.FormFile("data")
+	if err != nil {
+		httperror.Respond(w, r, repository.NewValidation("data", "ERR_BLANK", "can't be blank"))
+		return
+	}
+	defer file.Close()
+
+	accts, err := parseAccounts(file)
+	if err != nil {
+		httperror.Respond(w, r, repository.NewValidation("data", "ERR_INVALID", err.Error()))
+		return
+	}
+
+	data, err := json.Marshal(accts)
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+
+	imp, err := h.app.Dao.AccountImport().Create(ctx, auth.AccountOf(r).ID, importType, string(data), len(accts))
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(imp); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// Get handles request for `GET /v1/imports/{id}`
+func (h *handler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := request.IDOf(r)
+	if err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+
+	imp, err := h.app.Dao.AccountImport().FindByID(r.Context(), id)
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+	if imp == nil || imp.AccountID != auth.AccountOf(r).ID {
+		httperror.Status(w, http.StatusNotFound, errors.New("import not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(imp); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// parseAccounts reads account addresses from the first column, the header row is optional
+func parseAccounts(file io.Reader) ([]string, error) {
+	c := csv.NewReader(file)
+	c.FieldsPerRecord = -1
+	c.TrimLeadingSpace = true
+
+	accts := []string{}
+	for first := true; ; first = false {
+		record, err := c.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("is not a valid CSV: %w", err)
+		}
+
+		acct := strings.TrimSpace(record[0])
+		if acct == "" || (first && acct == accountAddressHeader) {
+			continue
+		}
+		if len(accts) == maxImportRows {
+			return nil, fmt.Errorf("has more than %d rows", maxImportRows)
+		}
+		accts = append(accts, acct)
+	}
+
+	if len(accts) == 0 {
+		return nil, errors.New("has no rows")
+	}
+	return accts, nil
+}
@@ -0,0 +1,139 @@
+package imports
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"yatter-backend-go/app/app"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/mock"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/handler/auth"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+func newImportRequest(t *testing.T, importType, data string) *http.Request {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	if importType != "" {
+		if err := mw.WriteField("type", importType); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if data != "" {
+		f, err := mw.CreateFormFile("data", "following_accounts.csv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", &buf)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return auth.SetAccount(r, &object.Account{ID: 1, Username: "john"})
+}
+
+func TestImport_Create(t *testing.T) {
+	const mastodon = "Account address,Show boosts,Notify on new posts,Languages\n" +
+		"jane,true,false,\n" +
+		"bob@example.com,true,false,\n"
+
+	cases := map[string]struct {
+		importType string
+		data       string
+		status     int
+		accts      []string
+	}{
+		"mastodon format":  {"following", mastodon, http.StatusAccepted, []string{"jane", "bob@example.com"}},
+		"without header":   {"following", "jane\n\n@bob\n", http.StatusAccepted, []string{"jane", "@bob"}},
+		"blocking":         {"blocking", mastodon, http.StatusAccepted, []string{"jane", "bob@example.com"}},
+		"muting":           {"muting", "jane\n", http.StatusAccepted, []string{"jane"}},
+		"unknown type":     {"friends", mastodon, http.StatusBadRequest, nil},
+		"missing file":     {"following", "", http.StatusBadRequest, nil},
+		"header only":      {"following", "Account address\n", http.StatusBadRequest, nil},
+		"malformed csv":    {"following", "\"jane\n", http.StatusBadRequest, nil},
+		"too many rows":    {"following", strings.Repeat("jane\n", maxImportRows+1), http.StatusBadRequest, nil},
+		"exactly max rows": {"following", strings.Repeat("jane\n", maxImportRows), http.StatusAccepted, nil},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			var created []string
+			d := dao.NewMock(nil, nil, nil)
+			d.AccountImportMock = &mock.AccountImportMock{
+				CreateFunc: func(ctx context.Context, accountID int64, importType, data string, totalRows int) (*object.AccountImport, error) {
+					assert.NoError(t, json.Unmarshal([]byte(data), &created))
+					assert.Equal(t, len(created), totalRows)
+					return &object.AccountImport{ID: 1, AccountID: accountID, Type: importType, State: object.ImportPending, TotalRows: totalRows}, nil
+				},
+			}
+			h := &handler{app: &app.App{Dao: d}}
+
+			w := httptest.NewRecorder()
+			h.Create(w, newImportRequest(t, tt.importType, tt.data))
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.accts != nil {
+				assert.Equal(t, tt.accts, created)
+			}
+		})
+	}
+}
+
+func TestImport_Get(t *testing.T) {
+	imports := map[int64]*object.AccountImport{
+		1: {ID: 1, AccountID: 1, Failures: []object.ImportFailure{{Row: 2, Acct: "nobody", Reason: "account not found"}}},
+		2: {ID: 2, AccountID: 2},
+	}
+	d := dao.NewMock(nil, nil, nil)
+	d.AccountImportMock = &mock.AccountImportMock{
+		FindByIDFunc: func(ctx context.Context, id int64) (*object.AccountImport, error) {
+			return imports[id], nil
+		},
+	}
+	h := &handler{app: &app.App{Dao: d}}
+
+	cases := map[string]struct {
+		id     string
+		status int
+	}{
+		"own":              {"1", http.StatusOK},
+		"of other account": {"2", http.StatusNotFound},
+		"unknown":          {"3", http.StatusNotFound},
+		"invalid id":       {"x", http.StatusBadRequest},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.id)
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+			r = auth.SetAccount(r, &object.Account{ID: 1})
+
+			w := httptest.NewRecorder()
+			h.Get(w, r)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				var got object.AccountImport
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				assert.Equal(t, imports[1].Failures, got.Failures)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package imports
+
+import (
+	"net/http"
+	"time"
+	"yatter-backend-go/app/app"
+	"yatter-backend-go/app/handler/auth"
+	"yatter-backend-go/app/ratelimit"
+
+	"github.com/go-chi/chi"
+)
+
+type handler struct {
+	app *app.App
+}
+
+// Create Handler for `/v1/imports/`
+func NewRouter(app *app.App) http.Handler {
+	r := chi.NewRouter()
+
+	h := &handler{app: app}
+
+	r.Use(auth.BasicAuth(h.app))
+	r.With(app.RateLimit.Limit(ratelimit.Policy{
+		Name: "imports.create", Limit: 10, Window: time.Hour, Key: auth.ByAccount,
+	})).Post("/", h.Create)
+	r.Get("/{id}", h.Get)
+
+	return r
+}
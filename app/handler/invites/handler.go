@@ -0,0 +1,183 @@
+package invites
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"time"
+	"yatter-backend-go/app/config"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+	"yatter-backend-go/app/handler/auth"
+	"yatter-backend-go/app/handler/httperror"
+	"yatter-backend-go/app/handler/request"
+)
+
+const (
+	// Max uses of an invite, unlimited invites are allowed without max_uses
+	maxInviteUses = 100
+
+	// Max lifetime of an invite, invites without expires_in never expire
+	maxInviteLifetime = 30 * 24 * time.Hour
+
+	// Characters of invite codes, without ones easily confused
+	codeAlphabet = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+	// Length of invite codes
+	codeLength = 10
+)
+
+// Handle request for `POST /v1/invites`
+// Request body
+type CreateRequest struct {
+	// Optional, how many times the invite can be used
+	MaxUses *int `json:"max_uses"`
+
+	// Optional, seconds until the invite expires
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+// Create handles request for `POST /v1/invites`
+func (h *handler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if h.app.Config.Get().Registration.Mode == config.RegistrationClosed {
+		httperror.Respond(w, r, repository.NewForbidden("registration is closed"))
+		return
+	}
+
+	var req CreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+
+	v := &repository.ValidationError{}
+	if req.MaxUses != nil && (*req.MaxUses < 1 || *req.MaxUses > maxInviteUses) {
+		v.Add("max_uses", "ERR_INVALID", fmt.Sprintf("must be between 1 and %d", maxInviteUses))
+	}
+	if maxExpiresIn := int64(maxInviteLifetime / time.Second); req.ExpiresIn < 0 || req.ExpiresIn > maxExpiresIn {
+		v.Add("expires_in", "ERR_INVALID", fmt.Sprintf("must be between 0 and %d", maxExpiresIn))
+	}
+	if err := v.Err(); err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	code, err := generateCode()
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+	invite := &object.Invite{
+		AccountID: auth.AccountOf(r).ID,
+		Code:      code,
+		MaxUses:   req.MaxUses,
+	}
+	if req.ExpiresIn > 0 {
+		invite.ExpiresAt = &object.DateTime{Time: time.Now().Add(time.Duration(req.ExpiresIn) * time.Second)}
+	}
+
+	repo := h.app.Dao.Invite()
+	id, err := repo.Create(ctx, invite)
+	if err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	h.respondInvite(w, r, id)
+}
+
+// List handles request for `GET /v1/invites`
+func (h *handler) List(w http.ResponseWriter, r *http.Request) {
+	const (
+		maxID   = "max_id"
+		sinceID = "since_id"
+		limit   = "limit"
+	)
+
+	options := []request.Option{
+		{Name: maxID, DefaultValue: 0, MinValue: 1, MaxValue: math.MaxInt64},
+		{Name: sinceID, DefaultValue: 0, MinValue: 1, MaxValue: math.MaxInt64},
+		{Name: limit, DefaultValue: 40, MinValue: 0, MaxValue: 80},
+	}
+	params, err := request.GetOptionParams(r, options)
+	if err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+
+	invites, err := h.app.Dao.Invite().ListByAccountID(r.Context(), auth.AccountOf(r).ID, params[maxID], params[sinceID], params[limit])
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(invites); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// Expire handles request for `DELETE /v1/invites/{id}`
+func (h *handler) Expire(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := request.IDOf(r)
+	if err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+
+	repo := h.app.Dao.Invite()
+	invite, err := repo.FindByID(ctx, id)
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+	if invite == nil || invite.AccountID != auth.AccountOf(r).ID {
+		httperror.Status(w, http.StatusNotFound, errors.New("invite not found"))
+		return
+	}
+
+	if err := repo.Expire(ctx, id); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+
+	h.respondInvite(w, r, id)
+}
+
+// respondInvite writes the invite
+func (h *handler) respondInvite(w http.ResponseWriter, r *http.Request, id int64) {
+	invite, err := h.app.Dao.Invite().FindByID(r.Context(), id)
+	if err != nil || invite == nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(invite); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// generateCode returns a random invite code
+func generateCode() (string, error) {
+	b := make([]byte, codeLength)
+	max := big.NewInt(int64(len(codeAlphabet)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = codeAlphabet[n.Int64()]
+	}
+	return string(b), nil
+}
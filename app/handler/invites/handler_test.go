@@ -0,0 +1,109 @@
+package invites
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"yatter-backend-go/app/app"
+	"yatter-backend-go/app/config"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/mock"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/handler/auth"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupInvites(mode string) (*handler, map[int64]*object.Invite) {
+	invites := map[int64]*object.Invite{
+		1: {ID: 1, AccountID: 2, Code: "othersCode"},
+	}
+	d := dao.NewMock(nil, nil, nil)
+	d.InviteMock = &mock.InviteMock{
+		CreateFunc: func(ctx context.Context, invite *object.Invite) (int64, error) {
+			invite.ID = int64(len(invites) + 1)
+			invites[invite.ID] = invite
+			return invite.ID, nil
+		},
+		FindByIDFunc: func(ctx context.Context, id int64) (*object.Invite, error) {
+			return invites[id], nil
+		},
+		ExpireFunc: func(ctx context.Context, id int64) error {
+			invites[id].ExpiresAt = &object.DateTime{Time: time.Now()}
+			return nil
+		},
+	}
+	cfg := config.Default()
+	cfg.Registration.Mode = mode
+	return &handler{app: &app.App{Dao: d, Config: config.NewStore("", cfg)}}, invites
+}
+
+func TestInvite_Create(t *testing.T) {
+	cases := map[string]struct {
+		mode   string
+		body   string
+		status int
+	}{
+		"unlimited":         {config.RegistrationInvite, `{}`, http.StatusOK},
+		"limited":           {config.RegistrationInvite, `{"max_uses":5,"expires_in":86400}`, http.StatusOK},
+		"too many uses":     {config.RegistrationInvite, `{"max_uses":101}`, http.StatusBadRequest},
+		"zero uses":         {config.RegistrationInvite, `{"max_uses":0}`, http.StatusBadRequest},
+		"too long lifetime": {config.RegistrationInvite, `{"expires_in":2592001}`, http.StatusBadRequest},
+		"closed":            {config.RegistrationClosed, `{}`, http.StatusForbidden},
+		"approval":          {config.RegistrationApproval, `{}`, http.StatusOK},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			h, _ := setupInvites(tt.mode)
+
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			r = auth.SetAccount(r, &object.Account{ID: 1})
+			w := httptest.NewRecorder()
+			h.Create(w, r)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				var got object.Invite
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				assert.Len(t, got.Code, codeLength)
+			}
+		})
+	}
+}
+
+func TestInvite_Expire(t *testing.T) {
+	cases := map[string]struct {
+		id     string
+		status int
+	}{
+		"own":      {"2", http.StatusOK},
+		"of other": {"1", http.StatusNotFound},
+		"unknown":  {"9", http.StatusNotFound},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			h, invites := setupInvites(config.RegistrationInvite)
+			invites[2] = &object.Invite{ID: 2, AccountID: 1, Code: "ownCode"}
+
+			r := httptest.NewRequest(http.MethodDelete, "/", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.id)
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+			r = auth.SetAccount(r, &object.Account{ID: 1})
+			w := httptest.NewRecorder()
+			h.Expire(w, r)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Nil(t, invites[1].ExpiresAt)
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package invites
+
+import (
+	"net/http"
+	"time"
+	"yatter-backend-go/app/app"
+	"yatter-backend-go/app/handler/auth"
+	"yatter-backend-go/app/ratelimit"
+
+	"github.com/go-chi/chi"
+)
+
+type handler struct {
+	app *app.App
+}
+
+// Create Handler for `/v1/invites/`
+func NewRouter(app *app.App) http.Handler {
+	r := chi.NewRouter()
+
+	h := &handler{app: app}
+
+	r.Use(auth.BasicAuth(h.app))
+	r.With(app.RateLimit.Limit(ratelimit.Policy{
+		Name: "invites.create", Limit: 20, Window: 24 * time.Hour, Key: auth.ByAccount,
+	})).Post("/", h.Create)
+	r.Get("/", h.List)
+	r.Delete("/{id}", h.Expire)
+
+	return r
+}
@@ -0,0 +1,108 @@
+package media
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"yatter-backend-go/app/handler/httperror"
+
+	"github.com/go-chi/chi"
+)
+
+const (
+	// Content-addressed files never change
+	immutableCacheControl = "public, max-age=31536000, immutable"
+
+	// Other files, including previews and transcodes derived from blobs, are revalidated by ETag
+	revalidateCacheControl = "public, no-cache"
+)
+
+// Name of content-addressed files
+// Files derived from them are named `<hash>_<suffix>.<ext>` and can be regenerated with other settings, so they don't match.
+var contentAddressed = regexp.MustCompile(`^([0-9a-f]{64})\.[0-9a-z]+$`)
+
+// fileServer serves files in root with caching headers
+// Range and conditional requests are handled by http.ServeContent
+type fileServer struct {
+	root http.FileSystem
+
+	// ETags of files which are not content-addressed, hashed from their own content
+	mu    sync.Mutex
+	etags map[string]etagEntry
+}
+
+type etagEntry struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+func newFileServer(root http.FileSystem) *fileServer {
+	return &fileServer{root: root, etags: make(map[string]etagEntry)}
+}
+
+func (s *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := path.Clean("/" + chi.URLParam(r, "*"))
+
+	f, err := s.root.Open(name)
+	if err != nil {
+		httperror.Error(w, http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+		// directories are not listed, and temporary files are not served
+		httperror.Error(w, http.StatusNotFound)
+		return
+	}
+
+	if m := contentAddressed.FindStringSubmatch(info.Name()); m != nil {
+		w.Header().Set("ETag", fmt.Sprintf(`"%s%s"`, m[1], path.Ext(info.Name())))
+		w.Header().Set("Cache-Control", immutableCacheControl)
+	} else {
+		etag, err := s.etag(name, info.ModTime(), info.Size(), f)
+		if err != nil {
+			httperror.InternalServerError(w, r, err)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", revalidateCacheControl)
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
+
+// etag hashes the file once and caches it until the file changes
+func (s *fileServer) etag(name string, modTime time.Time, size int64, f http.File) (string, error) {
+	s.mu.Lock()
+	e, ok := s.etags[name]
+	s.mu.Unlock()
+	if ok && e.modTime.Equal(modTime) && e.size == size {
+		return e.etag, nil
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := `"` + hex.EncodeToString(h.Sum(nil)) + `"`
+
+	s.mu.Lock()
+	s.etags[name] = etagEntry{modTime: modTime, size: size, etag: etag}
+	s.mu.Unlock()
+
+	return etag, nil
+}
@@ -0,0 +1,160 @@
+package media
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"yatter-backend-go/app/app"
+	"yatter-backend-go/app/config"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileServer(t *testing.T) {
+	t.Parallel()
+
+	const hash = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	const content = "0123456789"
+	sum := sha256.Sum256([]byte(content))
+
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "01", "23"), os.ModePerm))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "01", "23", hash+".mp4"), []byte(content), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "01", "23", hash+"_small.webp"), []byte("preview"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "legacy.png"), []byte(content), 0644))
+	preview := sha256.Sum256([]byte("preview"))
+
+	r := chi.NewRouter()
+	FileServer(r, "/files", http.Dir(dir))
+
+	type want struct {
+		status       int
+		etag         string
+		cacheControl string
+		body         string
+	}
+	cases := map[string]struct {
+		path    string
+		headers map[string]string
+		want    want
+	}{
+		"content-addressed": {
+			path: "/files/01/23/" + hash + ".mp4",
+			want: want{http.StatusOK, `"` + hash + `.mp4"`, immutableCacheControl, content},
+		},
+		"not modified": {
+			path:    "/files/01/23/" + hash + ".mp4",
+			headers: map[string]string{"If-None-Match": `"` + hash + `.mp4"`},
+			want:    want{http.StatusNotModified, `"` + hash + `.mp4"`, immutableCacheControl, ""},
+		},
+		"range": {
+			path:    "/files/01/23/" + hash + ".mp4",
+			headers: map[string]string{"Range": "bytes=2-5"},
+			want:    want{http.StatusPartialContent, `"` + hash + `.mp4"`, immutableCacheControl, "2345"},
+		},
+		"range with stale If-Range": {
+			path:    "/files/01/23/" + hash + ".mp4",
+			headers: map[string]string{"Range": "bytes=2-5", "If-Range": `"stale"`},
+			want:    want{http.StatusOK, `"` + hash + `.mp4"`, immutableCacheControl, content},
+		},
+		"derived file": {
+			path: "/files/01/23/" + hash + "_small.webp",
+			want: want{http.StatusOK, `"` + hex.EncodeToString(preview[:]) + `"`, revalidateCacheControl, "preview"},
+		},
+		"legacy file": {
+			path: "/files/legacy.png",
+			want: want{http.StatusOK, `"` + hex.EncodeToString(sum[:]) + `"`, revalidateCacheControl, content},
+		},
+		"directory": {
+			path: "/files/01/",
+			want: want{status: http.StatusNotFound},
+		},
+		"not found": {
+			path: "/files/none.png",
+			want: want{status: http.StatusNotFound},
+		},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want.status, w.Code)
+			if tt.want.status == http.StatusNotFound {
+				return
+			}
+			assert.Equal(t, tt.want.etag, w.Header().Get("ETag"))
+			assert.Equal(t, tt.want.cacheControl, w.Header().Get("Cache-Control"))
+			assert.Equal(t, tt.want.body, w.Body.String())
+		})
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1600000000, 0)
+	signer := NewSigner([]byte("secret"))
+	signer.now = func() time.Time { return now }
+
+	const path = "/v1/media/files/a.png"
+	cases := map[string]struct {
+		url     string
+		private bool
+		want    int
+	}{
+		"unsigned public":  {path, false, http.StatusOK},
+		"unsigned private": {path, true, http.StatusForbidden},
+		"signed":           {signer.Sign(path, now.Add(time.Minute)), true, http.StatusOK},
+		"expired":          {signer.Sign(path, now.Add(-time.Minute)), true, http.StatusForbidden},
+		"other path":       {"/v1/media/files/b.png" + signer.Sign(path, now.Add(time.Minute))[len(path):], false, http.StatusForbidden},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			h := VerifySignature(signer, func(r *http.Request) bool { return tt.private })(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestSignedMediaFeature(t *testing.T) {
+	t.Parallel()
+
+	const url = "/v1/media/files/a.png?expires=1&signature=forged"
+	for _, enabled := range []bool{false, true} {
+		cfg := config.Default()
+		cfg.Media.SigningKey = "secret"
+		cfg.Features.SignedMedia = enabled
+		h := &handler{app: &app.App{Config: config.NewStore("", cfg)}}
+
+		w := httptest.NewRecorder()
+		h.verifySignature(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).
+			ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+
+		want := http.StatusOK
+		if enabled {
+			want = http.StatusForbidden
+		}
+		assert.Equal(t, want, w.Code, "signed_media: %v", enabled)
+	}
+}
@@ -4,12 +4,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"strings"
 	"unicode/utf8"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/handler/auth"
 	"yatter-backend-go/app/handler/httperror"
 	"yatter-backend-go/app/handler/request"
+	"yatter-backend-go/app/logger"
+	"yatter-backend-go/app/mediatype"
+	"yatter-backend-go/app/metrics"
 
 	"github.com/go-chi/chi"
 )
@@ -20,44 +24,135 @@ const maxDescriptionLength = 420
 func (h *handler) Upload(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	account := auth.AccountOf(r)
 	repo := h.app.Dao.Attachment()
+	cfg := h.app.Config.Get()
 
-	description := r.FormValue("description")
-	if utf8.RuneCountInString(description) > maxDescriptionLength {
-		httperror.BadRequest(w, fmt.Errorf("description is too long, please less than or equal %d", maxDescriptionLength))
+	form, file, code, err := readUpload(r, mediatype.LimitsFromConfig(cfg.Media))
+	if err != nil {
+		httperror.Status(w, code, err)
 		return
 	}
+	defer form.RemoveAll()
+
+	// video and audio were probed while the upload was validated
+	var meta *object.AttachmentMeta
+	if file.Info != nil {
+		meta = &object.AttachmentMeta{}
+		mediatype.FillMeta(meta, file.Info)
+	}
 
-	file, fileHeader, err := r.FormFile("file")
+	attachment, err := repo.UploadFile(ctx, account.ID, file, cfg.Media.FilesDir, cfg.Server.PublicURL, file.Ext, file.Type, form.Value("description"), meta)
 	if err != nil {
-		httperror.BadRequest(w, errors.New("invalid file name in request body"))
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+	metrics.Uploaded(file.Type, file.Size)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(attachment); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// Handle request for `POST /v2/media`
+func (h *handler) UploadAsync(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	account := auth.AccountOf(r)
+	repo := h.app.Dao.Attachment()
+	cfg := h.app.Config.Get()
+	if !cfg.Features.AsyncMedia {
+		httperror.Error(w, http.StatusNotFound)
 		return
 	}
-	defer file.Close()
 
-	filetype, err := request.DetectAttachmentType(file)
+	form, file, code, err := readUpload(r, mediatype.LimitsFromConfig(cfg.Media))
 	if err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.Status(w, code, err)
 		return
 	}
+	defer form.RemoveAll()
 
-	attachment, err := repo.UploadFile(ctx, file, FilesDir, fileHeader.Filename, filetype, description)
+	attachment, err := repo.StoreFile(ctx, account.ID, file, cfg.Media.FilesDir, file.Ext, file.Type, form.Value("description"))
 	if err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
+	metrics.Uploaded(file.Type, file.Size)
 
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(attachment); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// Handle request for `GET /v1/media/{id}`
+func (h *handler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := request.IDOf(r)
+	if err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+
+	repo := h.app.Dao.Attachment()
+	attachment, err := repo.FindByID(ctx, id)
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	} else if attachment == nil {
+		httperror.Error(w, http.StatusNotFound)
+		return
+	}
+
+	switch attachment.State {
+	case object.AttachmentFailed:
+		httperror.UnprocessableEntity(w, errors.New("media processing failed"))
+		return
+	case object.AttachmentProcessing:
+		// Partial Content tells clients to poll again
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusPartialContent)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+	}
+
 	if err := json.NewEncoder(w).Encode(attachment); err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 }
 
+// readUpload reads the file and the description from the multipart form
+func readUpload(r *http.Request, limits mediatype.Limits) (*request.Form, *request.File, int, error) {
+	form, err := request.ParseUpload(r, limits)
+	if err != nil {
+		return nil, nil, request.UploadErrorCode(err), err
+	}
+
+	if utf8.RuneCountInString(form.Value("description")) > maxDescriptionLength {
+		form.RemoveAll()
+		return nil, nil, http.StatusBadRequest, fmt.Errorf("description is too long, please less than or equal %d", maxDescriptionLength)
+	}
+
+	file := form.File("file")
+	if file == nil {
+		form.RemoveAll()
+		return nil, nil, http.StatusBadRequest, errors.New("invalid file name in request body")
+	}
+
+	return form, file, http.StatusOK, nil
+}
+
 // Handle request for `GET /v1/media/files/{id}`
 func FileServer(r chi.Router, path string, root http.FileSystem) {
 	if strings.ContainsAny(path, "{}*") {
-		log.Println("FileServer does not permit URL parameters.")
+		logger.Default().Error("FileServer does not permit URL parameters", "path", path)
 		return
 	}
 
@@ -68,10 +163,5 @@ func FileServer(r chi.Router, path string, root http.FileSystem) {
 
 	path += "*"
 
-	r.Get(path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		rctx := chi.RouteContext(r.Context())
-		pathPrefix := strings.TrimSuffix(rctx.RoutePattern(), "/*")
-		fs := http.StripPrefix(pathPrefix, http.FileServer(root))
-		fs.ServeHTTP(w, r)
-	}))
+	r.Get(path, newFileServer(root).ServeHTTP)
 }
@@ -0,0 +1,57 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"yatter-backend-go/app/app"
+	"yatter-backend-go/app/config"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/mock"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/handler/auth"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadProbesAudio(t *testing.T) {
+	var (
+		stored *object.AttachmentMeta
+		owner  int64
+	)
+	d := dao.NewMock(nil, nil, &mock.AttachmentMock{
+		UploadFileFunc: func(ctx context.Context, accountID int64, file io.Reader, fileDir, baseURL, ext, filetype, description string, meta *object.AttachmentMeta) (*object.Attachment, error) {
+			stored, owner = meta, accountID
+			return &object.Attachment{ID: 1, Type: filetype, Meta: meta}, nil
+		},
+	})
+	h := &handler{app: &app.App{Dao: d, Config: config.NewStore("", config.Default())}}
+
+	// MPEG-1 Layer III, 128kbps, 44.1kHz: 417 bytes per frame
+	mp3 := bytes.Repeat(append([]byte{0xFF, 0xFB, 0x90, 0x00}, make([]byte, 413)...), 10)
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreateFormFile("file", "test.mp3")
+	assert.NoError(t, err)
+	_, err = fw.Write(mp3)
+	assert.NoError(t, err)
+	assert.NoError(t, mw.Close())
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/media", &buf)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	r = auth.SetAccount(r, &object.Account{ID: 7, Username: "john"})
+	w := httptest.NewRecorder()
+	h.Upload(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, int64(7), owner)
+	if assert.NotNil(t, stored) && assert.NotNil(t, stored.Original) {
+		assert.InDelta(t, 4170*8/128000.0, stored.Original.Duration, 0.001)
+		assert.Equal(t, int64(128000), stored.Original.Bitrate)
+	}
+}
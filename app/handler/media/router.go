@@ -2,9 +2,11 @@ package media
 
 import (
 	"net/http"
-	"os"
 	"path/filepath"
+	"time"
 	"yatter-backend-go/app/app"
+	"yatter-backend-go/app/handler/auth"
+	"yatter-backend-go/app/ratelimit"
 
 	"github.com/go-chi/chi"
 )
@@ -13,18 +15,33 @@ type handler struct {
 	app *app.App
 }
 
-const FilesDir = "files"
+// Budget of uploads shared by `/v1/media` and `/v2/media`
+var uploadPolicy = ratelimit.Policy{Name: "media.upload", Limit: 30, Window: 30 * time.Minute, Key: ratelimit.ByIP}
 
 func NewRouter(app *app.App) http.Handler {
 	r := chi.NewRouter()
 
 	h := &handler{app: app}
 
-	r.Post("/", h.Upload)
+	r.With(auth.BasicAuth(app), app.RateLimit.Limit(uploadPolicy)).Post("/", h.Upload)
+	r.Get("/{id}", h.Get)
 
-	workDir, _ := os.Getwd()
-	filesDir := http.Dir(filepath.Join(workDir, FilesDir))
-	FileServer(r, "/files", filesDir)
+	filesDir, _ := filepath.Abs(app.Config.Get().Media.FilesDir)
+	r.Group(func(r chi.Router) {
+		r.Use(h.verifySignature)
+		FileServer(r, "/files", http.Dir(filesDir))
+	})
+
+	return r
+}
+
+// Create router for `/v2/media`
+func NewRouterV2(app *app.App) http.Handler {
+	r := chi.NewRouter()
+
+	h := &handler{app: app}
+
+	r.With(auth.BasicAuth(app), app.RateLimit.Limit(uploadPolicy)).Post("/", h.UploadAsync)
 
 	return r
 }
@@ -0,0 +1,117 @@
+package reports
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"unicode/utf8"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+	"yatter-backend-go/app/handler/auth"
+	"yatter-backend-go/app/handler/httperror"
+)
+
+const (
+	// Max number of statuses in a report
+	maxReportStatuses = 20
+
+	// Max length of the comment in characters
+	maxCommentLength = 1000
+)
+
+// Handle request for `POST /v1/reports`
+// Request body
+type CreateRequest struct {
+	AccountID int64   `json:"account_id"`
+	StatusIDs []int64 `json:"status_ids"`
+	Comment   string  `json:"comment"`
+	// One of: "spam", "violation", "other", defaults to "other"
+	Category string `json:"category"`
+}
+
+// Create handles request for `POST /v1/reports`
+// The statuses are copied, so the report keeps them after the author deletes them.
+func (h *handler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req CreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.BadRequest(w, err)
+		return
+	}
+	if req.Category == "" {
+		req.Category = object.ReportOther
+	}
+
+	account := auth.AccountOf(r)
+	statusIDs := uniqueIDs(req.StatusIDs)
+
+	v := &repository.ValidationError{}
+	if req.AccountID == 0 {
+		v.Add("account_id", "ERR_BLANK", "can't be blank")
+	} else if req.AccountID == account.ID {
+		v.Add("account_id", "ERR_INVALID", "cannot report yourself")
+	}
+	if !object.ValidReportCategory(req.Category) {
+		v.Add("category", "ERR_INVALID", "is not included in the list")
+	}
+	if utf8.RuneCountInString(req.Comment) > maxCommentLength {
+		v.Add("comment", "ERR_LENGTH", fmt.Sprintf("is too long (maximum is %d)", maxCommentLength))
+	}
+	if len(statusIDs) > maxReportStatuses {
+		v.Add("status_ids", "ERR_LENGTH", fmt.Sprintf("is too long (maximum is %d)", maxReportStatuses))
+	}
+	if err := v.Err(); err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	target, err := h.app.Dao.Account().FindByID(ctx, req.AccountID)
+	if err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	} else if target == nil {
+		httperror.Respond(w, r, repository.NewNotFound("account"))
+		return
+	}
+
+	reportRepo := h.app.Dao.Report()
+	id, err := reportRepo.Create(ctx, &object.Report{
+		AccountID:       account.ID,
+		TargetAccountID: target.ID,
+		Category:        req.Category,
+		Comment:         req.Comment,
+	}, statusIDs)
+	if err != nil {
+		httperror.Respond(w, r, err)
+		return
+	}
+
+	report, err := reportRepo.FindByID(ctx, id)
+	if err != nil || report == nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+	// moderation of the report is not shown to the reporter
+	report.Notes = nil
+	report.History = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		httperror.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// uniqueIDs removes duplicated IDs keeping the order
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	unique := []int64{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
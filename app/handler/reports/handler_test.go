@@ -0,0 +1,84 @@
+package reports
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"yatter-backend-go/app/app"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/mock"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/handler/auth"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReport_Create(t *testing.T) {
+	cases := map[string]struct {
+		body      string
+		status    int
+		statusIDs []int64
+		category  string
+	}{
+		"with statuses":    {`{"account_id":2,"status_ids":[3,4,3],"comment":"spam","category":"spam"}`, http.StatusOK, []int64{3, 4}, object.ReportSpam},
+		"default category": {`{"account_id":2}`, http.StatusOK, []int64{}, object.ReportOther},
+		"yourself":         {`{"account_id":1}`, http.StatusBadRequest, nil, ""},
+		"missing account":  {`{"comment":"spam"}`, http.StatusBadRequest, nil, ""},
+		"unknown category": {`{"account_id":2,"category":"rude"}`, http.StatusBadRequest, nil, ""},
+		"long comment":     {`{"account_id":2,"comment":"` + strings.Repeat("あ", maxCommentLength+1) + `"}`, http.StatusBadRequest, nil, ""},
+		"unknown account":  {`{"account_id":9}`, http.StatusNotFound, nil, ""},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			var created *object.Report
+			var createdStatusIDs []int64
+			d := dao.NewMock(&mock.AccountMock{
+				FindByIDFunc: func(ctx context.Context, id int64) (*object.Account, error) {
+					if id == 2 {
+						return &object.Account{ID: 2, Username: "jane"}, nil
+					}
+					return nil, nil
+				},
+			}, nil, nil)
+			d.ReportMock = &mock.ReportMock{
+				CreateFunc: func(ctx context.Context, report *object.Report, statusIDs []int64) (int64, error) {
+					created = report
+					createdStatusIDs = statusIDs
+					return 1, nil
+				},
+				FindByIDFunc: func(ctx context.Context, id int64) (*object.Report, error) {
+					report := *created
+					report.ID = id
+					report.State = object.ReportOpen
+					report.History = []object.ReportHistory{{ID: 1}}
+					return &report, nil
+				},
+			}
+			h := &handler{app: &app.App{Dao: d}}
+
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			r = auth.SetAccount(r, &object.Account{ID: 1, Username: "john"})
+			w := httptest.NewRecorder()
+			h.Create(w, r)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status != http.StatusOK {
+				assert.Nil(t, created)
+				return
+			}
+			assert.Equal(t, int64(1), created.AccountID)
+			assert.Equal(t, int64(2), created.TargetAccountID)
+			assert.Equal(t, tt.category, created.Category)
+			assert.Equal(t, tt.statusIDs, createdStatusIDs)
+
+			var got map[string]interface{}
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.NotContains(t, got, "history")
+		})
+	}
+}
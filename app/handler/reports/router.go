@@ -0,0 +1,29 @@
+package reports
+
+import (
+	"net/http"
+	"time"
+	"yatter-backend-go/app/app"
+	"yatter-backend-go/app/handler/auth"
+	"yatter-backend-go/app/ratelimit"
+
+	"github.com/go-chi/chi"
+)
+
+type handler struct {
+	app *app.App
+}
+
+// Create Handler for `/v1/reports/`
+func NewRouter(app *app.App) http.Handler {
+	r := chi.NewRouter()
+
+	h := &handler{app: app}
+
+	r.Use(auth.BasicAuth(h.app))
+	r.With(app.RateLimit.Limit(ratelimit.Policy{
+		Name: "reports.create", Limit: 20, Window: time.Hour, Key: auth.ByAccount,
+	})).Post("/", h.Create)
+
+	return r
+}
@@ -0,0 +1,307 @@
+package request
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"yatter-backend-go/app/mediatype"
+	"yatter-backend-go/app/probe"
+
+	// register decoders for image.DecodeConfig
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+var (
+	// ErrTooLarge is returned when uploaded file exceeds the size limit
+	ErrTooLarge = errors.New("file is too large")
+
+	// ErrUnsupportedType is returned when uploaded file is not allowed
+	ErrUnsupportedType = errors.New("file type is not supported")
+
+	// ErrTooManyPixels is returned when uploaded image exceeds the dimension limit
+	ErrTooManyPixels = errors.New("image dimensions are too large")
+)
+
+const (
+	// Max size of each non-file field
+	maxValueSize = 64 << 10
+	// Max number of parts in a form
+	maxParts = 32
+)
+
+// File uploaded file spooled to a temporary file
+type File struct {
+	*os.File
+
+	// The name of file given by client
+	Filename string
+
+	// The sniffed MIME type
+	MIME string
+
+	// The attachment type
+	Type string
+
+	// The extension for the MIME type
+	Ext string
+
+	// The size of file in bytes
+	Size int64
+
+	// Stream information of video and audio, nil for images
+	Info *probe.Info
+}
+
+// Close and remove the temporary file
+func (f *File) Close() error {
+	err := f.File.Close()
+	os.Remove(f.File.Name())
+	return err
+}
+
+// Form multipart form read by ParseUpload
+type Form struct {
+	Values map[string]string
+	Files  map[string]*File
+}
+
+// Read form value
+func (f *Form) Value(name string) string {
+	return f.Values[name]
+}
+
+// Read form file, nil if not uploaded
+func (f *Form) File(name string) *File {
+	return f.Files[name]
+}
+
+// Remove all temporary files
+func (f *Form) RemoveAll() {
+	for _, file := range f.Files {
+		file.Close()
+	}
+}
+
+// ParseUpload streams the multipart form of request
+// Every file is validated while it is read, so that oversized files are not stored entirely
+func ParseUpload(r *http.Request, limits mediatype.Limits) (*Form, error) {
+	form := &Form{Values: map[string]string{}, Files: map[string]*File{}}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		if err := r.ParseForm(); err != nil {
+			return nil, err
+		}
+		for name := range r.PostForm {
+			form.Values[name] = r.PostForm.Get(name)
+		}
+		return form, nil
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; ; i++ {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			form.RemoveAll()
+			return nil, err
+		}
+		if i >= maxParts {
+			form.RemoveAll()
+			return nil, errors.New("too many parts in form")
+		}
+
+		name := part.FormName()
+		if part.FileName() == "" {
+			b, err := ioutil.ReadAll(io.LimitReader(part, maxValueSize+1))
+			if err != nil {
+				form.RemoveAll()
+				return nil, err
+			} else if len(b) > maxValueSize {
+				form.RemoveAll()
+				return nil, fmt.Errorf("%s is too long", name)
+			}
+			form.Values[name] = string(b)
+			continue
+		}
+
+		file, err := spool(part, limits)
+		if err != nil {
+			form.RemoveAll()
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if old, ok := form.Files[name]; ok {
+			old.Close()
+		}
+		form.Files[name] = file
+	}
+
+	return form, nil
+}
+
+func spool(part *multipart.Part, limits mediatype.Limits) (*File, error) {
+	head := make([]byte, mediatype.SniffLen)
+	n, err := io.ReadFull(part, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	head = head[:n]
+
+	mimeType := mediatype.DetectMIME(head)
+	filetype := mediatype.Classify(mimeType)
+	limit := limits.SizeOf(filetype)
+	if filetype == mediatype.Unknown || limit <= 0 {
+		return nil, ErrUnsupportedType
+	}
+	if int64(n) > limit {
+		return nil, ErrTooLarge
+	}
+
+	tmp, err := ioutil.TempFile("", "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	file := &File{File: tmp, Filename: part.FileName(), MIME: mimeType, Type: filetype, Ext: mediatype.Extension(mimeType)}
+
+	if _, err := tmp.Write(head); err != nil {
+		file.Close()
+		return nil, err
+	}
+	// read one more byte than remaining to detect oversized files
+	copied, err := io.Copy(tmp, io.LimitReader(part, limit-int64(n)+1))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	file.Size = int64(n) + copied
+	if file.Size > limit {
+		file.Close()
+		return nil, ErrTooLarge
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	switch filetype {
+	case mediatype.Image, mediatype.Gifv:
+		if err := checkDimensions(file, limits); err != nil {
+			file.Close()
+			return nil, err
+		}
+	case mediatype.Video, mediatype.Audio:
+		if err := checkContainer(file); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	return file, nil
+}
+
+func checkDimensions(file *File, limits mediatype.Limits) error {
+	var width, height int
+	if file.MIME == "image/webp" {
+		head := make([]byte, 30)
+		if _, err := io.ReadFull(file, head); err != nil {
+			return ErrUnsupportedType
+		}
+		w, h, ok := webpDimensions(head)
+		if !ok {
+			return ErrUnsupportedType
+		}
+		width, height = w, h
+	} else {
+		cfg, _, err := image.DecodeConfig(file)
+		if err != nil {
+			return ErrUnsupportedType
+		}
+		width, height = cfg.Width, cfg.Height
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if width > limits.MaxWidth || height > limits.MaxHeight {
+		return fmt.Errorf("%w: %dx%d exceeds %dx%d", ErrTooManyPixels, width, height, limits.MaxWidth, limits.MaxHeight)
+	}
+	return nil
+}
+
+// checkContainer probes the container to verify it matches the sniffed type
+// The stream information is kept in file.Info
+func checkContainer(file *File) error {
+	info, err := probe.Probe(file, file.MIME)
+	if err != nil {
+		if errors.Is(err, probe.ErrInvalidContainer) {
+			return fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+		}
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	switch {
+	case !info.HasVideo && !info.HasAudio:
+		return fmt.Errorf("%w: no video or audio stream", ErrUnsupportedType)
+	case file.Type == mediatype.Video && !info.HasVideo:
+		// e.g. WebM which has only audio tracks
+		file.Type = mediatype.Audio
+	case file.Type == mediatype.Audio && info.HasVideo:
+		return fmt.Errorf("%w: audio file has video stream", ErrUnsupportedType)
+	}
+	file.Info = info
+	return nil
+}
+
+// webpDimensions reads canvas size from the header of WebP
+func webpDimensions(head []byte) (int, int, bool) {
+	switch string(head[12:16]) {
+	case "VP8 ":
+		if head[23] != 0x9d || head[24] != 0x01 || head[25] != 0x2a {
+			return 0, 0, false
+		}
+		w := int(binary.LittleEndian.Uint16(head[26:28]) & 0x3fff)
+		h := int(binary.LittleEndian.Uint16(head[28:30]) & 0x3fff)
+		return w, h, true
+	case "VP8L":
+		if head[20] != 0x2f {
+			return 0, 0, false
+		}
+		bits := binary.LittleEndian.Uint32(head[21:25])
+		return int(bits&0x3fff) + 1, int(bits>>14&0x3fff) + 1, true
+	case "VP8X":
+		w := int(head[24]) | int(head[25])<<8 | int(head[26])<<16
+		h := int(head[27]) | int(head[28])<<8 | int(head[29])<<16
+		return w + 1, h + 1, true
+	}
+	return 0, 0, false
+}
+
+// UploadErrorCode returns status code for the error of ParseUpload
+func UploadErrorCode(err error) int {
+	switch {
+	case errors.Is(err, ErrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrUnsupportedType), errors.Is(err, ErrTooManyPixels):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusBadRequest
+}
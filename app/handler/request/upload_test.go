@@ -0,0 +1,117 @@
+package request
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"yatter-backend-go/app/mediatype"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUpload(t *testing.T) {
+	t.Parallel()
+
+	smallPNG := encodePNG(t, 10, 10)
+	widePNG := encodePNG(t, 200, 10)
+	// MPEG-1 Layer III, 128kbps, 44.1kHz: 417 bytes per frame
+	mp3 := bytes.Repeat(append([]byte{0xFF, 0xFB, 0x90, 0x00}, make([]byte, 413)...), 10)
+
+	limits := mediatype.Limits{
+		Image:     int64(len(smallPNG)) + 10,
+		Gifv:      1 << 10,
+		Video:     1 << 10,
+		Audio:     1 << 13,
+		MaxWidth:  100,
+		MaxHeight: 100,
+	}
+
+	type want struct {
+		filetype string
+		duration float64
+		code     int
+		err      error
+	}
+	cases := map[string]struct {
+		file   []byte
+		limits mediatype.Limits
+		want   want
+	}{
+		"success": {
+			file:   smallPNG,
+			limits: limits,
+			want:   want{filetype: mediatype.Image, code: http.StatusOK},
+		},
+		"audio": {
+			file:   mp3,
+			limits: limits,
+			want:   want{filetype: mediatype.Audio, duration: 4170 * 8 / 128000.0, code: http.StatusOK},
+		},
+		"unsupported type": {
+			limits: limits,
+			file:   []byte("#!/bin/sh\necho hello\n"),
+			want:   want{code: http.StatusUnprocessableEntity, err: ErrUnsupportedType},
+		},
+		"too large": {
+			limits: limits,
+			file:   append(smallPNG, make([]byte, 100)...),
+			want:   want{code: http.StatusRequestEntityTooLarge, err: ErrTooLarge},
+		},
+		"too many pixels": {
+			file:   widePNG,
+			limits: mediatype.Limits{Image: 1 << 20, MaxWidth: 100, MaxHeight: 100},
+			want:   want{code: http.StatusUnprocessableEntity, err: ErrTooManyPixels},
+		},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			mw := multipart.NewWriter(&buf)
+			assert.NoError(t, mw.WriteField("description", "test"))
+			fw, err := mw.CreateFormFile("file", "test.png")
+			assert.NoError(t, err)
+			_, err = fw.Write(tt.file)
+			assert.NoError(t, err)
+			assert.NoError(t, mw.Close())
+
+			r := httptest.NewRequest(http.MethodPost, "/v1/media", &buf)
+			r.Header.Set("Content-Type", mw.FormDataContentType())
+
+			form, err := ParseUpload(r, tt.limits)
+			if tt.want.err != nil {
+				assert.ErrorIs(t, err, tt.want.err)
+				assert.Equal(t, tt.want.code, UploadErrorCode(err))
+				return
+			}
+
+			assert.NoError(t, err)
+			defer form.RemoveAll()
+			assert.Equal(t, "test", form.Value("description"))
+			if assert.NotNil(t, form.File("file")) {
+				assert.Equal(t, tt.want.filetype, form.File("file").Type)
+				assert.Equal(t, int64(len(tt.file)), form.File("file").Size)
+				if tt.want.duration != 0 && assert.NotNil(t, form.File("file").Info) {
+					assert.InDelta(t, tt.want.duration, form.File("file").Info.Duration, 0.001)
+				}
+			}
+		})
+	}
+}
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -2,14 +2,20 @@ package handler
 
 import (
 	"net/http"
-	"time"
 
 	"yatter-backend-go/app/app"
 	"yatter-backend-go/app/handler/accounts"
+	"yatter-backend-go/app/handler/admin"
 	"yatter-backend-go/app/handler/health"
+	"yatter-backend-go/app/handler/imports"
+	"yatter-backend-go/app/handler/invites"
 	"yatter-backend-go/app/handler/media"
+	"yatter-backend-go/app/handler/reports"
 	"yatter-backend-go/app/handler/statuses"
 	"yatter-backend-go/app/handler/timelines"
+	"yatter-backend-go/app/logger"
+	"yatter-backend-go/app/metrics"
+	"yatter-backend-go/app/tracing"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
@@ -21,26 +27,40 @@ func NewRouter(app *app.App, v *validator.Validate) http.Handler {
 	r := chi.NewRouter()
 
 	// A good base middleware stack
+	r.Use(tracing.Middleware)
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
-	r.Use(middleware.Logger)
+	r.Use(metrics.Middleware)
+	r.Use(logger.Middleware(app.Logger))
 	r.Use(middleware.Recoverer)
 	r.Use(newCORS().Handler)
 
 	// Set a timeout value on the request context (ctx), that will signal
 	// through ctx.Done() that the request has timed out and further
 	// processing should be stopped.
-	r.Use(middleware.Timeout(60 * time.Second))
+	r.Use(middleware.Timeout(app.Config.Get().Server.RequestTimeout))
 
 	r.Mount("/v1/accounts", accounts.NewRouter(app, v))
 
 	r.Mount("/v1/media", media.NewRouter(app))
 
+	r.Mount("/v2/media", media.NewRouterV2(app))
+
 	r.Mount("/v1/statuses", statuses.NewRouter(app))
 
 	r.Mount("/v1/timelines", timelines.NewRouter(app))
 
-	r.Mount("/v1/health", health.NewRouter())
+	r.Mount("/v1/imports", imports.NewRouter(app))
+
+	r.Mount("/v1/reports", reports.NewRouter(app))
+
+	r.Mount("/v1/invites", invites.NewRouter(app))
+
+	r.Mount("/v1/admin", admin.NewRouter(app))
+
+	r.Mount("/v1/health", health.NewRouter(app))
+
+	r.Handle("/metrics", metrics.Handler())
 
 	return r
 }
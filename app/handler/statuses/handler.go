@@ -1,13 +1,12 @@
 package statuses
 
 import (
-	"database/sql"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"yatter-backend-go/app/handler/auth"
 	"yatter-backend-go/app/handler/httperror"
 	"yatter-backend-go/app/handler/request"
+	"yatter-backend-go/app/metrics"
 )
 
 // Handle request for `POST /v1/statuses`
@@ -31,19 +30,20 @@ func (h *handler) Create(w http.ResponseWriter, r *http.Request) {
 	statusRepo := h.app.Dao.Status()
 	id, err := statusRepo.Create(ctx, account.ID, req.Status, req.MediaIDs)
 	if err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.Respond(w, r, err)
 		return
 	}
+	metrics.StatusCreated()
 	status, err := statusRepo.FindByID(ctx, id)
 	if err != nil || status == nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 	status.Account = *account
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(status); err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 }
@@ -81,7 +81,7 @@ func (h *handler) Get(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(status); err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 }
@@ -99,18 +99,14 @@ func (h *handler) Delete(w http.ResponseWriter, r *http.Request) {
 	statusRepo := h.app.Dao.Status()
 	err = statusRepo.DeleteByID(ctx, id)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			httperror.Error(w, http.StatusNotFound)
-		} else {
-			httperror.BadRequest(w, err)
-		}
+		httperror.Respond(w, r, err)
 		return
 	}
 
 	status := struct{}{}
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(status); err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 }
@@ -2,8 +2,10 @@ package statuses
 
 import (
 	"net/http"
+	"time"
 	"yatter-backend-go/app/app"
 	"yatter-backend-go/app/handler/auth"
+	"yatter-backend-go/app/ratelimit"
 
 	"github.com/go-chi/chi"
 )
@@ -19,7 +21,9 @@ func NewRouter(app *app.App) http.Handler {
 
 	r.Route("/", func(r chi.Router) {
 		r.Use(auth.BasicAuth(h.app))
-		r.Post("/", h.Create)
+		r.With(app.RateLimit.Limit(ratelimit.Policy{
+			Name: "statuses.create", Limit: 300, Window: 3 * time.Hour, Key: auth.ByAccount,
+		})).Post("/", h.Create)
 		r.Delete("/{id}", h.Delete)
 	})
 
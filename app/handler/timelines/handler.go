@@ -44,7 +44,7 @@ func (h *handler) Public(w http.ResponseWriter, r *http.Request) {
 	repo := h.app.Dao.Status()
 	statuses, err := repo.ListAll(ctx, req.MaxID, req.SinceID, req.Limit)
 	if err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	} else if statuses == nil {
 		httperror.Error(w, http.StatusNotFound)
@@ -53,7 +53,7 @@ func (h *handler) Public(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(statuses); err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 }
@@ -89,7 +89,7 @@ func (h *handler) Home(w http.ResponseWriter, r *http.Request) {
 	repo := h.app.Dao.Status()
 	statuses, err := repo.ListByID(ctx, account.ID, req.MaxID, req.SinceID, req.Limit)
 	if err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 	} else if statuses == nil {
 		httperror.Error(w, http.StatusNotFound)
 		return
@@ -100,7 +100,7 @@ func (h *handler) Home(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(statuses); err != nil {
-		httperror.InternalServerError(w, err)
+		httperror.InternalServerError(w, r, err)
 		return
 	}
 }
@@ -1,34 +1,53 @@
 package validate
 
 import (
-	"fmt"
-	"log"
 	"strings"
+	"yatter-backend-go/app/domain/repository"
+	"yatter-backend-go/app/logger"
 
 	"github.com/go-playground/validator/v10"
 )
 
-type ValidationErr struct {
-	Errors []string
-}
+// Validate obj, problems of fields are returned as *repository.ValidationError
+func Validate(v *validator.Validate, obj interface{}) error {
+	err := v.Struct(obj)
+	if err == nil {
+		return nil
+	}
+
+	errs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		logger.Default().Error("invalid validation", "error", err)
+		return nil
+	}
 
-func (v *ValidationErr) Error() string {
-	return strings.Join(v.Errors, ", ")
+	verr := &repository.ValidationError{}
+	for _, e := range errs {
+		verr.Add(strings.ToLower(e.Field()), codeOf(e.Tag()), messageOf(e))
+	}
+	return verr
 }
 
-func Validate(v *validator.Validate, obj interface{}) error {
-	err := v.Struct(obj)
-	if err != nil {
-		verr := &ValidationErr{}
-		if _, ok := err.(*validator.ValidationErrors); ok {
-			log.Println(err)
-			return nil
-		}
+func codeOf(tag string) string {
+	switch tag {
+	case "required":
+		return "ERR_BLANK"
+	case "min", "max", "len":
+		return "ERR_LENGTH"
+	default:
+		return "ERR_INVALID"
+	}
+}
 
-		for _, e := range err.(validator.ValidationErrors) {
-			verr.Errors = append(verr.Errors, fmt.Sprintf("%s is %s", e.StructField(), e.Tag()))
-		}
-		return verr
+func messageOf(e validator.FieldError) string {
+	switch e.Tag() {
+	case "required":
+		return "can't be blank"
+	case "min":
+		return "is too short (minimum is " + e.Param() + ")"
+	case "max":
+		return "is too long (maximum is " + e.Param() + ")"
+	default:
+		return "is invalid (" + e.Tag() + ")"
 	}
-	return nil
 }
@@ -0,0 +1,118 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"yatter-backend-go/app/logger"
+)
+
+// Manager runs HTTP server and stops components in order on shutdown
+type Manager struct {
+	// Duration to keep serving with failing readiness before draining,
+	// so that load balancers stop routing new requests
+	DrainDelay time.Duration
+
+	// Max duration to drain connections and stop components
+	ShutdownTimeout time.Duration
+
+	draining int32
+
+	mu    sync.Mutex
+	hooks []hook
+}
+
+type hook struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// Create Manager
+func New(drainDelay, shutdownTimeout time.Duration) *Manager {
+	return &Manager{DrainDelay: drainDelay, ShutdownTimeout: shutdownTimeout}
+}
+
+// Ready reports whether the server accepts new requests
+// nil Manager is always ready
+func (m *Manager) Ready() bool {
+	return m == nil || atomic.LoadInt32(&m.draining) == 0
+}
+
+// OnStop registers stop function called after the server is drained
+// Functions are called in the order they are registered.
+func (m *Manager) OnStop(name string, stop func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook{name: name, stop: stop})
+}
+
+// Serve srv until ctx is done, then drains it and calls stop functions
+// Errors while shutting down are all returned.
+func (m *Manager) Serve(ctx context.Context, srv *http.Server) error {
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		m.stop(context.Background())
+		return err
+	}
+	return m.ServeListener(ctx, srv, ln)
+}
+
+// ServeListener is Serve with the listener
+func (m *Manager) ServeListener(ctx context.Context, srv *http.Server, ln net.Listener) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-serveErr:
+		// server stopped by itself
+		m.stop(context.Background())
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Default().Info("shutting down", "drain_delay", m.DrainDelay)
+	atomic.StoreInt32(&m.draining, 1)
+	time.Sleep(m.DrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), m.ShutdownTimeout)
+	defer cancel()
+
+	var errs []string
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		errs = append(errs, fmt.Sprintf("http server: %v", err))
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		errs = append(errs, fmt.Sprintf("http server: %v", err))
+	}
+	errs = append(errs, m.stop(shutdownCtx)...)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("shutdown: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (m *Manager) stop(ctx context.Context) []string {
+	m.mu.Lock()
+	hooks := m.hooks
+	m.hooks = nil
+	m.mu.Unlock()
+
+	var errs []string
+	for _, h := range hooks {
+		if err := h.stop(ctx); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", h.name, err))
+			continue
+		}
+		logger.Default().Info("stopped", "component", h.name)
+	}
+	return errs
+}
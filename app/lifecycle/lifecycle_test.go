@@ -0,0 +1,71 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_ServeListener(t *testing.T) {
+	m := New(20*time.Millisecond, time.Second)
+
+	started := make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("done"))
+	})}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	var order []string
+	m.OnStop("worker", func(ctx context.Context) error {
+		order = append(order, "worker")
+		return nil
+	})
+	m.OnStop("dao", func(ctx context.Context) error {
+		order = append(order, "dao")
+		return errors.New("close failed")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- m.ServeListener(ctx, srv, ln)
+	}()
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+
+	<-started
+	assert.True(t, m.Ready())
+	cancel()
+	time.Sleep(5 * time.Millisecond)
+	assert.False(t, m.Ready(), "readiness fails while draining")
+
+	assert.Equal(t, "done", <-body, "in-flight request is completed")
+	err = <-served
+	assert.EqualError(t, err, "shutdown: dao: close failed")
+	assert.Equal(t, []string{"worker", "dao"}, order)
+}
+
+func TestManager_Ready(t *testing.T) {
+	var m *Manager
+	assert.True(t, m.Ready())
+}
@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var contextKey = new(struct{})
+
+// WithContext returns ctx which carries l
+func WithContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey, l)
+}
+
+// FromContext returns the logger of ctx, or Default if ctx has no logger
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(contextKey).(*Logger); ok {
+		return l
+	}
+	return Default()
+}
+
+// SetAccountID records the authenticated account of the request in ctx
+func SetAccountID(ctx context.Context, id int64) {
+	if l, ok := ctx.Value(contextKey).(*Logger); ok && l.req != nil {
+		atomic.StoreInt64(&l.req.accountID, id)
+	}
+}
+
+// request holds fields of the request, some are known while it is handled
+type request struct {
+	start     time.Time
+	id        string
+	traceID   string
+	rctx      *chi.Context
+	accountID int64
+}
+
+func newRequest(ctx context.Context, id string, start time.Time) *request {
+	req := &request{start: start, id: id, rctx: chi.RouteContext(ctx)}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		req.traceID = sc.TraceID().String()
+	}
+	return req
+}
+
+func (r *request) fields() []interface{} {
+	fields := []interface{}{"request_id", r.id}
+	if r.traceID != "" {
+		fields = append(fields, "trace_id", r.traceID)
+	}
+	if id := atomic.LoadInt64(&r.accountID); id != 0 {
+		fields = append(fields, "account_id", id)
+	}
+	if r.rctx != nil {
+		if route := r.rctx.RoutePattern(); route != "" {
+			fields = append(fields, "route", route)
+		}
+	}
+	fields = append(fields, "latency_ms", float64(time.Since(r.start).Microseconds())/1000)
+	return fields
+}
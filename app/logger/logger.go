@@ -0,0 +1,206 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Level of log
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return fmt.Sprintf("level(%d)", int32(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel parses name of level
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q", s)
+}
+
+const redacted = "[REDACTED]"
+
+// keys whose values are never written
+var secretKeys = []string{"password", "authorization", "authentication", "secret", "token"}
+
+func isSecret(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range secretKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Logger writes structured logs as JSON lines
+// Fields are given as alternating keys and values.
+type Logger struct {
+	out    *output
+	fields []interface{}
+	req    *request
+}
+
+// output is shared by loggers derived by With
+type output struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level int32
+	now   func() time.Time
+}
+
+// Create Logger writing logs of level or higher to w
+func New(w io.Writer, level Level) *Logger {
+	return &Logger{out: &output{w: w, level: int32(level), now: time.Now}}
+}
+
+// Nop returns Logger which writes nothing
+func Nop() *Logger {
+	return New(ioutil.Discard, LevelError+1)
+}
+
+var (
+	defaultMu sync.RWMutex
+	std       = New(os.Stderr, LevelInfo)
+)
+
+// Default returns the logger used without request context
+func Default() *Logger {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return std
+}
+
+// SetDefault replaces the logger returned by Default
+func SetDefault(l *Logger) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	std = l
+}
+
+// SetLevel changes level of the logger and loggers derived from it
+func (l *Logger) SetLevel(level Level) {
+	atomic.StoreInt32(&l.out.level, int32(level))
+}
+
+// Enabled reports whether logs of level are written
+func (l *Logger) Enabled(level Level) bool {
+	return int32(level) >= atomic.LoadInt32(&l.out.level)
+}
+
+// With returns Logger which adds fields to every log
+func (l *Logger) With(kv ...interface{}) *Logger {
+	c := *l
+	c.fields = append(append([]interface{}{}, l.fields...), kv...)
+	return &c
+}
+
+func (l *Logger) Debug(msg string, kv ...interface{}) { l.log(LevelDebug, msg, kv) }
+func (l *Logger) Info(msg string, kv ...interface{})  { l.log(LevelInfo, msg, kv) }
+func (l *Logger) Warn(msg string, kv ...interface{})  { l.log(LevelWarn, msg, kv) }
+func (l *Logger) Error(msg string, kv ...interface{}) { l.log(LevelError, msg, kv) }
+
+func (l *Logger) log(level Level, msg string, kv []interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	fields := make([]interface{}, 0, len(l.fields)+len(kv)+8)
+	fields = append(fields, "time", l.out.now().Format(time.RFC3339Nano), "level", level.String(), "msg", msg)
+	if l.req != nil {
+		fields = append(fields, l.req.fields()...)
+	}
+	fields = append(fields, l.fields...)
+	fields = append(fields, kv...)
+
+	line := encode(fields)
+
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	l.out.w.Write(line)
+}
+
+// encode writes fields as a JSON object, later fields override earlier ones of the same key
+func encode(fields []interface{}) []byte {
+	if len(fields)%2 != 0 {
+		fields = append(fields, "(MISSING)")
+	}
+
+	last := make(map[string]int, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		last[fmt.Sprint(fields[i])] = i
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := 0; i < len(fields); i += 2 {
+		key := fmt.Sprint(fields[i])
+		if last[key] != i {
+			continue
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(encodeValue(key, fields[i+1]))
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+func encodeValue(key string, v interface{}) []byte {
+	if isSecret(key) {
+		v = redacted
+	}
+
+	switch x := v.(type) {
+	case error:
+		v = x.Error()
+	case time.Duration:
+		v = x.String()
+	case http.Header:
+		h := make(http.Header, len(x))
+		for k, vs := range x {
+			if isSecret(k) {
+				vs = []string{redacted}
+			}
+			h[k] = vs
+		}
+		v = h
+	case fmt.Stringer:
+		v = x.String()
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprintf("%+v", v))
+	}
+	return b
+}
@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &m), line)
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, LevelInfo)
+	l.out.now = func() time.Time { return time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	l.Debug("hidden")
+	l.With("component", "test", "key", "old").Info("hello",
+		"key", "new",
+		"error", errors.New("boom"),
+		"password", "p@ss",
+		"header", http.Header{"Authorization": {"Basic xxx"}, "Accept": {"*/*"}},
+	)
+	l.SetLevel(LevelError)
+	l.Warn("hidden")
+
+	lines := decodeLines(t, &buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, map[string]interface{}{
+		"time":      "2022-01-02T03:04:05Z",
+		"level":     "info",
+		"msg":       "hello",
+		"component": "test",
+		"key":       "new",
+		"error":     "boom",
+		"password":  "[REDACTED]",
+		"header": map[string]interface{}{
+			"Authorization": []interface{}{"[REDACTED]"},
+			"Accept":        []interface{}{"*/*"},
+		},
+	}, lines[0])
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("WARN")
+	assert.NoError(t, err)
+	assert.Equal(t, LevelWarn, level)
+
+	_, err = ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, LevelInfo)
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(Middleware(l))
+	r.Get("/v1/accounts/{username}", func(w http.ResponseWriter, r *http.Request) {
+		SetAccountID(r.Context(), 42)
+		FromContext(r.Context()).Error("failed")
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/accounts/john", nil)
+	req.Header.Set("Authorization", "Basic xxx")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := decodeLines(t, &buf)
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		assert.NotEmpty(t, line["request_id"])
+		assert.Equal(t, float64(42), line["account_id"])
+		assert.Equal(t, "/v1/accounts/{username}", line["route"])
+		assert.Contains(t, line, "latency_ms")
+		assert.Equal(t, "error", line["level"])
+	}
+	assert.Equal(t, "failed", lines[0]["msg"])
+	assert.Equal(t, "access", lines[1]["msg"])
+	assert.Equal(t, float64(500), lines[1]["status"])
+	assert.NotContains(t, buf.String(), "Basic xxx")
+}
@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+// Middleware puts a request scoped logger into context and writes access logs
+// It must be placed after middleware.RequestID and middleware.RealIP.
+func Middleware(l *Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			rl := &Logger{out: l.out, fields: l.fields, req: newRequest(ctx, middleware.GetReqID(ctx), time.Now())}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(WithContext(ctx, rl)))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			level := LevelInfo
+			if status >= http.StatusInternalServerError {
+				level = LevelError
+			}
+			rl.log(level, "access", []interface{}{
+				"method", r.Method,
+				"path", r.URL.Path,
+				"proto", r.Proto,
+				"status", status,
+				"bytes", ww.BytesWritten(),
+				"remote_addr", r.RemoteAddr,
+				"user_agent", r.UserAgent(),
+			})
+		})
+	}
+}
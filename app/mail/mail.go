@@ -0,0 +1,34 @@
+// Package mail sends messages to users
+package mail
+
+import (
+	"context"
+	"fmt"
+	"yatter-backend-go/app/config"
+)
+
+// Message plain text mail
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends messages
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// New returns Mailer of the driver of cfg
+func New(cfg config.MailConfig) (Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return NewSMTP(cfg), nil
+	case "file":
+		return NewFile(cfg.Dir, cfg.From), nil
+	case "memory":
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,73 @@
+package mail
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"yatter-backend-go/app/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	tests := map[string]struct {
+		driver string
+		want   interface{}
+	}{
+		"smtp":   {"smtp", &SMTP{}},
+		"file":   {"file", &File{}},
+		"memory": {"memory", &Memory{}},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			m, err := New(config.MailConfig{Driver: tt.driver})
+			assert.Nil(t, err)
+			assert.IsType(t, tt.want, m)
+		})
+	}
+
+	_, err := New(config.MailConfig{Driver: "sendmail"})
+	assert.NotNil(t, err)
+}
+
+func TestFormat(t *testing.T) {
+	msg := &Message{To: "john@example.com", Subject: "パスワードの再設定", Body: "line1\nline2\n"}
+	got := string(format("yatter@example.com", msg, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	assert.Contains(t, got, "From: yatter@example.com\r\n")
+	assert.Contains(t, got, "To: john@example.com\r\n")
+	assert.Contains(t, got, "Subject: =?utf-8?q?")
+	assert.Contains(t, got, "Date: Sat, 01 Jan 2022 00:00:00 +0000\r\n")
+	assert.True(t, strings.HasSuffix(got, "\r\n\r\nline1\r\nline2\r\n"))
+}
+
+func TestFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	f := NewFile(dir, "yatter@example.com")
+
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, f.Send(context.Background(), &Message{To: "john@example.com", Subject: "hello", Body: "body"}))
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	assert.Nil(t, err)
+	assert.Len(t, files, 2)
+
+	b, err := ioutil.ReadFile(files[0])
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), "To: john@example.com\r\n")
+}
+
+func TestMemory(t *testing.T) {
+	m := NewMemory()
+	assert.Nil(t, m.Send(context.Background(), &Message{To: "john@example.com"}))
+
+	messages := m.Messages()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "john@example.com", messages[0].To)
+}
@@ -0,0 +1,66 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// File writes messages to files in a directory instead of sending, for development
+type File struct {
+	dir  string
+	from string
+}
+
+// Create File
+func NewFile(dir, from string) *File {
+	return &File{dir: dir, from: from}
+}
+
+func (f *File) Send(ctx context.Context, msg *Message) error {
+	if err := os.MkdirAll(f.dir, 0755); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	tmp, err := ioutil.TempFile(f.dir, fmt.Sprintf("%d-*.eml", now.UnixNano()))
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(format(f.from, msg, now))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// Memory keeps messages in memory, for tests
+type Memory struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// Create Memory
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) Send(ctx context.Context, msg *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, *msg)
+	return nil
+}
+
+// Messages returns sent messages
+func (m *Memory) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
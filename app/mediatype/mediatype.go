@@ -0,0 +1,182 @@
+// Package mediatype sniffs types of uploaded media and holds their limits,
+// shared by the upload handlers and the background worker
+package mediatype
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/http"
+	"yatter-backend-go/app/config"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/probe"
+)
+
+// Attachment types
+const (
+	Image   string = "image"
+	Gifv    string = "gifv"
+	Video   string = "video"
+	Audio   string = "audio"
+	Unknown string = "unknown"
+)
+
+// Number of bytes read to sniff the content type
+const SniffLen = 4096
+
+// Allowed MIME types and attachment types for them
+var allowedTypes = map[string]string{
+	"image/jpeg":      Image,
+	"image/png":       Image,
+	"image/webp":      Image,
+	"image/gif":       Gifv,
+	"video/mp4":       Video,
+	"video/quicktime": Video,
+	"video/webm":      Video,
+	"audio/mpeg":      Audio,
+	"audio/ogg":       Audio,
+	"audio/mp4":       Audio,
+}
+
+// Extensions of stored files for allowed MIME types
+var extensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+	"image/gif":       ".gif",
+	"video/mp4":       ".mp4",
+	"video/quicktime": ".mov",
+	"video/webm":      ".webm",
+	"audio/mpeg":      ".mp3",
+	"audio/ogg":       ".ogg",
+	"audio/mp4":       ".m4a",
+}
+
+// Major brands of ISO base media files
+var mp4Brands = map[string]string{
+	"isom": "video/mp4",
+	"iso2": "video/mp4",
+	"iso4": "video/mp4",
+	"iso5": "video/mp4",
+	"iso6": "video/mp4",
+	"mp41": "video/mp4",
+	"mp42": "video/mp4",
+	"avc1": "video/mp4",
+	"dash": "video/mp4",
+	"M4V ": "video/mp4",
+	"M4A ": "audio/mp4",
+	"qt  ": "video/quicktime",
+}
+
+// DetectMIME sniffs MIME type from the head of file
+// It verifies the container structure in addition to http.DetectContentType
+func DetectMIME(head []byte) string {
+	if len(head) >= 12 && string(head[4:8]) == "ftyp" {
+		// http.DetectContentType doesn't know QuickTime and some brands
+		size := int(binary.BigEndian.Uint32(head[:4]))
+		if size < 8 || size > len(head) {
+			return "application/octet-stream"
+		}
+		if mime, ok := mp4Brands[string(head[8:12])]; ok {
+			return mime
+		}
+		return "application/octet-stream"
+	}
+
+	// MPEG audio without ID3 tag starts with frame sync
+	if len(head) >= 2 && head[0] == 0xFF && head[1]&0xE0 == 0xE0 {
+		return "audio/mpeg"
+	}
+
+	mime := http.DetectContentType(head)
+	switch mime {
+	case "application/ogg":
+		// only Vorbis and Opus audio is allowed in Ogg
+		if bytes.Contains(head, []byte("\x01vorbis")) || bytes.Contains(head, []byte("OpusHead")) {
+			return "audio/ogg"
+		}
+	case "image/png":
+		// IHDR chunk must follow the signature
+		if len(head) < 16 || string(head[12:16]) != "IHDR" {
+			return "application/octet-stream"
+		}
+	case "image/webp":
+		if len(head) < 16 || !bytes.HasPrefix(head[12:16], []byte("VP8")) {
+			return "application/octet-stream"
+		}
+	case "video/webm":
+		// DocType must be webm, Matroska is not allowed
+		if !bytes.Contains(head, []byte{0x42, 0x82, 0x84, 'w', 'e', 'b', 'm'}) {
+			return "application/octet-stream"
+		}
+	}
+	return mime
+}
+
+// Classify returns the attachment type of MIME type, Unknown if not allowed
+func Classify(mimeType string) string {
+	if t, ok := allowedTypes[mimeType]; ok {
+		return t
+	}
+	return Unknown
+}
+
+// Extension returns the extension of stored files of MIME type
+func Extension(mimeType string) string {
+	return extensions[mimeType]
+}
+
+// Limits of uploaded files
+type Limits struct {
+	// Max size of images in bytes
+	Image int64
+	// Max size of gifv in bytes
+	Gifv int64
+	// Max size of videos in bytes
+	Video int64
+	// Max size of audio in bytes
+	Audio int64
+	// Max dimensions of images in pixels
+	MaxWidth  int
+	MaxHeight int
+}
+
+// Read Limits from configuration
+func LimitsFromConfig(cfg config.MediaConfig) Limits {
+	return Limits{
+		Image:     cfg.ImageSizeLimit,
+		Gifv:      cfg.GifvSizeLimit,
+		Video:     cfg.VideoSizeLimit,
+		Audio:     cfg.AudioSizeLimit,
+		MaxWidth:  cfg.ImageMaxWidth,
+		MaxHeight: cfg.ImageMaxHeight,
+	}
+}
+
+// SizeOf returns the size limit of the attachment type, 0 if not allowed
+func (l Limits) SizeOf(filetype string) int64 {
+	switch filetype {
+	case Image:
+		return l.Image
+	case Gifv:
+		return l.Gifv
+	case Video:
+		return l.Video
+	case Audio:
+		return l.Audio
+	}
+	return 0
+}
+
+// FillMeta stores the stream information of info as the original of meta
+// Dimensions already in meta are kept when the stream has none, e.g. audio
+func FillMeta(meta *object.AttachmentMeta, info *probe.Info) {
+	if meta.Original == nil {
+		meta.Original = &object.MediaInfo{}
+	}
+	if info.Width > 0 && info.Height > 0 {
+		*meta.Original = *object.NewMediaInfo(info.Width, info.Height)
+	}
+	meta.Original.Duration = info.Duration
+	meta.Original.FrameRate = info.FrameRate
+	meta.Original.Bitrate = info.Bitrate
+}
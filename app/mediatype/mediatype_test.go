@@ -0,0 +1,31 @@
+package mediatype
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectMIME(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		head []byte
+		want string
+	}{
+		"mp4":           {append([]byte{0, 0, 0, 0x18}, []byte("ftypisom\x00\x00\x02\x00isomiso2")...), "video/mp4"},
+		"quicktime":     {append([]byte{0, 0, 0, 0x14}, []byte("ftypqt  \x00\x00\x02\x00qt  ")...), "video/quicktime"},
+		"unknown brand": {append([]byte{0, 0, 0, 0x14}, []byte("ftypheic\x00\x00\x02\x00heic")...), "application/octet-stream"},
+		"broken png":    {[]byte("\x89PNG\x0D\x0A\x1A\x0Aabcdefgh"), "application/octet-stream"},
+		"m4a":           {append([]byte{0, 0, 0, 0x14}, []byte("ftypM4A \x00\x00\x02\x00M4A ")...), "audio/mp4"},
+		"mp3 frame":     {[]byte{0xFF, 0xFB, 0x90, 0x00}, "audio/mpeg"},
+		"ogg opus":      {[]byte("OggS\x00\x02\x00\x00\x00\x00\x00\x00\x00\x00OpusHead"), "audio/ogg"},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DetectMIME(tt.head))
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	sub := chi.NewRouter()
+	sub.Get("/{username}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Mount("/v1/accounts", sub)
+
+	for _, path := range []string{"/v1/accounts/john", "/v1/accounts/jane", "/unknown"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(httpRequests.WithLabelValues("GET", "/v1/accounts/{username}", "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(httpRequests.WithLabelValues("GET", "unmatched", "404")))
+}
+
+func TestQueryHook(t *testing.T) {
+	_, end := QueryHook(context.Background(), "account.FindByID")
+	end()
+
+	assert.Equal(t, 1, testutil.CollectAndCount(queryDuration, "yatter_dao_query_duration_seconds"))
+}
+
+func TestHandler(t *testing.T) {
+	RegisterDBStats(func() sql.DBStats { return sql.DBStats{OpenConnections: 3} })
+	StatusCreated()
+	Uploaded("image", 100)
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := w.Body.String()
+	for _, want := range []string{
+		"yatter_db_open_connections 3",
+		"yatter_statuses_created_total 1",
+		`yatter_media_stored_bytes_total{type="image"} 100`,
+	} {
+		assert.True(t, strings.Contains(body, want), want)
+	}
+}
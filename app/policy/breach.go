@@ -0,0 +1,49 @@
+package policy
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"strings"
+)
+
+// BreachList is BreachChecker of SHA-1 hashes loaded from a file
+type BreachList struct {
+	hashes map[string]struct{}
+}
+
+// LoadBreachList reads the file at path whose lines are uppercase or
+// lowercase hex SHA-1 of passwords, optionally followed by ":count"
+func LoadBreachList(path string) (*BreachList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	l := &BreachList{hashes: make(map[string]struct{})}
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			line = line[:i]
+		}
+		if len(line) != sha1.Size*2 {
+			continue
+		}
+		l.hashes[strings.ToUpper(line)] = struct{}{}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Breached implements BreachChecker
+func (l *BreachList) Breached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	_, ok := l.hashes[strings.ToUpper(hex.EncodeToString(sum[:]))]
+	return ok, nil
+}
@@ -0,0 +1,127 @@
+// Package policy checks usernames and passwords of new credentials against
+// the rules of the configuration. Problems are reported as
+// *repository.ValidationError so that handlers respond them as they are.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+	"yatter-backend-go/app/config"
+	"yatter-backend-go/app/domain/repository"
+	"yatter-backend-go/app/logger"
+)
+
+// Longest password bcrypt accepts, in bytes
+const maxPasswordBytes = 72
+
+// Usernames which collide with routes under `/v1/accounts/` or impersonate the staff
+var reservedUsernames = map[string]bool{
+	"2fa": true, "relationships": true, "update_credentials": true, "verify_credentials": true, "login_activity": true,
+	"verify_email": true, "password_reset": true, "change_password": true, "delete": true, "export": true, "token": true,
+	"admin": true, "administrator": true, "moderator": true, "mod": true, "staff": true, "root": true,
+	"system": true, "support": true, "help": true, "security": true, "abuse": true, "yatter": true,
+	"postmaster": true, "webmaster": true, "hostmaster": true, "noreply": true, "no_reply": true,
+	"api": true, "me": true, "null": true, "undefined": true,
+}
+
+// Passwords which are too common to be guessed slowly, checked with or without a BreachChecker
+var commonPasswords = map[string]bool{
+	"password": true, "password1": true, "password123": true, "passw0rd": true,
+	"12345678": true, "123456789": true, "1234567890": true, "87654321": true, "11111111": true,
+	"00000000": true, "qwertyuiop": true, "qwerty123": true, "1q2w3e4r": true, "1qaz2wsx": true,
+	"abcd1234": true, "iloveyou": true, "letmein1": true, "sunshine": true, "football": true,
+	"baseball": true, "princess": true, "superman": true, "welcome1": true, "trustno1": true,
+}
+
+// BreachChecker tells whether password is known from data breaches
+type BreachChecker interface {
+	Breached(ctx context.Context, password string) (bool, error)
+}
+
+// CheckRegistration checks username and password of a new account
+func CheckRegistration(ctx context.Context, cfg config.AccountsConfig, breach BreachChecker, username, password string) error {
+	verr := &repository.ValidationError{}
+	checkUsername(verr, cfg, username)
+	checkPassword(ctx, verr, cfg, breach, "password", username, password)
+	return verr.Err()
+}
+
+// CheckPassword checks a new password of field, username is empty when unknown yet
+func CheckPassword(ctx context.Context, cfg config.AccountsConfig, breach BreachChecker, field, username, password string) error {
+	verr := &repository.ValidationError{}
+	checkPassword(ctx, verr, cfg, breach, field, username, password)
+	return verr.Err()
+}
+
+func checkUsername(verr *repository.ValidationError, cfg config.AccountsConfig, username string) {
+	n := utf8.RuneCountInString(username)
+	switch {
+	case n < cfg.UsernameMinLength:
+		verr.Add("username", "ERR_LENGTH", fmt.Sprintf("is too short (minimum is %d)", cfg.UsernameMinLength))
+		return
+	case n > cfg.UsernameMaxLength:
+		verr.Add("username", "ERR_LENGTH", fmt.Sprintf("is too long (maximum is %d)", cfg.UsernameMaxLength))
+		return
+	}
+
+	// the pattern is validated with the configuration
+	if pattern, err := regexp.Compile(cfg.UsernamePattern); err == nil && !pattern.MatchString(username) {
+		verr.Add("username", "ERR_INVALID", "must contain only letters, numbers and underscores")
+		return
+	}
+
+	if IsReserved(cfg, username) {
+		verr.Add("username", "ERR_RESERVED", "is reserved")
+	}
+}
+
+// IsReserved reports whether username is refused for registration
+func IsReserved(cfg config.AccountsConfig, username string) bool {
+	name := strings.ToLower(username)
+	if reservedUsernames[name] {
+		return true
+	}
+	for _, r := range cfg.ReservedUsernames {
+		if strings.ToLower(r) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func checkPassword(ctx context.Context, verr *repository.ValidationError, cfg config.AccountsConfig, breach BreachChecker, field, username, password string) {
+	switch {
+	case utf8.RuneCountInString(password) < cfg.PasswordMinLength:
+		verr.Add(field, "ERR_LENGTH", fmt.Sprintf("is too short (minimum is %d)", cfg.PasswordMinLength))
+		return
+	case len(password) > maxPasswordBytes:
+		verr.Add(field, "ERR_LENGTH", fmt.Sprintf("is too long (maximum is %d bytes)", maxPasswordBytes))
+		return
+	}
+
+	lower := strings.ToLower(password)
+	if username != "" && strings.Contains(lower, strings.ToLower(username)) {
+		verr.Add(field, "ERR_INVALID", "must not contain the username")
+		return
+	}
+	if commonPasswords[lower] {
+		verr.Add(field, "ERR_BREACHED", "is too common")
+		return
+	}
+
+	if breach == nil {
+		return
+	}
+	breached, err := breach.Breached(ctx, password)
+	if err != nil {
+		// an unavailable breach list must not stop users from setting passwords
+		logger.FromContext(ctx).Warn("failed to check breached passwords", "error", err)
+		return
+	}
+	if breached {
+		verr.Add(field, "ERR_BREACHED", "has appeared in a data breach, choose another one")
+	}
+}
@@ -0,0 +1,112 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"yatter-backend-go/app/config"
+	"yatter-backend-go/app/domain/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type breachFunc func(ctx context.Context, password string) (bool, error)
+
+func (f breachFunc) Breached(ctx context.Context, password string) (bool, error) {
+	return f(ctx, password)
+}
+
+func TestCheckRegistration(t *testing.T) {
+	cfg := config.Default().Accounts
+	cfg.ReservedUsernames = []string{"Yatter_Team"}
+
+	breach := breachFunc(func(ctx context.Context, password string) (bool, error) {
+		switch password {
+		case "breached secret":
+			return true, nil
+		case "unavailable":
+			return false, errors.New("unavailable")
+		}
+		return false, nil
+	})
+
+	tests := map[string]struct {
+		username string
+		password string
+		field    string
+		code     string
+	}{
+		"valid":               {"john_doe", "long secret", "", ""},
+		"empty username":      {"", "long secret", "username", "ERR_LENGTH"},
+		"long username":       {strings.Repeat("a", 31), "long secret", "username", "ERR_LENGTH"},
+		"slash":               {"john/doe", "long secret", "username", "ERR_INVALID"},
+		"space":               {"john doe", "long secret", "username", "ERR_INVALID"},
+		"emoji":               {"john🐦", "long secret", "username", "ERR_INVALID"},
+		"route name":          {"Export", "long secret", "username", "ERR_RESERVED"},
+		"staff name":          {"ADMIN", "long secret", "username", "ERR_RESERVED"},
+		"configured":          {"yatter_team", "long secret", "username", "ERR_RESERVED"},
+		"short password":      {"john", "secret", "password", "ERR_LENGTH"},
+		"long password":       {"john", strings.Repeat("a", 73), "password", "ERR_LENGTH"},
+		"contains username":   {"john", "JOHN1234", "password", "ERR_INVALID"},
+		"common password":     {"john", "Password1", "password", "ERR_BREACHED"},
+		"breached password":   {"john", "breached secret", "password", "ERR_BREACHED"},
+		"breach list is down": {"john", "unavailable", "", ""},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			err := CheckRegistration(context.Background(), cfg, breach, tt.username, tt.password)
+			if tt.field == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var verr *repository.ValidationError
+			if assert.ErrorAs(t, err, &verr) && assert.Len(t, verr.Fields, 1) {
+				assert.Equal(t, tt.field, verr.Fields[0].Field)
+				assert.Equal(t, tt.code, verr.Fields[0].Code)
+			}
+		})
+	}
+}
+
+func TestCheckRegistrationReportsBothFields(t *testing.T) {
+	err := CheckRegistration(context.Background(), config.Default().Accounts, nil, "a/b", "short")
+
+	var verr *repository.ValidationError
+	if assert.ErrorAs(t, err, &verr) && assert.Len(t, verr.Fields, 2) {
+		assert.Equal(t, "username", verr.Fields[0].Field)
+		assert.Equal(t, "password", verr.Fields[1].Field)
+	}
+}
+
+func TestBreachList(t *testing.T) {
+	// SHA-1 of "password1" and "long secret"
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	content := "E38AD214943DAAD1D64C102FAEC29DE4AFE9DA3D:2413945\n" +
+		"not a hash\n" +
+		"a17c9b8329738982d58b6ca5c789d73ead2052a0\n"
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0o600))
+
+	list, err := LoadBreachList(path)
+	assert.NoError(t, err)
+
+	breached, err := list.Breached(context.Background(), "password1")
+	assert.NoError(t, err)
+	assert.True(t, breached)
+
+	breached, err = list.Breached(context.Background(), "long secret")
+	assert.NoError(t, err)
+	assert.True(t, breached)
+
+	breached, err = list.Breached(context.Background(), "correct horse battery staple")
+	assert.NoError(t, err)
+	assert.False(t, breached)
+
+	_, err = LoadBreachList(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
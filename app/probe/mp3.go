@@ -0,0 +1,153 @@
+package probe
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Range to search the first frame after ID3 tag
+const mp3SearchLen = 64 << 10
+
+// Bitrates in kbps indexed by [MPEG-1][layer I, II, III][index]
+var mp3Bitrates = [2][3][16]int64{
+	{ // MPEG-2, 2.5
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+	},
+	{ // MPEG-1
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+	},
+}
+
+// Sample rates indexed by version bits
+var mp3SampleRates = [4][3]int64{
+	{11025, 12000, 8000},  // MPEG-2.5
+	{0, 0, 0},             // reserved
+	{22050, 24000, 16000}, // MPEG-2
+	{44100, 48000, 32000}, // MPEG-1
+}
+
+type mp3Frame struct {
+	mpeg1      bool
+	mono       bool
+	bitrate    int64 // bits per second
+	sampleRate int64
+	samples    int64 // samples per frame
+	length     int64 // bytes of frame
+}
+
+func parseMP3Frame(h []byte) (*mp3Frame, bool) {
+	if len(h) < 4 || h[0] != 0xFF || h[1]&0xE0 != 0xE0 {
+		return nil, false
+	}
+	version := h[1] >> 3 & 0x3
+	layerBits := h[1] >> 1 & 0x3
+	bitrateIdx := h[2] >> 4
+	rateIdx := h[2] >> 2 & 0x3
+	if version == 1 || layerBits == 0 || bitrateIdx == 0 || bitrateIdx == 15 || rateIdx == 3 {
+		return nil, false
+	}
+
+	f := &mp3Frame{mpeg1: version == 3, mono: h[3]>>6 == 3}
+	layer := 4 - int(layerBits) // 1, 2 or 3
+	v := 0
+	if f.mpeg1 {
+		v = 1
+	}
+	f.bitrate = mp3Bitrates[v][layer-1][bitrateIdx] * 1000
+	f.sampleRate = mp3SampleRates[version][rateIdx]
+	padding := int64(h[2] >> 1 & 0x1)
+
+	switch {
+	case layer == 1:
+		f.samples = 384
+		f.length = (12*f.bitrate/f.sampleRate + padding) * 4
+	case layer == 3 && !f.mpeg1:
+		f.samples = 576
+		f.length = f.samples/8*f.bitrate/f.sampleRate + padding
+	default:
+		f.samples = 1152
+		f.length = f.samples/8*f.bitrate/f.sampleRate + padding
+	}
+	return f, f.length > 4
+}
+
+func probeMP3(r io.ReadSeeker, size int64) (*Info, error) {
+	var offset int64
+	hdr := make([]byte, 10)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return nil, err
+	}
+	if string(hdr[:3]) == "ID3" {
+		// tag size is a syncsafe integer
+		tagSize := int64(hdr[6]&0x7F)<<21 | int64(hdr[7]&0x7F)<<14 | int64(hdr[8]&0x7F)<<7 | int64(hdr[9]&0x7F)
+		offset = 10 + tagSize
+		if hdr[5]&0x10 != 0 {
+			offset += 10
+		}
+	}
+
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, mp3SearchLen)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	buf = buf[:n]
+
+	for i := 0; i+4 <= len(buf); i++ {
+		f, ok := parseMP3Frame(buf[i:])
+		if !ok {
+			continue
+		}
+		// the next frame must follow unless the file ends, to avoid false sync
+		next := int64(i) + f.length
+		if next+4 <= int64(len(buf)) {
+			if _, ok := parseMP3Frame(buf[next:]); !ok {
+				continue
+			}
+		} else if offset+next < size {
+			continue
+		}
+
+		return mp3Info(buf[i:], f, size-offset-int64(i)), nil
+	}
+
+	return nil, invalid("no MPEG audio frame")
+}
+
+func mp3Info(frame []byte, f *mp3Frame, audioSize int64) *Info {
+	info := &Info{HasAudio: true}
+
+	// Xing or Info header of VBR files follows the side information
+	side := 32
+	switch {
+	case f.mpeg1 && f.mono:
+		side = 17
+	case !f.mpeg1 && f.mono:
+		side = 9
+	case !f.mpeg1:
+		side = 17
+	}
+	// the last frame of a file may be cut short, it has no room for the header
+	if len(frame) >= 4+side {
+		x := frame[4+side:]
+		if len(x) >= 12 && (string(x[:4]) == "Xing" || string(x[:4]) == "Info") && binary.BigEndian.Uint32(x[4:])&0x1 != 0 {
+			frames := int64(binary.BigEndian.Uint32(x[8:]))
+			info.Duration = float64(frames*f.samples) / float64(f.sampleRate)
+			if info.Duration > 0 {
+				info.Bitrate = int64(float64(audioSize*8) / info.Duration)
+			}
+			return info
+		}
+	}
+
+	info.Bitrate = f.bitrate
+	info.Duration = float64(audioSize*8) / float64(f.bitrate)
+	return info
+}
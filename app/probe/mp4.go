@@ -0,0 +1,219 @@
+package probe
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Max size of boxes read into memory
+const maxBoxSize = 4 << 20
+
+type box struct {
+	typ   string
+	start int64 // offset of the payload
+	size  int64 // size of the payload
+}
+
+// walkBoxes calls fn for every box in [start, end) of ISO base media file
+func walkBoxes(r io.ReadSeeker, start, end int64, fn func(b box) error) error {
+	hdr := make([]byte, 16)
+	for off := start; off+8 <= end; {
+		if _, err := r.Seek(off, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(r, hdr[:8]); err != nil {
+			return err
+		}
+		size := int64(binary.BigEndian.Uint32(hdr[:4]))
+		hdrLen := int64(8)
+		switch size {
+		case 0:
+			size = end - off
+		case 1:
+			if _, err := io.ReadFull(r, hdr[8:16]); err != nil {
+				return err
+			}
+			size = int64(binary.BigEndian.Uint64(hdr[8:16]))
+			hdrLen = 16
+		}
+		if size < hdrLen || off+size > end {
+			return invalid("broken box %q", hdr[4:8])
+		}
+
+		if err := fn(box{typ: string(hdr[4:8]), start: off + hdrLen, size: size - hdrLen}); err != nil {
+			return err
+		}
+		off += size
+	}
+	return nil
+}
+
+func readBox(r io.ReadSeeker, b box) ([]byte, error) {
+	if b.size > maxBoxSize {
+		return nil, invalid("box %q is too large", b.typ)
+	}
+	if _, err := r.Seek(b.start, io.SeekStart); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, b.size)
+	_, err := io.ReadFull(r, buf)
+	return buf, err
+}
+
+// fullBox returns version and the payload after version and flags
+func fullBox(buf []byte) (byte, []byte, error) {
+	if len(buf) < 4 {
+		return 0, nil, invalid("short full box")
+	}
+	return buf[0], buf[4:], nil
+}
+
+type mp4Track struct {
+	handler   string
+	width     int
+	height    int
+	timescale int64
+	duration  int64
+	samples   int64
+}
+
+func probeMP4(r io.ReadSeeker, size int64) (*Info, error) {
+	var (
+		ftyp, moov bool
+		timescale  int64
+		duration   int64
+		tracks     []*mp4Track
+	)
+
+	err := walkBoxes(r, 0, size, func(b box) error {
+		switch b.typ {
+		case "ftyp":
+			ftyp = true
+		case "moov":
+			if !ftyp {
+				return invalid("moov before ftyp")
+			}
+			moov = true
+			return walkBoxes(r, b.start, b.start+b.size, func(b box) error {
+				switch b.typ {
+				case "mvhd":
+					buf, err := readBox(r, b)
+					if err != nil {
+						return err
+					}
+					timescale, duration, err = parseMediaHeader(buf)
+					return err
+				case "trak":
+					t := &mp4Track{}
+					tracks = append(tracks, t)
+					return probeTrack(r, b, t)
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ftyp || !moov {
+		return nil, invalid("no movie box")
+	}
+
+	info := &Info{}
+	if timescale > 0 {
+		info.Duration = float64(duration) / float64(timescale)
+	}
+	for _, t := range tracks {
+		switch t.handler {
+		case "vide":
+			info.HasVideo = true
+			info.Width, info.Height = t.width, t.height
+			if t.duration > 0 {
+				info.FrameRate = rate(t.samples*t.timescale, t.duration)
+			}
+		case "soun":
+			info.HasAudio = true
+		}
+	}
+	return info, nil
+}
+
+func probeTrack(r io.ReadSeeker, trak box, t *mp4Track) error {
+	return walkBoxes(r, trak.start, trak.start+trak.size, func(b box) error {
+		switch b.typ {
+		case "tkhd":
+			buf, err := readBox(r, b)
+			if err != nil {
+				return err
+			}
+			version, p, err := fullBox(buf)
+			if err != nil {
+				return err
+			}
+			// width and height are fixed-point 16.16 at the end of tkhd
+			off := 72
+			if version == 1 {
+				off = 84
+			}
+			if len(p) < off+8 {
+				return invalid("short tkhd")
+			}
+			t.width = int(binary.BigEndian.Uint32(p[off:]) >> 16)
+			t.height = int(binary.BigEndian.Uint32(p[off+4:]) >> 16)
+		case "mdia", "minf", "stbl":
+			return probeTrack(r, b, t)
+		case "mdhd":
+			buf, err := readBox(r, b)
+			if err != nil {
+				return err
+			}
+			t.timescale, t.duration, err = parseMediaHeader(buf)
+			return err
+		case "hdlr":
+			buf, err := readBox(r, b)
+			if err != nil {
+				return err
+			}
+			if len(buf) < 12 {
+				return invalid("short hdlr")
+			}
+			t.handler = string(buf[8:12])
+		case "stts":
+			buf, err := readBox(r, b)
+			if err != nil {
+				return err
+			}
+			_, p, err := fullBox(buf)
+			if err != nil || len(p) < 4 {
+				return invalid("short stts")
+			}
+			n := int(binary.BigEndian.Uint32(p))
+			if len(p) < 4+n*8 {
+				return invalid("short stts")
+			}
+			for i := 0; i < n; i++ {
+				t.samples += int64(binary.BigEndian.Uint32(p[4+i*8:]))
+			}
+		}
+		return nil
+	})
+}
+
+// parseMediaHeader reads timescale and duration of mvhd and mdhd
+func parseMediaHeader(buf []byte) (int64, int64, error) {
+	version, p, err := fullBox(buf)
+	if err != nil {
+		return 0, 0, err
+	}
+	if version == 1 {
+		if len(p) < 28 {
+			return 0, 0, invalid("short media header")
+		}
+		return int64(binary.BigEndian.Uint32(p[16:])), int64(binary.BigEndian.Uint64(p[20:])), nil
+	}
+	if len(p) < 16 {
+		return 0, 0, invalid("short media header")
+	}
+	return int64(binary.BigEndian.Uint32(p[8:])), int64(binary.BigEndian.Uint32(p[12:])), nil
+}
@@ -0,0 +1,87 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// Range to search the last page from the end of file
+const oggTailLen = 64 << 10
+
+// oggPage reads the header of page at the head of buf and returns granule position and body
+func oggPage(buf []byte) (int64, []byte, bool) {
+	if len(buf) < 27 || string(buf[:4]) != "OggS" || buf[4] != 0 {
+		return 0, nil, false
+	}
+	granule := int64(binary.LittleEndian.Uint64(buf[6:14]))
+	segments := int(buf[26])
+	if len(buf) < 27+segments {
+		return 0, nil, false
+	}
+	var bodyLen int
+	for _, l := range buf[27 : 27+segments] {
+		bodyLen += int(l)
+	}
+	body := buf[27+segments:]
+	if len(body) > bodyLen {
+		body = body[:bodyLen]
+	}
+	return granule, body, true
+}
+
+func probeOgg(r io.ReadSeeker, size int64) (*Info, error) {
+	head := make([]byte, 4096)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	_, body, ok := oggPage(head[:n])
+	if !ok {
+		return nil, invalid("no Ogg page")
+	}
+
+	info := &Info{HasAudio: true}
+	var sampleRate, preSkip int64
+	switch {
+	case len(body) >= 28 && body[0] == 0x01 && string(body[1:7]) == "vorbis":
+		sampleRate = int64(binary.LittleEndian.Uint32(body[12:16]))
+		info.Bitrate = int64(int32(binary.LittleEndian.Uint32(body[20:24])))
+		if info.Bitrate < 0 {
+			info.Bitrate = 0
+		}
+	case len(body) >= 19 && string(body[:8]) == "OpusHead":
+		// granule position of Opus is always 48kHz
+		sampleRate = 48000
+		preSkip = int64(binary.LittleEndian.Uint16(body[10:12]))
+	default:
+		return nil, invalid("Ogg stream is neither Vorbis nor Opus")
+	}
+	if sampleRate == 0 {
+		return nil, invalid("no sample rate")
+	}
+
+	tail := int64(oggTailLen)
+	if tail > size {
+		tail = size
+	}
+	if _, err := r.Seek(size-tail, io.SeekStart); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, tail)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	for i := bytes.LastIndex(buf, []byte("OggS")); i >= 0; i = bytes.LastIndex(buf[:i], []byte("OggS")) {
+		granule, _, ok := oggPage(buf[i:])
+		if ok && granule > 0 {
+			info.Duration = float64(granule-preSkip) / float64(sampleRate)
+			if info.Bitrate == 0 && info.Duration > 0 {
+				info.Bitrate = int64(float64(size*8) / info.Duration)
+			}
+			return info, nil
+		}
+	}
+
+	return nil, invalid("no Ogg page with granule position")
+}
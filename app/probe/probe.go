@@ -0,0 +1,86 @@
+package probe
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrInvalidContainer is returned when the file doesn't have the structure of its type
+	ErrInvalidContainer = errors.New("container does not match the file type")
+
+	// ErrUnsupported is returned for types which can't be probed
+	ErrUnsupported = errors.New("unsupported type for probing")
+)
+
+// Info metadata of video and audio
+type Info struct {
+	// Dimensions of the video stream
+	Width  int
+	Height int
+
+	// Duration in seconds
+	Duration float64
+
+	// Frames per second as a fraction like "30/1", empty if unknown
+	FrameRate string
+
+	// Average bitrate in bits per second
+	Bitrate int64
+
+	// Which kinds of stream the file has
+	HasVideo bool
+	HasAudio bool
+}
+
+// Probe reads metadata of the file of MIME type
+func Probe(r io.ReadSeeker, mime string) (*Info, error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var info *Info
+	switch mime {
+	case "video/mp4", "video/quicktime", "audio/mp4":
+		info, err = probeMP4(r, size)
+	case "video/webm":
+		info, err = probeWebM(r, size)
+	case "audio/mpeg":
+		info, err = probeMP3(r, size)
+	case "audio/ogg":
+		info, err = probeOgg(r, size)
+	default:
+		return nil, ErrUnsupported
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("%w: unexpected end of file", ErrInvalidContainer)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if info.Bitrate == 0 && info.Duration > 0 {
+		info.Bitrate = int64(float64(size*8) / info.Duration)
+	}
+	return info, nil
+}
+
+// rate formats num/den as a reduced fraction
+func rate(num, den int64) string {
+	if num <= 0 || den <= 0 {
+		return ""
+	}
+	a, b := num, den
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return fmt.Sprintf("%d/%d", num/a, den/a)
+}
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: "+format, append([]interface{}{ErrInvalidContainer}, args...)...)
+}
@@ -0,0 +1,191 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbe(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		info *Info
+		err  error
+	}
+	cases := map[string]struct {
+		file []byte
+		mime string
+		want want
+	}{
+		"mp4": {
+			file: buildMP4(true),
+			mime: "video/mp4",
+			want: want{info: &Info{Width: 320, Height: 240, Duration: 10, FrameRate: "30/1", HasVideo: true}},
+		},
+		"mp4 without moov": {
+			file: buildMP4(false),
+			mime: "video/mp4",
+			want: want{err: ErrInvalidContainer},
+		},
+		"webm": {
+			file: buildWebM("webm"),
+			mime: "video/webm",
+			want: want{info: &Info{Width: 640, Height: 480, Duration: 5, FrameRate: "25/1", HasVideo: true}},
+		},
+		"matroska": {
+			file: buildWebM("matroska"),
+			mime: "video/webm",
+			want: want{err: ErrInvalidContainer},
+		},
+		"mp3": {
+			file: buildMP3(false),
+			mime: "audio/mpeg",
+			want: want{info: &Info{Duration: 4170 * 8 / 128000.0, Bitrate: 128000, HasAudio: true}},
+		},
+		"mp3 with ID3": {
+			file: buildMP3(true),
+			mime: "audio/mpeg",
+			want: want{info: &Info{Duration: 4170 * 8 / 128000.0, Bitrate: 128000, HasAudio: true}},
+		},
+		"mp3 with a short frame": {
+			file: buildMP3(true)[:20+4+10],
+			mime: "audio/mpeg",
+			want: want{info: &Info{Duration: 14 * 8 / 128000.0, Bitrate: 128000, HasAudio: true}},
+		},
+		"not mp3": {
+			file: bytes.Repeat([]byte("not an mp3 "), 100),
+			mime: "audio/mpeg",
+			want: want{err: ErrInvalidContainer},
+		},
+		"ogg vorbis": {
+			file: buildOgg(),
+			mime: "audio/ogg",
+			want: want{info: &Info{Duration: 10, Bitrate: 128000, HasAudio: true}},
+		},
+		"truncated ogg": {
+			file: buildOgg()[:20],
+			mime: "audio/ogg",
+			want: want{err: ErrInvalidContainer},
+		},
+		"unsupported": {
+			file: []byte("GIF89a"),
+			mime: "image/gif",
+			want: want{err: ErrUnsupported},
+		},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			info, err := Probe(bytes.NewReader(tt.file), tt.mime)
+			if tt.want.err != nil {
+				assert.ErrorIs(t, err, tt.want.err)
+				return
+			}
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want.info.Width, info.Width)
+				assert.Equal(t, tt.want.info.Height, info.Height)
+				assert.InDelta(t, tt.want.info.Duration, info.Duration, 0.001)
+				assert.Equal(t, tt.want.info.FrameRate, info.FrameRate)
+				assert.Equal(t, tt.want.info.HasVideo, info.HasVideo)
+				assert.Equal(t, tt.want.info.HasAudio, info.HasAudio)
+				if tt.want.info.Bitrate != 0 {
+					assert.Equal(t, tt.want.info.Bitrate, info.Bitrate)
+				}
+			}
+		})
+	}
+}
+
+func be32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+func mp4Box(typ string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	return bytes.Join([][]byte{be32(uint32(8 + len(body))), []byte(typ), body}, nil)
+}
+
+func buildMP4(withMoov bool) []byte {
+	ftyp := mp4Box("ftyp", []byte("isom"), be32(0x200), []byte("isom"))
+	if !withMoov {
+		return bytes.Join([][]byte{ftyp, mp4Box("mdat", make([]byte, 16))}, nil)
+	}
+
+	mvhd := mp4Box("mvhd", be32(0), be32(0), be32(0), be32(1000), be32(10000), make([]byte, 80))
+	tkhd := mp4Box("tkhd", be32(0), make([]byte, 72), be32(320<<16), be32(240<<16))
+	mdhd := mp4Box("mdhd", be32(0), be32(0), be32(0), be32(30000), be32(300000), make([]byte, 4))
+	hdlr := mp4Box("hdlr", be32(0), be32(0), []byte("vide"), make([]byte, 13))
+	stts := mp4Box("stts", be32(0), be32(1), be32(300), be32(1000))
+	trak := mp4Box("trak", tkhd, mp4Box("mdia", mdhd, hdlr, mp4Box("minf", mp4Box("stbl", stts))))
+
+	return bytes.Join([][]byte{ftyp, mp4Box("moov", mvhd, trak), mp4Box("mdat", make([]byte, 16))}, nil)
+}
+
+func ebml(id []byte, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	size := make([]byte, 8)
+	binary.BigEndian.PutUint64(size, uint64(len(body)))
+	size[0] = 0x01
+	return bytes.Join([][]byte{id, size, body}, nil)
+}
+
+func buildWebM(docType string) []byte {
+	duration := make([]byte, 8)
+	binary.BigEndian.PutUint64(duration, math.Float64bits(5000))
+
+	header := ebml([]byte{0x1A, 0x45, 0xDF, 0xA3}, ebml([]byte{0x42, 0x82}, []byte(docType)))
+	info := ebml([]byte{0x15, 0x49, 0xA9, 0x66},
+		ebml([]byte{0x2A, 0xD7, 0xB1}, []byte{0x0F, 0x42, 0x40}),
+		ebml([]byte{0x44, 0x89}, duration))
+	tracks := ebml([]byte{0x16, 0x54, 0xAE, 0x6B},
+		ebml([]byte{0xAE},
+			ebml([]byte{0x83}, []byte{0x01}),
+			ebml([]byte{0x23, 0xE3, 0x83}, be32(40000000)),
+			ebml([]byte{0xE0},
+				ebml([]byte{0xB0}, []byte{0x02, 0x80}),
+				ebml([]byte{0xBA}, []byte{0x01, 0xE0}))))
+	cluster := ebml([]byte{0x1F, 0x43, 0xB6, 0x75}, make([]byte, 16))
+
+	return bytes.Join([][]byte{header, ebml([]byte{0x18, 0x53, 0x80, 0x67}, info, tracks, cluster)}, nil)
+}
+
+func buildMP3(withID3 bool) []byte {
+	var buf bytes.Buffer
+	if withID3 {
+		buf.Write([]byte{'I', 'D', '3', 3, 0, 0, 0, 0, 0, 10})
+		buf.Write(make([]byte, 10))
+	}
+	// MPEG-1 Layer III, 128kbps, 44.1kHz: 417 bytes per frame
+	for i := 0; i < 10; i++ {
+		buf.Write([]byte{0xFF, 0xFB, 0x90, 0x00})
+		buf.Write(make([]byte, 413))
+	}
+	return buf.Bytes()
+}
+
+func oggPageOf(granule uint64, body []byte) []byte {
+	hdr := make([]byte, 27)
+	copy(hdr, "OggS")
+	binary.LittleEndian.PutUint64(hdr[6:], granule)
+	hdr[26] = 1
+	return bytes.Join([][]byte{hdr, {byte(len(body))}, body}, nil)
+}
+
+func buildOgg() []byte {
+	id := make([]byte, 30)
+	id[0] = 0x01
+	copy(id[1:], "vorbis")
+	id[11] = 1
+	binary.LittleEndian.PutUint32(id[12:], 44100)
+	binary.LittleEndian.PutUint32(id[20:], 128000)
+
+	return bytes.Join([][]byte{oggPageOf(0, id), oggPageOf(441000, []byte{0})}, nil)
+}
@@ -0,0 +1,276 @@
+package probe
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// EBML element IDs
+const (
+	idEBML            = 0x1A45DFA3
+	idDocType         = 0x4282
+	idSegment         = 0x18538067
+	idInfo            = 0x1549A966
+	idTimecodeScale   = 0x2AD7B1
+	idDuration        = 0x4489
+	idTracks          = 0x1654AE6B
+	idTrackEntry      = 0xAE
+	idTrackType       = 0x83
+	idDefaultDuration = 0x23E383
+	idVideo           = 0xE0
+	idPixelWidth      = 0xB0
+	idPixelHeight     = 0xBA
+	idCluster         = 0x1F43B675
+)
+
+// Size of elements whose size is unknown
+const unknownSize = -1
+
+type ebmlReader struct {
+	r   io.ReadSeeker
+	pos int64
+	buf [8]byte
+}
+
+// readVint reads variable length integer, keeping the length marker if it is an ID
+func (e *ebmlReader) readVint(keepMarker bool) (int64, int, error) {
+	if _, err := io.ReadFull(e.r, e.buf[:1]); err != nil {
+		return 0, 0, err
+	}
+	first := e.buf[0]
+	n := 1
+	for mask := byte(0x80); n <= 8 && first&mask == 0; mask >>= 1 {
+		n++
+	}
+	if n > 8 {
+		return 0, 0, invalid("broken vint")
+	}
+	if _, err := io.ReadFull(e.r, e.buf[1:n]); err != nil {
+		return 0, 0, err
+	}
+	e.pos += int64(n)
+
+	v := int64(first)
+	if !keepMarker {
+		v &= int64(0xFF >> uint(n))
+	}
+	allOnes := v == int64(0xFF>>uint(n))
+	for i := 1; i < n; i++ {
+		v = v<<8 | int64(e.buf[i])
+		allOnes = allOnes && e.buf[i] == 0xFF
+	}
+	if !keepMarker && allOnes {
+		return unknownSize, n, nil
+	}
+	return v, n, nil
+}
+
+func (e *ebmlReader) next() (uint32, int64, error) {
+	id, n, err := e.readVint(true)
+	if err != nil {
+		return 0, 0, err
+	} else if n > 4 {
+		return 0, 0, invalid("broken element id")
+	}
+	size, _, err := e.readVint(false)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint32(id), size, nil
+}
+
+func (e *ebmlReader) skip(size int64) error {
+	e.pos += size
+	_, err := e.r.Seek(e.pos, io.SeekStart)
+	return err
+}
+
+func (e *ebmlReader) read(size int64) ([]byte, error) {
+	if size < 0 || size > maxBoxSize {
+		return nil, invalid("element is too large")
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(e.r, buf); err != nil {
+		return nil, err
+	}
+	e.pos += size
+	return buf, nil
+}
+
+func (e *ebmlReader) readUint(size int64) (int64, error) {
+	buf, err := e.read(size)
+	if err != nil || len(buf) > 8 {
+		return 0, invalid("broken uint")
+	}
+	var v int64
+	for _, b := range buf {
+		v = v<<8 | int64(b)
+	}
+	return v, nil
+}
+
+func (e *ebmlReader) readFloat(size int64) (float64, error) {
+	buf, err := e.read(size)
+	if err != nil {
+		return 0, err
+	}
+	switch len(buf) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(buf))), nil
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(buf)), nil
+	}
+	return 0, invalid("broken float")
+}
+
+// children calls fn for the children of element ending at end, unknownSize runs until EOF
+func (e *ebmlReader) children(end int64, fn func(id uint32, size int64) error) error {
+	for end == unknownSize || e.pos < end {
+		id, size, err := e.next()
+		if err == io.EOF && end == unknownSize {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		start := e.pos
+		if err := fn(id, size); err != nil {
+			return err
+		}
+		if size == unknownSize {
+			return nil
+		}
+		if e.pos != start+size {
+			if err := e.skip(start + size - e.pos); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// errStop stops walking elements
+var errStop = errors.New("stop")
+
+func probeWebM(r io.ReadSeeker, size int64) (*Info, error) {
+	e := &ebmlReader{r: r}
+
+	id, hdrSize, err := e.next()
+	if err != nil {
+		return nil, err
+	} else if id != idEBML || hdrSize == unknownSize {
+		return nil, invalid("no EBML header")
+	}
+	var docType string
+	err = e.children(e.pos+hdrSize, func(id uint32, size int64) error {
+		if id == idDocType {
+			buf, err := e.read(size)
+			docType = string(buf)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	} else if docType != "webm" {
+		return nil, invalid("doc type %q is not webm", docType)
+	}
+
+	id, segSize, err := e.next()
+	if err != nil {
+		return nil, err
+	} else if id != idSegment {
+		return nil, invalid("no segment")
+	}
+	segEnd := int64(unknownSize)
+	if segSize != unknownSize {
+		segEnd = e.pos + segSize
+	}
+
+	info := &Info{}
+	var (
+		timecodeScale       = int64(1000000)
+		duration            float64
+		foundInfo, foundTrk bool
+	)
+	err = e.children(segEnd, func(id uint32, size int64) error {
+		switch id {
+		case idInfo:
+			foundInfo = true
+			return e.children(e.pos+size, func(id uint32, size int64) error {
+				var err error
+				switch id {
+				case idTimecodeScale:
+					timecodeScale, err = e.readUint(size)
+				case idDuration:
+					duration, err = e.readFloat(size)
+				}
+				return err
+			})
+		case idTracks:
+			foundTrk = true
+			return e.children(e.pos+size, func(id uint32, size int64) error {
+				if id != idTrackEntry {
+					return nil
+				}
+				return probeTrackEntry(e, e.pos+size, info)
+			})
+		case idCluster:
+			// metadata precedes media data
+			return errStop
+		}
+		if foundInfo && foundTrk {
+			return errStop
+		}
+		return nil
+	})
+	if err != nil && err != errStop {
+		return nil, err
+	}
+	if !foundTrk {
+		return nil, invalid("no tracks")
+	}
+
+	info.Duration = duration * float64(timecodeScale) / 1e9
+	return info, nil
+}
+
+func probeTrackEntry(e *ebmlReader, end int64, info *Info) error {
+	var trackType, defaultDuration int64
+	var width, height int64
+	err := e.children(end, func(id uint32, size int64) error {
+		var err error
+		switch id {
+		case idTrackType:
+			trackType, err = e.readUint(size)
+		case idDefaultDuration:
+			defaultDuration, err = e.readUint(size)
+		case idVideo:
+			err = e.children(e.pos+size, func(id uint32, size int64) error {
+				var err error
+				switch id {
+				case idPixelWidth:
+					width, err = e.readUint(size)
+				case idPixelHeight:
+					height, err = e.readUint(size)
+				}
+				return err
+			})
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	switch trackType {
+	case 1:
+		info.HasVideo = true
+		info.Width, info.Height = int(width), int(height)
+		info.FrameRate = rate(1000000000, defaultDuration)
+	case 2:
+		info.HasAudio = true
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	defaultFree   = 5
+	defaultBase   = time.Second
+	defaultMax    = 15 * time.Minute
+	defaultForget = time.Hour
+)
+
+// Lockout tracks failed attempts of keys (e.g. username and IP) and locks
+// them with exponential backoff
+//
+// Attempts are reserved with Begin before the expensive check (e.g. bcrypt),
+// so concurrent attempts cannot exceed the budget. Once a key is over the budget,
+// one attempt at a time is allowed after each lock expires.
+type Lockout struct {
+	// Number of failures before locking
+	Free int
+
+	// Lock duration of the first failure over Free, doubled on each failure up to Max
+	Base time.Duration
+	Max  time.Duration
+
+	// Failures are forgotten after this duration without attempts
+	Forget time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]*lockEntry
+	nextSweep time.Time
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+type lockEntry struct {
+	failures int
+	// attempts begun but not finished
+	pending int
+	until   time.Time
+	last    time.Time
+}
+
+// Create Lockout with default budget
+func NewLockout() *Lockout {
+	return &Lockout{
+		Free:    defaultFree,
+		Base:    defaultBase,
+		Max:     defaultMax,
+		Forget:  defaultForget,
+		entries: make(map[string]*lockEntry),
+		now:     time.Now,
+	}
+}
+
+// Attempt reserved by Lockout.Begin, either Fail or Succeed must be called
+type Attempt struct {
+	l    *Lockout
+	keys []string
+	once sync.Once
+}
+
+// Begin reserves an attempt of keys
+// If any key is locked nothing is reserved, and the duration to wait is returned.
+// Nil Lockout allows all attempts.
+func (l *Lockout) Begin(keys ...string) (*Attempt, time.Duration) {
+	if l == nil {
+		return nil, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	var wait time.Duration
+	for _, key := range keys {
+		e, ok := l.entries[key]
+		if !ok {
+			continue
+		}
+		if d := e.until.Sub(now); d > wait {
+			wait = d
+		} else if e.failures+e.pending >= l.Free && e.pending > 0 && wait == 0 {
+			// over the budget, wait for the attempt in flight
+			wait = l.Base
+		}
+	}
+	if wait > 0 {
+		return nil, wait
+	}
+
+	for _, key := range keys {
+		e, ok := l.entries[key]
+		if !ok {
+			e = &lockEntry{}
+			l.entries[key] = e
+		}
+		e.pending++
+		e.last = now
+	}
+
+	return &Attempt{l: l, keys: keys}, 0
+}
+
+// Fail records the failure of the attempt and locks keys over the budget
+func (a *Attempt) Fail() {
+	if a == nil {
+		return
+	}
+	a.once.Do(func() {
+		l := a.l
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		now := l.now()
+		for _, key := range a.keys {
+			e := l.entries[key]
+			if e == nil {
+				e = &lockEntry{pending: 1}
+				l.entries[key] = e
+			}
+			e.pending--
+			e.failures++
+			e.last = now
+			if over := e.failures - l.Free; over >= 0 {
+				e.until = now.Add(l.backoff(over))
+			}
+		}
+	})
+}
+
+// Succeed finishes the attempt and clears failures of reset keys
+// Keys shared by many accounts (e.g. IP) should not be reset, otherwise
+// a valid credential lets password spraying continue.
+func (a *Attempt) Succeed(reset ...string) {
+	if a == nil {
+		return
+	}
+	a.once.Do(func() {
+		l := a.l
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		for _, key := range a.keys {
+			if e := l.entries[key]; e != nil {
+				e.pending--
+			}
+		}
+		for _, key := range reset {
+			if e := l.entries[key]; e != nil && e.pending <= 0 {
+				delete(l.entries, key)
+			} else if e != nil {
+				e.failures = 0
+				e.until = time.Time{}
+			}
+		}
+	})
+}
+
+// Cancel releases the attempt without recording the result, e.g. on internal errors
+func (a *Attempt) Cancel() {
+	a.Succeed()
+}
+
+// backoff returns lock duration of the over-th failure over the budget
+func (l *Lockout) backoff(over int) time.Duration {
+	d := l.Base
+	for i := 0; i < over && d < l.Max; i++ {
+		d *= 2
+	}
+	if d > l.Max {
+		d = l.Max
+	}
+	return d
+}
+
+// sweep removes entries without attempts for Forget, l.mu must be held
+func (l *Lockout) sweep(now time.Time) {
+	if now.Before(l.nextSweep) {
+		return
+	}
+	for key, e := range l.entries {
+		if e.pending <= 0 && now.Sub(e.last) >= l.Forget && !now.Before(e.until) {
+			delete(l.entries, key)
+		}
+	}
+	l.nextSweep = now.Add(sweepInterval)
+}
@@ -0,0 +1,157 @@
+package ratelimit
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLockout(now *time.Time) *Lockout {
+	l := NewLockout()
+	l.Free = 3
+	l.Base = time.Second
+	l.Max = 4 * time.Second
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+func TestLockoutBackoff(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newTestLockout(&now)
+
+	// free failures
+	for i := 0; i < 2; i++ {
+		a, wait := l.Begin("user:john")
+		assert.Zero(t, wait)
+		a.Fail()
+	}
+
+	// lock is doubled on each failure up to Max
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		a, wait := l.Begin("user:john")
+		if !assert.Zero(t, wait) {
+			return
+		}
+		a.Fail()
+
+		_, wait = l.Begin("user:john")
+		assert.Equal(t, want, wait)
+		now = now.Add(want)
+	}
+
+	// other keys are not locked
+	a, wait := l.Begin("user:jane")
+	assert.Zero(t, wait)
+	a.Succeed()
+
+	// success resets failures
+	a, _ = l.Begin("user:john", "ip:192.0.2.1")
+	a.Succeed("user:john")
+	a, wait = l.Begin("user:john")
+	assert.Zero(t, wait)
+	a.Cancel()
+
+	assert.Equal(t, 0, l.entries["user:john"].failures)
+
+	// failures are forgotten after Forget
+	a, _ = l.Begin("user:bob")
+	a.Fail()
+	now = now.Add(l.Forget + sweepInterval)
+	l.Begin("user:alice")
+	_, ok := l.entries["user:bob"]
+	assert.False(t, ok)
+}
+
+func TestLockoutLocksAnyKey(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newTestLockout(&now)
+
+	// spraying a password over usernames locks the address
+	for _, user := range []string{"user:a", "user:b", "user:c"} {
+		a, wait := l.Begin(user, "ip:192.0.2.1")
+		assert.Zero(t, wait)
+		a.Fail()
+	}
+
+	_, wait := l.Begin("user:d", "ip:192.0.2.1")
+	assert.Equal(t, time.Second, wait)
+
+	// nothing is reserved for locked attempts
+	assert.Nil(t, l.entries["user:d"])
+}
+
+func TestLockoutConcurrentAttempts(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newTestLockout(&now)
+
+	const n = 100
+	var (
+		wg       sync.WaitGroup
+		start    = make(chan struct{})
+		begun    int32
+		attempts = make(chan *Attempt, n)
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if a, wait := l.Begin("user:john", "ip:192.0.2.1"); wait == 0 {
+				atomic.AddInt32(&begun, 1)
+				attempts <- a
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(attempts)
+
+	// attempts in flight count against the budget
+	assert.Equal(t, int32(l.Free), begun)
+
+	var fails sync.WaitGroup
+	for a := range attempts {
+		fails.Add(1)
+		go func(a *Attempt) {
+			defer fails.Done()
+			a.Fail()
+			// finishing twice is ignored
+			a.Fail()
+		}(a)
+	}
+	fails.Wait()
+
+	e := l.entries["user:john"]
+	assert.Equal(t, l.Free, e.failures)
+	assert.Equal(t, 0, e.pending)
+
+	_, wait := l.Begin("user:john")
+	assert.Equal(t, time.Second, wait)
+
+	// after the lock only one attempt is allowed at a time
+	now = now.Add(time.Second)
+	begun = 0
+	var probes sync.WaitGroup
+	for i := 0; i < n; i++ {
+		probes.Add(1)
+		go func() {
+			defer probes.Done()
+			if _, wait := l.Begin("user:john"); wait == 0 {
+				atomic.AddInt32(&begun, 1)
+			}
+		}()
+	}
+	probes.Wait()
+	assert.Equal(t, int32(1), begun)
+}
+
+func TestNilLockout(t *testing.T) {
+	var l *Lockout
+	a, wait := l.Begin("user:john")
+	assert.Zero(t, wait)
+	a.Fail()
+	a.Succeed()
+}
@@ -0,0 +1,148 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+	"yatter-backend-go/app/handler/httperror"
+	"yatter-backend-go/app/logger"
+)
+
+// KeyFunc returns the key whose requests share a budget
+type KeyFunc func(r *http.Request) string
+
+// Policy is the budget of a route
+type Policy struct {
+	// Name separates budgets of policies with the same key
+	Name string
+
+	// Number of requests allowed in Window
+	Limit  int
+	Window time.Duration
+
+	Key KeyFunc
+}
+
+// Limiter throttles requests exceeding the budget of policies
+type Limiter struct {
+	store   Store
+	enabled func() bool
+}
+
+// Create Limiter, enabled is checked on each request (nil means always enabled)
+func New(store Store, enabled func() bool) *Limiter {
+	return &Limiter{store: store, enabled: enabled}
+}
+
+// Limit returns middleware applying p
+// It sets X-RateLimit-* headers and responds with Too Many Requests (429)
+// when the budget is exhausted. Nil Limiter passes all requests, so does
+// the middleware when the store fails.
+func (l *Limiter) Limit(p Policy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if l == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if l.enabled != nil && !l.enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res, err := l.store.Take(r.Context(), p.Name+":"+p.Key(r), p.Limit, p.Window)
+			if err != nil {
+				logger.FromContext(r.Context()).Warn("rate limit store failed", "policy", p.Name, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("X-RateLimit-Reset", res.Reset.UTC().Format(time.RFC3339))
+
+			if !res.Allowed {
+				SetRetryAfter(w, time.Until(res.Reset))
+				logger.FromContext(r.Context()).Info("rate limited", "policy", p.Name)
+				httperror.Error(w, http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Exceeded reports whether the budget of p is exhausted without consuming it,
+// and returns the duration until it is restored. Policies checked with Exceeded
+// are consumed with Charge only by the requests they limit, e.g. failed logins.
+func (l *Limiter) Exceeded(r *http.Request, p Policy) (time.Duration, bool) {
+	if l == nil || (l.enabled != nil && !l.enabled()) {
+		return 0, false
+	}
+
+	res, err := l.store.Peek(r.Context(), p.Name+":"+p.Key(r), p.Limit, p.Window)
+	if err != nil {
+		logger.FromContext(r.Context()).Warn("rate limit store failed", "policy", p.Name, "error", err)
+		return 0, false
+	} else if res.Allowed {
+		return 0, false
+	}
+
+	logger.FromContext(r.Context()).Info("rate limited", "policy", p.Name)
+	return time.Until(res.Reset), true
+}
+
+// Charge consumes a request of r from the budget of p
+func (l *Limiter) Charge(r *http.Request, p Policy) {
+	if l == nil || (l.enabled != nil && !l.enabled()) {
+		return
+	}
+
+	if _, err := l.store.Take(r.Context(), p.Name+":"+p.Key(r), p.Limit, p.Window); err != nil {
+		logger.FromContext(r.Context()).Warn("rate limit store failed", "policy", p.Name, "error", err)
+	}
+}
+
+// SetRetryAfter sets Retry-After header in seconds, at least 1
+func SetRetryAfter(w http.ResponseWriter, d time.Duration) {
+	retry := math.Ceil(d.Seconds())
+	if retry < 1 {
+		retry = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(retry)))
+}
+
+// ClientIP returns the client address without port, middleware.RealIP must run before
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ByIP keys requests by the client address
+func ByIP(r *http.Request) string {
+	return "ip:" + ClientIP(r)
+}
+
+// ByToken keys requests by the credentials, requests without them are keyed by IP
+func ByToken(r *http.Request) string {
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		token = r.Header.Get("Authentication")
+	}
+	if token == "" {
+		return ByIP(r)
+	}
+
+	// credentials are not kept in memory as they are
+	sum := sha256.Sum256([]byte(token))
+	return "token:" + hex.EncodeToString(sum[:16])
+}
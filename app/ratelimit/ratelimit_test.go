@@ -0,0 +1,160 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		res, err := s.Take(ctx, "a", 2, time.Minute)
+		assert.Nil(t, err)
+		assert.Equal(t, i <= 2, res.Allowed)
+		assert.Equal(t, now.Add(time.Minute), res.Reset)
+	}
+
+	// keys have their own budget
+	res, _ := s.Take(ctx, "b", 2, time.Minute)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 1, res.Remaining)
+
+	// budget is restored after the window
+	now = now.Add(time.Minute)
+	res, _ = s.Take(ctx, "a", 2, time.Minute)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 1, res.Remaining)
+
+	// expired windows are removed
+	now = now.Add(2 * sweepInterval)
+	s.Take(ctx, "c", 2, time.Minute)
+	assert.Len(t, s.windows, 1)
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, int, time.Duration) (Result, error) {
+	return Result{}, errors.New("connection refused")
+}
+
+func (failingStore) Peek(context.Context, string, int, time.Duration) (Result, error) {
+	return Result{}, errors.New("connection refused")
+}
+
+func TestLimiter(t *testing.T) {
+	policy := Policy{Name: "test", Limit: 2, Window: time.Minute, Key: ByIP}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := map[string]struct {
+		limiter *Limiter
+		status  []int
+		headers bool
+	}{
+		"throttled": {
+			limiter: New(NewMemoryStore(), nil),
+			status:  []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+			headers: true,
+		},
+		"disabled": {
+			limiter: New(NewMemoryStore(), func() bool { return false }),
+			status:  []int{http.StatusOK, http.StatusOK, http.StatusOK},
+		},
+		"nil limiter": {
+			limiter: nil,
+			status:  []int{http.StatusOK, http.StatusOK, http.StatusOK},
+		},
+		"store failure": {
+			limiter: New(failingStore{}, nil),
+			status:  []int{http.StatusOK, http.StatusOK, http.StatusOK},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			h := tt.limiter.Limit(policy)(ok)
+
+			for i, status := range tt.status {
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest(http.MethodPost, "/", nil)
+				h.ServeHTTP(w, r)
+
+				assert.Equal(t, status, w.Code)
+				if !tt.headers {
+					assert.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+					continue
+				}
+
+				remaining := policy.Limit - i - 1
+				if remaining < 0 {
+					remaining = 0
+				}
+				assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+				assert.Equal(t, strconv.Itoa(remaining), w.Header().Get("X-RateLimit-Remaining"))
+				_, err := time.Parse(time.RFC3339, w.Header().Get("X-RateLimit-Reset"))
+				assert.Nil(t, err)
+				if status == http.StatusTooManyRequests {
+					retry, err := strconv.Atoi(w.Header().Get("Retry-After"))
+					assert.Nil(t, err)
+					assert.True(t, retry >= 1 && retry <= 60)
+				}
+			}
+		})
+	}
+}
+
+func TestKeys(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	assert.Equal(t, "ip:192.0.2.1", ByIP(r))
+	assert.Equal(t, "ip:192.0.2.1", ByToken(r))
+
+	// middleware.RealIP sets the address without port
+	r.RemoteAddr = "192.0.2.2"
+	assert.Equal(t, "ip:192.0.2.2", ByIP(r))
+
+	r.SetBasicAuth("john", "secret")
+	key := ByToken(r)
+	assert.Contains(t, key, "token:")
+	assert.NotContains(t, key, r.Header.Get("Authorization"))
+}
+
+func TestLimiterCharge(t *testing.T) {
+	policy := Policy{Name: "test", Limit: 2, Window: time.Minute, Key: ByIP}
+	l := New(NewMemoryStore(), nil)
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+
+	// checking doesn't consume the budget
+	for i := 0; i < 3; i++ {
+		_, exceeded := l.Exceeded(r, policy)
+		assert.False(t, exceeded)
+	}
+
+	l.Charge(r, policy)
+	_, exceeded := l.Exceeded(r, policy)
+	assert.False(t, exceeded)
+
+	l.Charge(r, policy)
+	wait, exceeded := l.Exceeded(r, policy)
+	assert.True(t, exceeded)
+	assert.True(t, 0 < wait && wait <= time.Minute)
+
+	// failures of the store and nil Limiter pass
+	_, exceeded = New(failingStore{}, nil).Exceeded(r, policy)
+	assert.False(t, exceeded)
+	var nilLimiter *Limiter
+	nilLimiter.Charge(r, policy)
+	_, exceeded = nilLimiter.Exceeded(r, policy)
+	assert.False(t, exceeded)
+}
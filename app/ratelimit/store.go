@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store counts requests of each key in fixed windows
+//
+// MemoryStore is enough for a single instance, deployments running multiple
+// instances implement Store on a shared storage (e.g. Redis INCR and PEXPIRE)
+// so that all instances consume the same budget.
+type Store interface {
+	// Take consumes a request of key from the budget of limit per window
+	Take(ctx context.Context, key string, limit int, window time.Duration) (Result, error)
+
+	// Peek reads the budget of key without consuming, Allowed reports whether a request is left
+	Peek(ctx context.Context, key string, limit int, window time.Duration) (Result, error)
+}
+
+// Result of Store.Take
+type Result struct {
+	Limit     int
+	Remaining int
+
+	// Time when the budget is restored
+	Reset time.Time
+
+	// Whether the request is within the budget
+	Allowed bool
+}
+
+// NewResult builds Result from count of requests in the window including the current one
+func NewResult(limit, count int, reset time.Time) Result {
+	remaining := limit - count
+	if remaining < 0 {
+		remaining = 0
+	}
+	return Result{Limit: limit, Remaining: remaining, Reset: reset, Allowed: count <= limit}
+}
+
+// Interval to remove expired windows of MemoryStore
+const sweepInterval = time.Minute
+
+type window struct {
+	count int
+	reset time.Time
+}
+
+// MemoryStore is Store in memory of the process
+type MemoryStore struct {
+	mu        sync.Mutex
+	windows   map[string]*window
+	nextSweep time.Time
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// Create MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		windows: make(map[string]*window),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit int, d time.Duration) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := s.window(key, d)
+	w.count++
+
+	return NewResult(limit, w.count, w.reset), nil
+}
+
+func (s *MemoryStore) Peek(_ context.Context, key string, limit int, d time.Duration) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := s.window(key, d)
+	res := NewResult(limit, w.count, w.reset)
+	res.Allowed = w.count < limit
+	return res, nil
+}
+
+// window returns the current window of key, s.mu must be held
+func (s *MemoryStore) window(key string, d time.Duration) *window {
+	now := s.now()
+	if now.After(s.nextSweep) {
+		for k, w := range s.windows {
+			if !now.Before(w.reset) {
+				delete(s.windows, k)
+			}
+		}
+		s.nextSweep = now.Add(sweepInterval)
+	}
+
+	w, ok := s.windows[key]
+	if !ok || !now.Before(w.reset) {
+		w = &window{reset: now.Add(d)}
+		s.windows[key] = w
+	}
+	return w
+}
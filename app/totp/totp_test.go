@@ -0,0 +1,92 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test vectors of RFC 6238 Appendix B for SHA1
+func TestHOTPVectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+
+	tests := map[string]struct {
+		unix int64
+		want string
+	}{
+		"59":          {59, "94287082"},
+		"1111111109":  {1111111109, "07081804"},
+		"1111111111":  {1111111111, "14050471"},
+		"1234567890":  {1234567890, "89005924"},
+		"2000000000":  {2000000000, "69279037"},
+		"20000000000": {20000000000, "65353130"},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, hotp(key, Counter(time.Unix(tt.unix, 0)), 8))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	const secret = "JBSWY3DPEHPK3PXP"
+	now := time.Unix(1650000000, 0)
+
+	code, err := Code(secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// code of a step out of the skew
+	old, err := Code(secret, now.Add(-2*Period))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		secret string
+		at     time.Time
+		code   string
+		ok     bool
+	}{
+		"current step":     {secret, now, code, true},
+		"client ahead":     {secret, now.Add(-Period), code, true},
+		"client behind":    {secret, now.Add(Period), code, true},
+		"out of skew":      {secret, now, old, false},
+		"wrong length":     {secret, now, code + "0", false},
+		"lowercase secret": {strings.ToLower(secret), now, code, true},
+		"invalid secret":   {"!", now, code, false},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			counter, ok := Validate(tt.secret, tt.code, tt.at)
+			assert.Equal(t, tt.ok, ok)
+			if ok {
+				assert.Equal(t, Counter(now), counter)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	assert.Nil(t, err)
+	b, err := GenerateSecret()
+	assert.Nil(t, err)
+
+	assert.Len(t, a, 32)
+	assert.NotEqual(t, a, b)
+
+	_, err = Code(a, time.Now())
+	assert.Nil(t, err)
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("yatter", "john", "JBSWY3DPEHPK3PXP")
+	assert.Equal(t, "otpauth://totp/yatter:john?algorithm=SHA1&digits=6&issuer=yatter&period=30&secret=JBSWY3DPEHPK3PXP", uri)
+}
@@ -0,0 +1,130 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+)
+
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	rec := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return rec
+}
+
+func attr(span sdktrace.ReadOnlySpan, key string) interface{} {
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value.AsInterface()
+		}
+	}
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	rec := setupRecorder(t)
+
+	var requestID, traceID string
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Use(middleware.RequestID)
+	r.Get("/v1/accounts/{username}", func(w http.ResponseWriter, r *http.Request) {
+		requestID = middleware.GetReqID(r.Context())
+		traceID = TraceID(r.Context())
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/accounts/john", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := rec.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /v1/accounts/{username}", span.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String(), "trace context is continued")
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.Equal(t, int64(500), attr(span, string(semconv.HTTPStatusCodeKey)))
+	assert.Equal(t, "Error", span.Status().Code.String())
+
+	assert.Equal(t, traceID, requestID, "request ID is correlated with trace ID")
+}
+
+func TestSanitize(t *testing.T) {
+	cases := map[string]struct {
+		query string
+		want  string
+	}{
+		"placeholders are kept": {
+			query: "SELECT * FROM account WHERE username = ?",
+			want:  "SELECT * FROM account WHERE username = ?",
+		},
+		"literals are replaced": {
+			query: "SELECT * FROM account WHERE username = 'john' AND id > 10 AND note = \"it\\\"s\"",
+			want:  "SELECT * FROM account WHERE username = ? AND id > ? AND note = ?",
+		},
+		"identifiers with digits are kept": {
+			query: "UPDATE t1 SET col2 = 3.5\n\t\tWHERE id = ?",
+			want:  "UPDATE t1 SET col2 = ? WHERE id = ?",
+		},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Sanitize(tt.query))
+		})
+	}
+}
+
+type dsnConnector struct {
+	dsn string
+	drv driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.drv.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.drv }
+
+func TestWrapConnector(t *testing.T) {
+	rec := setupRecorder(t)
+
+	mockDB, mock, err := sqlmock.NewWithDSN("tracing_test")
+	require.NoError(t, err)
+	defer mockDB.Close()
+	mock.ExpectExec("UPDATE account").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	db := sql.OpenDB(WrapConnector(dsnConnector{dsn: "tracing_test", drv: mockDB.Driver()}))
+	defer db.Close()
+
+	ctx, span := Start(context.Background(), "dao account.Update")
+	_, err = db.ExecContext(ctx, "UPDATE account SET note = 'secret' WHERE id = ?", 1)
+	require.NoError(t, err)
+	span.End()
+
+	// untraced queries are not recorded
+	mock.ExpectExec("UPDATE account").WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.ExecContext(context.Background(), "UPDATE account SET note = ''")
+	require.NoError(t, err)
+
+	spans := rec.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "sql exec", spans[0].Name())
+	assert.Equal(t, "UPDATE account SET note = ? WHERE id = ?", attr(spans[0], string(semconv.DBStatementKey)))
+	assert.Equal(t, span.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
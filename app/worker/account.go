@@ -0,0 +1,223 @@
+package worker
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"yatter-backend-go/app/config"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/object"
+)
+
+const (
+	// Number of statuses read at once while exporting
+	exportBatchSize = 100
+
+	// Upper bound of follows listed in an export
+	exportFollowLimit = 1 << 20
+)
+
+// Columns of following.csv, which Mastodon exports and imports
+var followingHeader = []string{"Account address", "Show boosts", "Notify on new posts", "Languages"}
+
+// PurgeAccount returns handler for object.JobPurgeAccount
+func PurgeAccount(d dao.Dao) Handler {
+	return func(ctx context.Context, job *object.Job) error {
+		var payload dao.AccountPayload
+		if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+			return err
+		}
+		return d.Account().Purge(ctx, payload.AccountID)
+	}
+}
+
+// ExportAccount returns handler for object.JobExportAccount
+// The archive is written to Media.ExportsDir and contains profile.json, statuses.json,
+// following.csv, followers.csv and the media of the statuses under media/.
+// Accounts are listed by `username@host` of Server.PublicURL, as Mastodon lists them.
+func ExportAccount(d dao.Dao, cfg *config.Store) Handler {
+	return func(ctx context.Context, job *object.Job) error {
+		var payload dao.ExportAccountPayload
+		if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+			return err
+		}
+
+		repo := d.AccountExport()
+		export, err := repo.FindByID(ctx, payload.ExportID)
+		if err != nil {
+			return err
+		} else if export == nil || export.State != object.ExportPending {
+			return nil
+		}
+
+		account, err := d.Account().FindByID(ctx, export.AccountID)
+		if err != nil {
+			return err
+		} else if account == nil {
+			// deleted in the meantime
+			return repo.Fail(ctx, export.ID)
+		}
+
+		c := cfg.Get()
+		path, err := writeExport(ctx, d, account, export.ID, c.Media.ExportsDir, localHost(c.Server.PublicURL))
+		if err != nil {
+			if job.Attempts >= job.MaxAttempts {
+				if err := repo.Fail(ctx, export.ID); err != nil {
+					return err
+				}
+			}
+			return err
+		}
+
+		return repo.Complete(ctx, export.ID, path)
+	}
+}
+
+// exportProfile is profile.json of an export, the email address is included unlike the API
+type exportProfile struct {
+	*object.Account
+	Email *string `json:"email,omitempty"`
+}
+
+// writeExport builds the archive in a temporary file and renames it when complete
+func writeExport(ctx context.Context, d dao.Dao, account *object.Account, exportID int64, dir, host string) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	tmp, err := ioutil.TempFile(dir, ".export-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	err = writeArchive(ctx, d, account, host, tmp)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("export-%d.zip", exportID))
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func writeArchive(ctx context.Context, d dao.Dao, account *object.Account, host string, w io.Writer) error {
+	z := zip.NewWriter(w)
+
+	if err := writeJSON(z, "profile.json", exportProfile{Account: account, Email: account.Email}); err != nil {
+		return err
+	}
+
+	statuses := []object.Status{}
+	var sinceID int64
+	for {
+		page, err := d.Status().ListByID(ctx, account.ID, 0, sinceID, exportBatchSize)
+		if err != nil {
+			return err
+		}
+		statuses = append(statuses, page...)
+		if len(page) < exportBatchSize {
+			break
+		}
+		sinceID = page[len(page)-1].ID + 1
+	}
+	if err := writeJSON(z, "statuses.json", statuses); err != nil {
+		return err
+	}
+
+	following, err := d.Account().FindFollowing(ctx, account.ID, exportFollowLimit)
+	if err != nil {
+		return err
+	}
+	// boosts are shown and nothing is notified, as following from the API does
+	if err := writeAddresses(z, "following.csv", followingHeader, following, host, []string{"true", "false", ""}); err != nil {
+		return err
+	}
+	followers, err := d.Account().FindFollowers(ctx, account.ID, 0, 0, exportFollowLimit)
+	if err != nil {
+		return err
+	}
+	if err := writeAddresses(z, "followers.csv", followingHeader[:1], followers, host, nil); err != nil {
+		return err
+	}
+
+	// identical uploads share the file
+	written := map[string]bool{}
+	for _, status := range statuses {
+		for _, attachment := range status.MediaAttachments {
+			if attachment.FilePath == "" || written[attachment.FilePath] {
+				continue
+			}
+			written[attachment.FilePath] = true
+			if err := copyFile(z, "media/"+filepath.Base(attachment.FilePath), attachment.FilePath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return z.Close()
+}
+
+func writeJSON(z *zip.Writer, name string, v interface{}) error {
+	f, err := z.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeAddresses writes a CSV of the addresses of accounts on host followed by columns
+func writeAddresses(z *zip.Writer, name string, header []string, accounts []object.Account, host string, columns []string) error {
+	f, err := z.Create(name)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, a := range accounts {
+		if a.DeletedAt != nil {
+			continue
+		}
+		address := a.Username
+		if host != "" {
+			address += "@" + host
+		}
+		if err := w.Write(append([]string{address}, columns...)); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func copyFile(z *zip.Writer, name, path string) error {
+	src, err := os.Open(path)
+	if os.IsNotExist(err) {
+		// the status was deleted in the meantime
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	f, err := z.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, src)
+	return err
+}
@@ -0,0 +1,103 @@
+package worker
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"yatter-backend-go/app/config"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/mock"
+	"yatter-backend-go/app/domain/object"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportAccount(t *testing.T) {
+	dir := t.TempDir()
+	media := filepath.Join(dir, "photo.png")
+	if err := ioutil.WriteFile(media, []byte("png"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	email := "john@example.com"
+	john := &object.Account{ID: 1, Username: "john", Email: &email}
+	d := dao.NewMock(&mock.AccountMock{
+		FindByIDFunc: func(ctx context.Context, id int64) (*object.Account, error) {
+			return john, nil
+		},
+		FindFollowingFunc: func(ctx context.Context, followerID, limit int64) ([]object.Account, error) {
+			return []object.Account{{Username: "jane"}}, nil
+		},
+		FindFollowersFunc: func(ctx context.Context, followeeID, maxID, sinceID, limit int64) ([]object.Account, error) {
+			return []object.Account{{Username: "bob"}, {Username: "gone", DeletedAt: &object.DateTime{}}}, nil
+		},
+	}, &mock.StatusMock{
+		ListByIDFunc: func(ctx context.Context, id, maxID, sinceID, limit int64) ([]object.Status, error) {
+			// two pages, statuses share the media
+			var page []object.Status
+			for i := sinceID; i < sinceID+limit && i < exportBatchSize+2; i++ {
+				page = append(page, object.Status{ID: i, MediaAttachments: []object.Attachment{{FilePath: media}}})
+			}
+			return page, nil
+		},
+	}, nil)
+
+	export := &object.AccountExport{ID: 7, AccountID: john.ID, State: object.ExportPending}
+	var path string
+	d.AccountExportMock = &mock.AccountExportMock{
+		FindByIDFunc: func(ctx context.Context, id int64) (*object.AccountExport, error) {
+			return export, nil
+		},
+		CompleteFunc: func(ctx context.Context, id int64, p string) error {
+			path = p
+			return nil
+		},
+	}
+
+	cfg := config.Default()
+	cfg.Media.ExportsDir = filepath.Join(dir, "exports")
+	h := ExportAccount(d, config.NewStore("", cfg))
+
+	err := h(context.Background(), &object.Job{Payload: `{"export_id":7}`, Attempts: 1, MaxAttempts: 5})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, filepath.Join(cfg.Media.ExportsDir, "export-7.zip"), path)
+
+	z, err := zip.OpenReader(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer z.Close()
+
+	files := map[string]string{}
+	for _, f := range z.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(b)
+	}
+
+	assert.Len(t, files, 5)
+	assert.Equal(t, "Account address,Show boosts,Notify on new posts,Languages\njane@localhost:8080,true,false,\n", files["following.csv"])
+	assert.Equal(t, "Account address\nbob@localhost:8080\n", files["followers.csv"])
+	assert.Equal(t, "png", files["media/photo.png"])
+
+	var profile map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(files["profile.json"]), &profile))
+	assert.Equal(t, "john", profile["username"])
+	assert.Equal(t, email, profile["email"])
+
+	var statuses []object.Status
+	assert.NoError(t, json.Unmarshal([]byte(files["statuses.json"]), &statuses))
+	assert.Len(t, statuses, exportBatchSize+2)
+}
@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"yatter-backend-go/app/config"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+)
+
+// Number of rows applied between progress updates
+const importBatchSize = 100
+
+// ImportAccount returns handler for object.JobImportAccount
+// Rows are applied from the recorded progress, so a retried job does not start over.
+func ImportAccount(d dao.Dao, cfg *config.Store) Handler {
+	return func(ctx context.Context, job *object.Job) error {
+		var payload dao.ImportAccountPayload
+		if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+			return err
+		}
+
+		repo := d.AccountImport()
+		imp, err := repo.FindByID(ctx, payload.ImportID)
+		if err != nil {
+			return err
+		} else if imp == nil || imp.State != object.ImportPending {
+			return nil
+		}
+
+		err = applyImport(ctx, d, imp, localHost(cfg.Get().Server.PublicURL))
+		if err != nil {
+			if job.Attempts >= job.MaxAttempts {
+				if err := repo.Finish(ctx, imp.ID, object.ImportFailed); err != nil {
+					return err
+				}
+			}
+			return err
+		}
+
+		return repo.Finish(ctx, imp.ID, object.ImportDone)
+	}
+}
+
+// localHost returns the host of publicURL, which addresses of local accounts end with
+func localHost(publicURL string) string {
+	u, err := url.Parse(publicURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+func applyImport(ctx context.Context, d dao.Dao, imp *object.AccountImport, host string) error {
+	account, err := d.Account().FindByID(ctx, imp.AccountID)
+	if err != nil {
+		return err
+	} else if account == nil {
+		return fmt.Errorf("account %d is deleted", imp.AccountID)
+	}
+
+	var accts []string
+	if err := json.Unmarshal([]byte(imp.Data), &accts); err != nil {
+		return err
+	}
+
+	for start := imp.ProcessedRows; start < len(accts); start += importBatchSize {
+		end := start + importBatchSize
+		if end > len(accts) {
+			end = len(accts)
+		}
+
+		var failures []object.ImportFailure
+		for i := start; i < end; i++ {
+			reason, err := importRow(ctx, d, account, imp.Type, accts[i], host)
+			if err != nil {
+				return err
+			}
+			if reason != "" {
+				failures = append(failures, object.ImportFailure{Row: i + 1, Acct: accts[i], Reason: reason})
+			}
+		}
+
+		if err := d.AccountImport().Progress(ctx, imp.ID, end, failures); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importRow applies acct by importType, the reason is returned if the row cannot be applied
+func importRow(ctx context.Context, d dao.Dao, account *object.Account, importType, acct, host string) (string, error) {
+	username := strings.TrimPrefix(acct, "@")
+	if i := strings.Index(username, "@"); i >= 0 {
+		// addresses exported by this server have its domain
+		if !strings.EqualFold(username[i+1:], host) {
+			return "remote accounts are not supported", nil
+		}
+		username = username[:i]
+	}
+
+	repo := d.Account()
+	target, err := repo.FindByUsername(ctx, username)
+	if err != nil {
+		return "", err
+	} else if target == nil {
+		return "account not found", nil
+	}
+
+	switch importType {
+	case object.ImportBlocking:
+		if target.ID == account.ID {
+			return "cannot block yourself", nil
+		}
+		return "", repo.Block(ctx, account.ID, target.ID)
+	case object.ImportMuting:
+		if target.ID == account.ID {
+			return "cannot mute yourself", nil
+		}
+		return "", repo.Mute(ctx, account.ID, target.ID)
+	}
+	return importFollow(ctx, repo, account, target)
+}
+
+// importFollow follows target unless it is already followed
+func importFollow(ctx context.Context, repo repository.Account, account, target *object.Account) (string, error) {
+	if target.ID == account.ID {
+		return "cannot follow yourself", nil
+	}
+
+	following, _, err := repo.FindRelationship(ctx, account.ID, target.ID)
+	if err != nil {
+		return "", err
+	} else if following {
+		return "", nil
+	}
+
+	_, _, err = repo.Follow(ctx, account.ID, target.ID)
+	var (
+		forbidden *repository.ForbiddenError
+		conflict  *repository.ConflictError
+	)
+	if errors.As(err, &forbidden) {
+		return "blocked", nil
+	} else if errors.As(err, &conflict) {
+		// followed since the relationship was read
+		return "", nil
+	}
+	return "", err
+}
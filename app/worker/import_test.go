@@ -0,0 +1,161 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"yatter-backend-go/app/config"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/mock"
+	"yatter-backend-go/app/domain/object"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportAccount(t *testing.T) {
+	// rows after the first batch fail once, the retry resumes from the progress
+	// the domain of this server is stripped
+	accts := []string{"jane", "@bob", "john", "nobody", "alice@example.com", "jane", "@dave@Yatter.example"}
+	for i := 0; i < importBatchSize; i++ {
+		accts = append(accts, "bob")
+	}
+	accts = append(accts, "carol")
+	data, err := json.Marshal(accts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accounts := map[string]*object.Account{
+		"john":  {ID: 1, Username: "john"},
+		"jane":  {ID: 2, Username: "jane"},
+		"bob":   {ID: 3, Username: "bob"},
+		"carol": {ID: 4, Username: "carol"},
+		"dave":  {ID: 5, Username: "dave"},
+	}
+	following := map[int64]bool{}
+	failCarol := true
+	d := dao.NewMock(&mock.AccountMock{
+		FindByIDFunc: func(ctx context.Context, id int64) (*object.Account, error) {
+			return accounts["john"], nil
+		},
+		FindByUsernameFunc: func(ctx context.Context, username string) (*object.Account, error) {
+			return accounts[username], nil
+		},
+		FindRelationshipFunc: func(ctx context.Context, userID, targetID int64) (bool, bool, error) {
+			return following[targetID], false, nil
+		},
+		FollowFunc: func(ctx context.Context, followerID, followeeID int64) (int64, bool, error) {
+			if followeeID == 4 && failCarol {
+				failCarol = false
+				return 0, false, errors.New("connection reset")
+			}
+			if following[followeeID] {
+				return 0, false, fmt.Errorf("duplicate follow of %d", followeeID)
+			}
+			following[followeeID] = true
+			return followeeID, false, nil
+		},
+	}, nil, nil)
+
+	imp := &object.AccountImport{ID: 1, AccountID: 1, Type: object.ImportFollowing, State: object.ImportPending, Data: string(data), TotalRows: len(accts)}
+	var failures []object.ImportFailure
+	d.AccountImportMock = &mock.AccountImportMock{
+		FindByIDFunc: func(ctx context.Context, id int64) (*object.AccountImport, error) {
+			copied := *imp
+			return &copied, nil
+		},
+		ProgressFunc: func(ctx context.Context, id int64, processedRows int, f []object.ImportFailure) error {
+			imp.ProcessedRows = processedRows
+			failures = append(failures, f...)
+			return nil
+		},
+		FinishFunc: func(ctx context.Context, id int64, state string) error {
+			imp.State = state
+			return nil
+		},
+	}
+
+	cfg := config.Default()
+	cfg.Server.PublicURL = "https://yatter.example"
+	h := ImportAccount(d, config.NewStore("", cfg))
+	job := &object.Job{Payload: `{"import_id":1}`, Attempts: 1, MaxAttempts: 5}
+
+	assert.Error(t, h(context.Background(), job))
+	assert.Equal(t, importBatchSize, imp.ProcessedRows)
+	assert.Equal(t, object.ImportPending, imp.State)
+
+	job.Attempts++
+	assert.NoError(t, h(context.Background(), job))
+	assert.Equal(t, len(accts), imp.ProcessedRows)
+	assert.Equal(t, object.ImportDone, imp.State)
+
+	assert.Equal(t, map[int64]bool{2: true, 3: true, 4: true, 5: true}, following)
+	assert.Equal(t, []object.ImportFailure{
+		{Row: 3, Acct: "john", Reason: "cannot follow yourself"},
+		{Row: 4, Acct: "nobody", Reason: "account not found"},
+		{Row: 5, Acct: "alice@example.com", Reason: "remote accounts are not supported"},
+	}, failures)
+}
+
+func TestImportAccountBlockingAndMuting(t *testing.T) {
+	accounts := map[string]*object.Account{
+		"john": {ID: 1, Username: "john"},
+		"jane": {ID: 2, Username: "jane"},
+	}
+	data, err := json.Marshal([]string{"jane", "john", "bob@other.example"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, importType := range []string{object.ImportBlocking, object.ImportMuting} {
+		importType := importType
+		t.Run(importType, func(t *testing.T) {
+			applied := map[string][]int64{}
+			apply := func(kind string) func(ctx context.Context, accountID, targetID int64) error {
+				return func(ctx context.Context, accountID, targetID int64) error {
+					applied[kind] = append(applied[kind], targetID)
+					return nil
+				}
+			}
+			d := dao.NewMock(&mock.AccountMock{
+				FindByIDFunc: func(ctx context.Context, id int64) (*object.Account, error) {
+					return accounts["john"], nil
+				},
+				FindByUsernameFunc: func(ctx context.Context, username string) (*object.Account, error) {
+					return accounts[username], nil
+				},
+				BlockFunc: apply(object.ImportBlocking),
+				MuteFunc:  apply(object.ImportMuting),
+			}, nil, nil)
+
+			imp := &object.AccountImport{ID: 1, AccountID: 1, Type: importType, State: object.ImportPending, Data: string(data), TotalRows: 3}
+			var failures []object.ImportFailure
+			d.AccountImportMock = &mock.AccountImportMock{
+				FindByIDFunc: func(ctx context.Context, id int64) (*object.AccountImport, error) {
+					return imp, nil
+				},
+				ProgressFunc: func(ctx context.Context, id int64, processedRows int, f []object.ImportFailure) error {
+					failures = append(failures, f...)
+					return nil
+				},
+				FinishFunc: func(ctx context.Context, id int64, state string) error {
+					imp.State = state
+					return nil
+				},
+			}
+
+			h := ImportAccount(d, config.NewStore("", config.Default()))
+			assert.NoError(t, h(context.Background(), &object.Job{Payload: `{"import_id":1}`, Attempts: 1, MaxAttempts: 5}))
+
+			assert.Equal(t, object.ImportDone, imp.State)
+			assert.Equal(t, map[string][]int64{importType: {2}}, applied)
+			verb := map[string]string{object.ImportBlocking: "block", object.ImportMuting: "mute"}[importType]
+			assert.Equal(t, []object.ImportFailure{
+				{Row: 2, Acct: "john", Reason: "cannot " + verb + " yourself"},
+				{Row: 3, Acct: "bob@other.example", Reason: "remote accounts are not supported"},
+			}, failures)
+		})
+	}
+}
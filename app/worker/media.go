@@ -0,0 +1,276 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+	"yatter-backend-go/app/config"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/mediatype"
+	"yatter-backend-go/app/probe"
+	"yatter-backend-go/app/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	// register decoders for image.Decode, every image type of the upload allowlist is decodable
+	_ "image/gif"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+// Longest side of the preview image
+const previewSize = 400
+
+// ProcessAttachment returns handler for object.JobProcessAttachment
+// ffmpeg is used to transcode gifv and to extract video frames, it is skipped if not found or disabled
+func ProcessAttachment(d dao.Dao, cfg *config.Store) Handler {
+	return func(ctx context.Context, job *object.Job) error {
+		c := cfg.Get()
+		ffmpeg := ""
+		if c.Features.Transcoding {
+			ffmpeg = lookFFmpeg(c.Media.FFmpegPath)
+		}
+
+		var payload dao.ProcessAttachmentPayload
+		if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+			return err
+		}
+
+		repo := d.Attachment()
+		attachment, err := repo.FindByID(ctx, payload.AttachmentID)
+		if err != nil {
+			return err
+		} else if attachment == nil || attachment.State != object.AttachmentProcessing {
+			return nil
+		}
+
+		pctx, span := tracing.Start(ctx, "media.Process",
+			attribute.Int64("attachment.id", attachment.ID), attribute.String("attachment.type", attachment.Type))
+		err = processAttachment(pctx, attachment, c.Media.FilesDir, c.Server.PublicURL, ffmpeg)
+		tracing.End(span, err)
+
+		if err != nil {
+			if job.Attempts >= job.MaxAttempts {
+				attachment.State = object.AttachmentFailed
+				if err := repo.UpdateProcessed(ctx, attachment); err != nil {
+					return err
+				}
+			}
+			return err
+		}
+
+		attachment.State = object.AttachmentReady
+		return repo.UpdateProcessed(ctx, attachment)
+	}
+}
+
+func lookFFmpeg(ffmpeg string) string {
+	if ffmpeg == "" {
+		return ""
+	}
+	path, err := exec.LookPath(ffmpeg)
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+func processAttachment(ctx context.Context, a *object.Attachment, fileDir, baseURL, ffmpeg string) error {
+	meta := &object.AttachmentMeta{}
+
+	switch a.Type {
+	case mediatype.Image:
+		preview, err := writePreview(fileDir, baseURL, a.FilePath, a.FilePath, meta)
+		if err != nil {
+			return err
+		}
+		a.PreviewURL = &preview
+
+	case mediatype.Gifv:
+		preview, err := writePreview(fileDir, baseURL, a.FilePath, a.FilePath, meta)
+		if err != nil {
+			return err
+		}
+		a.PreviewURL = &preview
+
+		if ffmpeg != "" {
+			// the original gif is kept as it is the shared blob
+			mp4 := replaceExt(a.FilePath, ".mp4")
+			if err := runFFmpeg(ctx, ffmpeg, mp4, "-i", a.FilePath, "-movflags", "faststart", "-pix_fmt", "yuv420p",
+				"-vf", "scale=trunc(iw/2)*2:trunc(ih/2)*2"); err != nil {
+				return err
+			}
+			a.FilePath = mp4
+
+			if err := probeFile(a.FilePath, meta); err != nil {
+				return err
+			}
+		}
+
+	case mediatype.Video:
+		if ffmpeg != "" {
+			frame := replaceExt(a.FilePath, "_frame.jpg")
+			if err := runFFmpeg(ctx, ffmpeg, frame, "-i", a.FilePath, "-frames:v", "1"); err != nil {
+				return err
+			}
+			defer os.Remove(frame)
+
+			preview, err := writePreview(fileDir, baseURL, frame, a.FilePath, meta)
+			if err != nil {
+				return err
+			}
+			a.PreviewURL = &preview
+		}
+
+		if err := probeFile(a.FilePath, meta); err != nil {
+			return err
+		}
+
+	case mediatype.Audio:
+		if err := probeFile(a.FilePath, meta); err != nil {
+			return err
+		}
+	}
+
+	url := dao.FileURL(baseURL, fileDir, a.FilePath)
+	a.URL = &url
+	if meta.Original != nil {
+		a.Meta = meta
+	}
+
+	return nil
+}
+
+// MIME types of stored files to probe
+var probeTypes = map[string]string{
+	".mp4":  "video/mp4",
+	".mov":  "video/quicktime",
+	".webm": "video/webm",
+	".mp3":  "audio/mpeg",
+	".ogg":  "audio/ogg",
+	".m4a":  "audio/mp4",
+}
+
+// probeFile reads stream information of the file into meta
+func probeFile(path string, meta *object.AttachmentMeta) error {
+	mime, ok := probeTypes[filepath.Ext(path)]
+	if !ok {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := probe.Probe(f, mime)
+	if err != nil {
+		return fmt.Errorf("probe %s: %w", path, err)
+	}
+
+	mediatype.FillMeta(meta, info)
+	return nil
+}
+
+// writePreview scales down the image at src and stores it next to the original file
+func writePreview(fileDir, baseURL, src, original string, meta *object.AttachmentMeta) (string, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return "", fmt.Errorf("decode %s: %w", src, err)
+	}
+	b := img.Bounds()
+	meta.Original = object.NewMediaInfo(b.Dx(), b.Dy())
+
+	small := scaleDown(img, previewSize)
+	meta.Small = object.NewMediaInfo(small.Bounds().Dx(), small.Bounds().Dy())
+
+	// identical uploads share the preview, so it is replaced atomically
+	dstName := replaceExt(original, "_small.jpg")
+	dst, err := ioutil.TempFile(filepath.Dir(dstName), ".preview-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(dst.Name())
+
+	err = jpeg.Encode(dst, small, &jpeg.Options{Quality: 80})
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := os.Rename(dst.Name(), dstName); err != nil {
+		return "", err
+	}
+
+	return dao.FileURL(baseURL, fileDir, dstName), nil
+}
+
+// scaleDown fits img into size x size with nearest neighbor sampling
+func scaleDown(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+
+	dw, dh := size, h*size/w
+	if h > w {
+		dw, dh = w*size/h, size
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			dst.Set(x, y, img.At(b.Min.X+x*w/dw, b.Min.Y+y*h/dh))
+		}
+	}
+	return dst
+}
+
+// runFFmpeg writes the output to a temporary file and renames it to dst
+func runFFmpeg(ctx context.Context, ffmpeg, dst string, args ...string) (err error) {
+	ctx, span := tracing.Start(ctx, "media.FFmpeg", attribute.String("media.output", filepath.Base(dst)))
+	defer func() { tracing.End(span, err) }()
+
+	tmp := filepath.Join(filepath.Dir(dst), fmt.Sprintf(".ffmpeg-%d-%s", time.Now().UnixNano(), filepath.Base(dst)))
+	defer os.Remove(tmp)
+
+	args = append([]string{"-y", "-loglevel", "error"}, args...)
+	args = append(args, tmp)
+	out, err := exec.CommandContext(ctx, ffmpeg, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return os.Rename(tmp, dst)
+}
+
+func replaceExt(path, ext string) string {
+	if i := strings.LastIndex(path, "."); i > strings.LastIndex(path, "/") {
+		path = path[:i]
+	}
+	return path + ext
+}
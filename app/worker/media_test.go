@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/mediatype"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessAttachmentWebP(t *testing.T) {
+	fixture, err := ioutil.ReadFile(filepath.Join("testdata", "small.webp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ab", "abcdef.webp")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, fixture, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &object.Attachment{Type: mediatype.Image, FilePath: path}
+	assert.NoError(t, processAttachment(context.Background(), a, dir, "https://example.com", ""))
+
+	if assert.NotNil(t, a.PreviewURL) {
+		assert.Equal(t, "https://example.com/v1/media/files/ab/abcdef_small.jpg", *a.PreviewURL)
+	}
+	assert.FileExists(t, filepath.Join(dir, "ab", "abcdef_small.jpg"))
+	if assert.NotNil(t, a.Meta) {
+		assert.Greater(t, a.Meta.Original.Width, 0)
+		assert.Greater(t, a.Meta.Original.Height, 0)
+	}
+}
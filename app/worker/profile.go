@@ -0,0 +1,203 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+	"yatter-backend-go/app/config"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/logger"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	// Bytes of a linked page read to find rel="me" links
+	maxVerifyBody = 1 << 20
+
+	// Time to fetch a linked page
+	verifyTimeout = 10 * time.Second
+
+	// Redirects followed while fetching a linked page
+	maxVerifyRedirects = 3
+)
+
+// Fetcher fetches the HTML page at url, replaced in tests
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) ([]byte, error)
+}
+
+// VerifyProfile returns handler for object.JobVerifyProfile
+// A field is verified when the page of its value links back to the profile with rel="me".
+// Pages which cannot be fetched are left unverified without retrying.
+func VerifyProfile(d dao.Dao, cfg *config.Store, fetcher Fetcher) Handler {
+	return func(ctx context.Context, job *object.Job) error {
+		var payload dao.AccountPayload
+		if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+			return err
+		}
+
+		account, err := d.Account().FindByID(ctx, payload.AccountID)
+		if err != nil {
+			return err
+		} else if account == nil {
+			return nil
+		}
+
+		profile := account.ProfileURL(cfg.Get().Server.PublicURL)
+		var verified []string
+		for _, field := range account.Fields {
+			if field.VerifiedAt != nil || !field.IsLink() {
+				continue
+			}
+			page, err := fetcher.Fetch(ctx, field.Value)
+			if err != nil {
+				logger.FromContext(ctx).Info("profile link not fetched", "account_id", account.ID, "error", err)
+				continue
+			}
+			if linksBack(page, profile) {
+				verified = append(verified, field.Value)
+			}
+		}
+		if len(verified) == 0 {
+			return nil
+		}
+		return d.Account().VerifyFields(ctx, account.ID, verified)
+	}
+}
+
+// linksBack reports whether page has an <a> or <link> element whose rel has "me"
+// and whose href is profile
+func linksBack(page []byte, profile string) bool {
+	z := html.NewTokenizer(bytes.NewReader(page))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return false
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if !hasAttr || (string(name) != "a" && string(name) != "link") {
+				continue
+			}
+			var rel, href string
+			for {
+				key, val, more := z.TagAttr()
+				switch string(key) {
+				case "rel":
+					rel = string(val)
+				case "href":
+					href = string(val)
+				}
+				if !more {
+					break
+				}
+			}
+			if hasRelMe(rel) && strings.EqualFold(strings.TrimSuffix(href, "/"), profile) {
+				return true
+			}
+		}
+	}
+}
+
+func hasRelMe(rel string) bool {
+	for _, v := range strings.Fields(rel) {
+		if strings.EqualFold(v, "me") {
+			return true
+		}
+	}
+	return false
+}
+
+// HTTPFetcher fetches pages over HTTP, refusing to connect to private addresses
+type HTTPFetcher struct {
+	client *http.Client
+}
+
+// Create HTTPFetcher
+func NewHTTPFetcher() *HTTPFetcher {
+	dialer := &net.Dialer{
+		Timeout: verifyTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublic(ip) {
+				return fmt.Errorf("refused to connect to %s", host)
+			}
+			return nil
+		},
+	}
+	return &HTTPFetcher{client: &http.Client{
+		Timeout:   verifyTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxVerifyRedirects {
+				return errors.New("too many redirects")
+			}
+			return nil
+		},
+	}}
+}
+
+// Fetch implements Fetcher
+func (f *HTTPFetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html")
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type")); mediaType != "text/html" {
+		return nil, fmt.Errorf("unexpected content type %q", mediaType)
+	}
+	return ioutil.ReadAll(io.LimitReader(res.Body, maxVerifyBody))
+}
+
+// Networks which are not reachable from the internet
+var privateNetworks = func() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16",
+		"172.16.0.0/12", "192.168.0.0/16", "::/128", "::1/128", "fc00::/7", "fe80::/10",
+	} {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+func isPublic(ip net.IP) bool {
+	if ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, n := range privateNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
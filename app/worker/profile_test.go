@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"yatter-backend-go/app/config"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/mock"
+	"yatter-backend-go/app/domain/object"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeFetcher map[string]string
+
+func (f fakeFetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
+	page, ok := f[url]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return []byte(page), nil
+}
+
+func TestVerifyProfile(t *testing.T) {
+	verifiedAt := &object.DateTime{}
+	account := &object.Account{ID: 1, Username: "john", Fields: object.ProfileFields{
+		{Name: "Blog", Value: "https://blog.example.com"},
+		{Name: "Site", Value: "https://example.com"},
+		{Name: "Old", Value: "https://old.example.com", VerifiedAt: verifiedAt},
+		{Name: "Down", Value: "https://down.example.com"},
+		{Name: "Pronouns", Value: "they/them"},
+	}}
+	fetcher := fakeFetcher{
+		"https://blog.example.com": `<html><head><link rel="me" href="https://yatter.example/@john"></head></html>`,
+		"https://example.com":      `<a rel="me" href="https://yatter.example/@jane">jane</a>`,
+		"https://old.example.com":  `<a rel="me" href="https://yatter.example/@john">john</a>`,
+	}
+
+	var verified []string
+	d := dao.NewMock(&mock.AccountMock{
+		FindByIDFunc: func(ctx context.Context, id int64) (*object.Account, error) {
+			return account, nil
+		},
+		VerifyFieldsFunc: func(ctx context.Context, id int64, values []string) error {
+			verified = values
+			return nil
+		},
+	}, nil, nil)
+
+	cfg := config.Default()
+	cfg.Server.PublicURL = "https://yatter.example/"
+	h := VerifyProfile(d, config.NewStore("", cfg), fetcher)
+
+	assert.NoError(t, h(context.Background(), &object.Job{Payload: `{"account_id":1}`}))
+	assert.Equal(t, []string{"https://blog.example.com"}, verified)
+}
+
+func TestLinksBack(t *testing.T) {
+	const profile = "https://yatter.example/@john"
+
+	tests := map[string]struct {
+		page string
+		want bool
+	}{
+		"anchor":         {`<p><a href="https://yatter.example/@john" rel="nofollow me">me</a></p>`, true},
+		"trailing slash": {`<a rel="me" href="https://yatter.example/@john/">me</a>`, true},
+		"link element":   {`<link rel="ME" href="https://yatter.example/@John"/>`, true},
+		"without rel me": {`<a rel="nofollow" href="https://yatter.example/@john">me</a>`, false},
+		"other profile":  {`<a rel="me" href="https://yatter.example/@johnny">me</a>`, false},
+		"other element":  {`<img rel="me" href="https://yatter.example/@john">`, false},
+		"text only":      {`https://yatter.example/@john`, false},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, linksBack([]byte(tt.page), profile))
+		})
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.216.34": true,
+		"127.0.0.1":     false,
+		"10.1.2.3":      false,
+		"192.168.0.1":   false,
+		"169.254.1.1":   false,
+		"::1":           false,
+		"fd00::1":       false,
+		"2606:2800::1":  true,
+	} {
+		assert.Equal(t, want, isPublic(net.ParseIP(addr)), addr)
+	}
+}
@@ -0,0 +1,141 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"yatter-backend-go/app/domain/object"
+	"yatter-backend-go/app/domain/repository"
+	"yatter-backend-go/app/logger"
+	"yatter-backend-go/app/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	defaultInterval = 2 * time.Second
+	defaultLease    = 5 * time.Minute
+	backoffUnit     = 10 * time.Second
+)
+
+// Handler processes a job, returning an error schedules a retry
+type Handler func(ctx context.Context, job *object.Job) error
+
+// Worker runs jobs of the durable queue
+type Worker struct {
+	repo     repository.Job
+	handlers map[string]Handler
+
+	// Interval to poll the queue when it is empty
+	Interval time.Duration
+
+	// Duration a claimed job is locked, it is claimed again after that
+	Lease time.Duration
+
+	Logger *logger.Logger
+
+	quit     chan struct{}
+	quitOnce sync.Once
+	done     chan struct{}
+}
+
+// Create worker
+func New(repo repository.Job) *Worker {
+	return &Worker{
+		repo:     repo,
+		handlers: make(map[string]Handler),
+		Interval: defaultInterval,
+		Lease:    defaultLease,
+		Logger:   logger.Default(),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Register handler for the kind of job
+func (w *Worker) Handle(kind string, h Handler) {
+	w.handlers[kind] = h
+}
+
+// Run jobs until ctx is canceled or Shutdown is called
+func (w *Worker) Run(ctx context.Context) {
+	defer close(w.done)
+
+	for {
+		select {
+		case <-w.quit:
+			return
+		default:
+		}
+
+		ran, err := w.RunOnce(ctx)
+		if err != nil {
+			w.Logger.Error("job failed", "error", err)
+		}
+		if ran && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.quit:
+			return
+		case <-time.After(w.Interval):
+		}
+	}
+}
+
+// Shutdown stops claiming jobs and waits for Run to return
+// The running job is not interrupted, if ctx is done first it is claimed again after the lease.
+func (w *Worker) Shutdown(ctx context.Context) error {
+	w.quitOnce.Do(func() { close(w.quit) })
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RunOnce claims and runs a job, it reports whether a job was run
+func (w *Worker) RunOnce(ctx context.Context) (bool, error) {
+	job, err := w.repo.Claim(ctx, w.Lease)
+	if err != nil {
+		return false, err
+	} else if job == nil {
+		return false, nil
+	}
+
+	jobCtx, span := tracing.Start(ctx, "job "+job.Kind,
+		attribute.Int64("job.id", job.ID), attribute.Int("job.attempts", job.Attempts))
+	err = w.run(jobCtx, job)
+	tracing.End(span, err)
+
+	if err != nil {
+		backoff := time.Duration(job.Attempts*job.Attempts) * backoffUnit
+		if err := w.repo.Fail(ctx, job.ID, err.Error(), backoff); err != nil {
+			return true, err
+		}
+		return true, fmt.Errorf("job %d (%s) failed at attempt %d: %w", job.ID, job.Kind, job.Attempts, err)
+	}
+
+	return true, w.repo.Complete(ctx, job.ID)
+}
+
+func (w *Worker) run(ctx context.Context, job *object.Job) (err error) {
+	h, ok := w.handlers[job.Kind]
+	if !ok {
+		return fmt.Errorf("no handler for job kind %q", job.Kind)
+	}
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("panic: %v", rec)
+		}
+	}()
+
+	return h(ctx, job)
+}
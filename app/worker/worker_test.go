@@ -0,0 +1,129 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"yatter-backend-go/app/domain/mock"
+	"yatter-backend-go/app/domain/object"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorker_RunOnce(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		ran       bool
+		completed bool
+		failed    bool
+		backoff   time.Duration
+		expectErr bool
+	}
+	cases := map[string]struct {
+		job     *object.Job
+		handler Handler
+		want    want
+	}{
+		"empty queue": {
+			job:  nil,
+			want: want{ran: false},
+		},
+		"success": {
+			job: &object.Job{ID: 1, Kind: "test", Attempts: 1},
+			handler: func(ctx context.Context, job *object.Job) error {
+				return nil
+			},
+			want: want{ran: true, completed: true},
+		},
+		"failure is retried with backoff": {
+			job: &object.Job{ID: 1, Kind: "test", Attempts: 2},
+			handler: func(ctx context.Context, job *object.Job) error {
+				return errors.New("failed")
+			},
+			want: want{ran: true, failed: true, backoff: 4 * backoffUnit, expectErr: true},
+		},
+		"panic is recovered": {
+			job: &object.Job{ID: 1, Kind: "test", Attempts: 1},
+			handler: func(ctx context.Context, job *object.Job) error {
+				panic("boom")
+			},
+			want: want{ran: true, failed: true, backoff: backoffUnit, expectErr: true},
+		},
+		"unknown kind": {
+			job:  &object.Job{ID: 1, Kind: "unknown", Attempts: 1},
+			want: want{ran: true, failed: true, backoff: backoffUnit, expectErr: true},
+		},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			var completed, failed bool
+			var backoff time.Duration
+
+			w := New(&mock.JobMock{
+				ClaimFunc: func(ctx context.Context, lease time.Duration) (*object.Job, error) {
+					return tt.job, nil
+				},
+				CompleteFunc: func(ctx context.Context, id int64) error {
+					completed = true
+					return nil
+				},
+				FailFunc: func(ctx context.Context, id int64, reason string, d time.Duration) error {
+					failed = true
+					backoff = d
+					return nil
+				},
+			})
+			if tt.handler != nil {
+				w.Handle("test", tt.handler)
+			}
+
+			ran, err := w.RunOnce(context.Background())
+
+			assert.Equal(t, tt.want.ran, ran)
+			assert.Equal(t, tt.want.expectErr, err != nil)
+			assert.Equal(t, tt.want.completed, completed)
+			assert.Equal(t, tt.want.failed, failed)
+			assert.Equal(t, tt.want.backoff, backoff)
+		})
+	}
+}
+
+func TestWorker_Shutdown(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var claims, completed int32
+
+	w := New(&mock.JobMock{
+		ClaimFunc: func(ctx context.Context, lease time.Duration) (*object.Job, error) {
+			claims++
+			return &object.Job{ID: int64(claims), Kind: "test", Attempts: 1}, nil
+		},
+		CompleteFunc: func(ctx context.Context, id int64) error {
+			completed++
+			return nil
+		},
+	})
+	w.Handle("test", func(ctx context.Context, job *object.Job) error {
+		close(started)
+		<-release
+		return nil
+	})
+	go w.Run(context.Background())
+
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	// the running job is not finished yet
+	assert.ErrorIs(t, w.Shutdown(ctx), context.DeadlineExceeded)
+
+	close(release)
+	assert.NoError(t, w.Shutdown(context.Background()))
+	assert.Equal(t, int32(1), claims, "no job is claimed after shutdown")
+	assert.Equal(t, int32(1), completed, "running job is completed")
+}
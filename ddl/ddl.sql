@@ -1,6 +1,6 @@
 CREATE TABLE `account` (
   `id` bigint(20) NOT NULL AUTO_INCREMENT,
-  `username` varchar(255) NOT NULL UNIQUE,
+  `username` varchar(255) COLLATE utf8mb4_general_ci NOT NULL UNIQUE,
   `password_hash` varchar(255) NOT NULL,
   `display_name` varchar(255),
   `create_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
@@ -9,6 +9,24 @@ CREATE TABLE `account` (
   `note` text,
   `avatar` text,
   `header` text,
+  `avatar_attachment_id` bigint(20),
+  `header_attachment_id` bigint(20),
+  `fields` text,
+  `bot` boolean NOT NULL DEFAULT FALSE,
+  `discoverable` boolean NOT NULL DEFAULT FALSE,
+  `default_privacy` varchar(16) NOT NULL DEFAULT 'public',
+  `default_sensitive` boolean NOT NULL DEFAULT FALSE,
+  `default_language` varchar(8),
+  `email` varchar(255) UNIQUE,
+  `email_verified_at` datetime,
+  `deleted_at` datetime,
+  `role` varchar(16) NOT NULL DEFAULT 'user',
+  `suspended_at` datetime,
+  `silenced_at` datetime,
+  `sensitized_at` datetime,
+  `pending` boolean NOT NULL DEFAULT FALSE,
+  `registration_reason` text,
+  `invite_id` bigint(20),
   PRIMARY KEY (`id`)
 );
 
@@ -16,6 +34,7 @@ CREATE TABLE `status` (
   `id` bigint(20) NOT NULL AUTO_INCREMENT,
   `account_id` bigint(20) NOT NULL,
   `content` text NOT NULL,
+  `sensitive` boolean NOT NULL DEFAULT FALSE,
   `create_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
   PRIMARY KEY (`id`),
   INDEX `idx_account_id` (`account_id`),
@@ -34,12 +53,49 @@ CREATE TABLE `follow` (
   UNIQUE follow_combination (follower_id, followee_id)
 );
 
+CREATE TABLE `account_block` (
+  `account_id` bigint(20) NOT NULL,
+  `target_account_id` bigint(20) NOT NULL,
+  `create_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`account_id`, `target_account_id`),
+  INDEX `idx_target_account_id` (`target_account_id`),
+  CONSTRAINT `fk_account_block_account_id` FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_account_block_target_account_id` FOREIGN KEY (`target_account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `account_mute` (
+  `account_id` bigint(20) NOT NULL,
+  `target_account_id` bigint(20) NOT NULL,
+  `create_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`account_id`, `target_account_id`),
+  CONSTRAINT `fk_account_mute_account_id` FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_account_mute_target_account_id` FOREIGN KEY (`target_account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `media_blob` (
+  `hash` char(64) NOT NULL,
+  `path` varchar(255) NOT NULL,
+  `size` bigint(20) NOT NULL,
+  `ref_count` bigint(20) NOT NULL DEFAULT 0,
+  `create_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`hash`)
+);
+
 CREATE TABLE `attachment` (
   `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `account_id` bigint(20) NOT NULL,
   `type` text NOT NULL,
-  `url` text NOT NULL,
+  `url` text,
+  `preview_url` text,
   `description` varchar(420),
-  PRIMARY KEY (`id`)
+  `meta` json,
+  `file_path` text NOT NULL,
+  `blob_hash` char(64),
+  `state` varchar(16) NOT NULL DEFAULT 'ready',
+  PRIMARY KEY (`id`),
+  INDEX `idx_attachment_account_id` (`account_id`),
+  CONSTRAINT `fk_attachment_account_id` FOREIGN KEY (`account_id`) REFERENCES `account` (`id`),
+  CONSTRAINT `fk_attachment_blob_hash` FOREIGN KEY (`blob_hash`) REFERENCES `media_blob` (`hash`)
 );
 
 CREATE TABLE `status_attachment` (
@@ -50,4 +106,199 @@ CREATE TABLE `status_attachment` (
   CONSTRAINT `fk_attachment_id` FOREIGN KEY (`attachment_id`) REFERENCES  `attachment` (`id`),
   PRIMARY KEY (`id`),
   UNIQUE st_at (status_id, attachment_id)
-)
+);
+
+CREATE TABLE `job` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `kind` varchar(64) NOT NULL,
+  `payload` text NOT NULL,
+  `state` varchar(16) NOT NULL DEFAULT 'pending',
+  `attempts` int NOT NULL DEFAULT 0,
+  `max_attempts` int NOT NULL DEFAULT 5,
+  `last_error` text,
+  `run_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `locked_at` datetime,
+  `create_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  INDEX `idx_state_run_at` (`state`, `run_at`)
+);
+
+CREATE TABLE `login_activity` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `account_id` bigint(20) NOT NULL,
+  `success` boolean NOT NULL,
+  `failure_reason` varchar(64),
+  `ip` varchar(45) NOT NULL,
+  `user_agent` varchar(255) NOT NULL DEFAULT '',
+  `create_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  INDEX `idx_account_id` (`account_id`, `id`),
+  CONSTRAINT `fk_login_activity_account_id` FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `two_factor` (
+  `account_id` bigint(20) NOT NULL,
+  `secret` varchar(64) NOT NULL,
+  `enabled` boolean NOT NULL DEFAULT FALSE,
+  `last_counter` bigint(20) NOT NULL DEFAULT 0,
+  `create_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`account_id`),
+  CONSTRAINT `fk_two_factor_account_id` FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `recovery_code` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `account_id` bigint(20) NOT NULL,
+  `code_hash` char(64) NOT NULL,
+  `used_at` datetime,
+  PRIMARY KEY (`id`),
+  UNIQUE `idx_account_code` (`account_id`, `code_hash`),
+  CONSTRAINT `fk_recovery_code_account_id` FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `account_token` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `account_id` bigint(20) NOT NULL,
+  `purpose` varchar(32) NOT NULL,
+  `token_hash` char(64) NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `used_at` datetime,
+  `create_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE `idx_token_hash` (`token_hash`),
+  INDEX `idx_account_purpose` (`account_id`, `purpose`),
+  CONSTRAINT `fk_account_token_account_id` FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `account_export` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `account_id` bigint(20) NOT NULL,
+  `state` varchar(16) NOT NULL DEFAULT 'pending',
+  `file_path` text,
+  `create_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `completed_at` datetime,
+  PRIMARY KEY (`id`),
+  INDEX `idx_account_id` (`account_id`, `id`),
+  CONSTRAINT `fk_account_export_account_id` FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `account_import` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `account_id` bigint(20) NOT NULL,
+  `type` varchar(16) NOT NULL,
+  `state` varchar(16) NOT NULL DEFAULT 'pending',
+  `data` mediumtext NOT NULL,
+  `total_rows` int NOT NULL,
+  `processed_rows` int NOT NULL DEFAULT 0,
+  `create_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `completed_at` datetime,
+  PRIMARY KEY (`id`),
+  INDEX `idx_account_id` (`account_id`, `id`),
+  CONSTRAINT `fk_account_import_account_id` FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `account_import_failure` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `import_id` bigint(20) NOT NULL,
+  `row_index` int NOT NULL,
+  `acct` varchar(255) NOT NULL,
+  `reason` varchar(255) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE `idx_import_row` (`import_id`, `row_index`),
+  CONSTRAINT `fk_account_import_failure_import_id` FOREIGN KEY (`import_id`) REFERENCES `account_import` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `report` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `account_id` bigint(20) NOT NULL,
+  `target_account_id` bigint(20) NOT NULL,
+  `category` varchar(16) NOT NULL,
+  `comment` text NOT NULL,
+  `state` varchar(16) NOT NULL DEFAULT 'open',
+  `assigned_account_id` bigint(20),
+  `create_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `resolved_at` datetime,
+  PRIMARY KEY (`id`),
+  INDEX `idx_state` (`state`, `id`),
+  INDEX `idx_target_account_id` (`target_account_id`, `id`),
+  CONSTRAINT `fk_report_account_id` FOREIGN KEY (`account_id`) REFERENCES `account` (`id`),
+  CONSTRAINT `fk_report_target_account_id` FOREIGN KEY (`target_account_id`) REFERENCES `account` (`id`),
+  CONSTRAINT `fk_report_assigned_account_id` FOREIGN KEY (`assigned_account_id`) REFERENCES `account` (`id`)
+);
+
+-- Snapshots hold a reference to media blobs, so the statuses can be deleted
+CREATE TABLE `report_status` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `report_id` bigint(20) NOT NULL,
+  `status_id` bigint(20) NOT NULL,
+  `content` text NOT NULL,
+  `sensitive` boolean NOT NULL,
+  `media_attachments` json NOT NULL,
+  `create_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE `idx_report_status` (`report_id`, `status_id`),
+  CONSTRAINT `fk_report_status_report_id` FOREIGN KEY (`report_id`) REFERENCES `report` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `report_note` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `report_id` bigint(20) NOT NULL,
+  `account_id` bigint(20) NOT NULL,
+  `content` text NOT NULL,
+  `create_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  INDEX `idx_report_id` (`report_id`, `id`),
+  CONSTRAINT `fk_report_note_report_id` FOREIGN KEY (`report_id`) REFERENCES `report` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `report_history` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `report_id` bigint(20) NOT NULL,
+  `account_id` bigint(20) NOT NULL,
+  `action` varchar(16) NOT NULL,
+  `assigned_account_id` bigint(20),
+  `create_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  INDEX `idx_report_id` (`report_id`, `id`),
+  CONSTRAINT `fk_report_history_report_id` FOREIGN KEY (`report_id`) REFERENCES `report` (`id`) ON DELETE CASCADE
+);
+
+-- Append only, rows are written in the transaction of the action and never updated nor deleted
+CREATE TABLE `admin_action_log` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `account_id` bigint(20) NOT NULL,
+  `action` varchar(32) NOT NULL,
+  `target_type` varchar(16) NOT NULL,
+  `target_id` bigint(20) NOT NULL,
+  `before_state` json,
+  `after_state` json,
+  `create_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  INDEX `idx_account_id` (`account_id`, `id`),
+  INDEX `idx_action` (`action`, `id`),
+  INDEX `idx_target` (`target_type`, `target_id`, `id`),
+  CONSTRAINT `fk_admin_action_log_account_id` FOREIGN KEY (`account_id`) REFERENCES `account` (`id`)
+);
+
+CREATE TABLE `invite` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `account_id` bigint(20) NOT NULL,
+  `code` varchar(32) NOT NULL,
+  `max_uses` int,
+  `uses` int NOT NULL DEFAULT 0,
+  `expires_at` datetime,
+  `create_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE `idx_code` (`code`),
+  INDEX `idx_account_id` (`account_id`, `id`),
+  CONSTRAINT `fk_invite_account_id` FOREIGN KEY (`account_id`) REFERENCES `account` (`id`)
+);
+
+-- Bump with `dao.SchemaVersion` when the schema is changed
+CREATE TABLE `schema_version` (
+  `version` int NOT NULL,
+  `applied_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`version`)
+);
+
+INSERT INTO `schema_version` (`version`) VALUES (16)
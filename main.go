@@ -2,29 +2,101 @@ package main
 
 import (
 	"context"
-	"log"
+	"flag"
+	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 
 	"yatter-backend-go/app/app"
 	"yatter-backend-go/app/config"
+	"yatter-backend-go/app/dao"
+	"yatter-backend-go/app/domain/object"
 	"yatter-backend-go/app/handler"
+	"yatter-backend-go/app/logger"
+	"yatter-backend-go/app/metrics"
+	"yatter-backend-go/app/tracing"
+	"yatter-backend-go/app/worker"
 
 	"github.com/go-playground/validator/v10"
 )
 
 func main() {
-	log.Fatalf("%+v", serve(context.Background()))
+	path := flag.String("config", "", "path to YAML configuration file")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	if err := serve(ctx, *path); err != nil {
+		logger.Default().Error("server failed", "error", fmt.Sprintf("%+v", err))
+		os.Exit(1)
+	}
+	logger.Default().Info("server stopped")
 }
 
-func serve(ctx context.Context) error {
-	app, err := app.NewApp()
+func serve(ctx context.Context, path string) error {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	// validated on load
+	level, _ := logger.ParseLevel(cfg.Log.Level)
+	log := logger.New(os.Stdout, level)
+	logger.SetDefault(log)
+	log.Info("configuration loaded", "config", cfg.Redacted())
+
+	store := config.NewStore(path, cfg)
+	store.OnReload(func(c *config.Config) {
+		level, _ := logger.ParseLevel(c.Log.Level)
+		log.SetLevel(level)
+	})
+	go store.WatchSignal(ctx.Done())
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		return err
+	}
+	dao.AddQueryHook(metrics.QueryHook)
+	dao.AddQueryHook(tracing.QueryHook)
+	dao.WrapConnector(tracing.WrapConnector)
+
+	app, err := app.NewApp(store, log)
 	if err != nil {
 		return err
 	}
 	v := validator.New()
-	addr := ":" + strconv.Itoa(config.Port())
-	log.Printf("Serve on http://%s", addr)
 
-	return http.ListenAndServe(addr, handler.NewRouter(app, v))
+	metrics.RegisterDBStats(app.Dao.Stats)
+
+	w := worker.New(app.Dao.Job())
+	w.Interval = cfg.Worker.PollInterval
+	w.Lease = cfg.Worker.Lease
+	w.Logger = log.With("component", "worker")
+	w.Handle(object.JobProcessAttachment, worker.ProcessAttachment(app.Dao, store))
+	w.Handle(object.JobPurgeAccount, worker.PurgeAccount(app.Dao))
+	w.Handle(object.JobExportAccount, worker.ExportAccount(app.Dao, store))
+	w.Handle(object.JobImportAccount, worker.ImportAccount(app.Dao, store))
+	w.Handle(object.JobVerifyProfile, worker.VerifyProfile(app.Dao, store, worker.NewHTTPFetcher()))
+	go w.Run(context.Background())
+
+	// stopped in order after connections are drained
+	app.Lifecycle.OnStop("worker", w.Shutdown)
+	app.Lifecycle.OnStop("dao", func(context.Context) error {
+		return app.Dao.Close()
+	})
+	app.Lifecycle.OnStop("tracing", shutdownTracing)
+
+	srv := &http.Server{
+		Addr:         ":" + strconv.Itoa(cfg.Server.Port),
+		Handler:      handler.NewRouter(app, v),
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+	log.Info("serve", "addr", srv.Addr)
+
+	return app.Lifecycle.Serve(ctx, srv)
 }